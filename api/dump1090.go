@@ -0,0 +1,251 @@
+package api
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/geo"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+// Aircraft is an entry of aircraft.json. Optional fields are omitted when unknown.
+type Aircraft struct {
+	Hex      string      `json:"hex"`
+	Flight   string      `json:"flight,omitempty"`
+	AltBaro  interface{} `json:"alt_baro,omitempty"`
+	Gs       *float64    `json:"gs,omitempty"`
+	Ias      *float64    `json:"ias,omitempty"`
+	Tas      *float64    `json:"tas,omitempty"`
+	Track    *float64    `json:"track,omitempty"`
+	BaroRate *int32      `json:"baro_rate,omitempty"`
+	GeomRate *int32      `json:"geom_rate,omitempty"`
+	Squawk   string      `json:"squawk,omitempty"`
+	Lat      *float64    `json:"lat,omitempty"`
+	Lon      *float64    `json:"lon,omitempty"`
+	SeenPos  *float64    `json:"seen_pos,omitempty"`
+	Messages int         `json:"messages"`
+	Seen     float64     `json:"seen"`
+	Rssi     float64     `json:"rssi"`
+	// MLAT lists the fields derived from multilateration, as readsb does.
+	MLAT []string `json:"mlat,omitempty"`
+	// Receivers names the inputs that heard the aircraft, when there are several.
+	Receivers []string `json:"receivers,omitempty"`
+	// Registration, Type, Desc, Operator and DBFlags are the aircraft database details, named as in readsb. DBFlags
+	// is 1 for military aircraft.
+	Registration string `json:"r,omitempty"`
+	Type         string `json:"t,omitempty"`
+	Desc         string `json:"desc,omitempty"`
+	Operator     string `json:"ownOp,omitempty"`
+	DBFlags      int    `json:"dbFlags,omitempty"`
+	// Country is the state the address was allocated to.
+	Country string `json:"country,omitempty"`
+	// Airline and Telephony are the operator of the callsign, Origin and Destination the ICAO airports of its route.
+	Airline     string `json:"airline,omitempty"`
+	Telephony   string `json:"telephony,omitempty"`
+	Origin      string `json:"origin,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	// Dst is the distance from the receiver in nautical miles, Dir the bearing from the receiver in degrees.
+	Dst *float64 `json:"r_dst,omitempty"`
+	Dir *float64 `json:"r_dir,omitempty"`
+	// MagHeading is the magnetic heading that comes with an airspeed, Track is only set with a ground speed.
+	MagHeading *float64 `json:"mag_heading,omitempty"`
+}
+
+// AircraftFile is the contents of aircraft.json.
+type AircraftFile struct {
+	Now      float64    `json:"now"`
+	Messages uint64     `json:"messages"`
+	Aircraft []Aircraft `json:"aircraft"`
+}
+
+// ReceiverFile is the contents of receiver.json.
+type ReceiverFile struct {
+	Version string  `json:"version"`
+	Refresh int     `json:"refresh"`
+	History int     `json:"history"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// StatsPeriod is a single period of stats.json.
+type StatsPeriod struct {
+	Start     float64     `json:"start"`
+	End       float64     `json:"end"`
+	Messages  uint64      `json:"messages"`
+	Local     StatsLocal  `json:"local"`
+	Tracks    StatsTracks `json:"tracks"`
+	Positions uint64      `json:"positions"`
+}
+
+// StatsLocal holds the frame counts of a period.
+type StatsLocal struct {
+	Accepted []uint64 `json:"accepted"`
+}
+
+// StatsTracks holds the track counts of a period.
+type StatsTracks struct {
+	All uint64 `json:"all"`
+}
+
+// StatsFile is the contents of stats.json. The tracker keeps statistics per minute, so as in dump1090-fa Latest is the
+// current, incomplete minute, and the other periods include it.
+type StatsFile struct {
+	Latest    StatsPeriod `json:"latest"`
+	Last1Min  StatsPeriod `json:"last1min"`
+	Last5Min  StatsPeriod `json:"last5min"`
+	Last15Min StatsPeriod `json:"last15min"`
+	Total     StatsPeriod `json:"total"`
+}
+
+// TrackFile is the contents of track/<icao>.json, the recent positions of one aircraft. Every point is
+// [unix time, latitude, longitude, altitude], with the altitude "ground" for surface positions.
+type TrackFile struct {
+	Hex   string          `json:"hex"`
+	Track [][]interface{} `json:"track"`
+}
+
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return math.Round(float64(t.UnixNano())/1e6) / 1e3
+}
+
+func ageSeconds(now time.Time, t time.Time) float64 {
+	age := now.Sub(t).Seconds()
+	if age < 0 {
+		age = 0
+	}
+	return math.Round(age*10) / 10
+}
+
+// NewAircraft converts a tracked flight into an aircraft.json entry.
+func NewAircraft(f models.Flight, now time.Time) Aircraft {
+	a := Aircraft{
+		Hex:      strings.ToLower(f.Icao),
+		Flight:   strings.TrimRight(f.Callsign, " #"),
+		Squawk:   f.Squawk,
+		Messages: f.Messages,
+		Seen:     ageSeconds(now, f.LastSeen),
+		Rssi:     f.RSSI,
+	}
+
+	if len(f.Receivers) > 1 {
+		a.Receivers = f.Receivers
+	}
+
+	a.Registration, a.Type, a.Operator, a.Country = f.Registration, f.TypeCode, f.Operator, f.Country
+	a.Desc = strings.TrimSpace(f.Manufacturer + " " + f.Model)
+	if f.Military {
+		a.DBFlags = 1
+	}
+	a.Airline, a.Telephony, a.Origin, a.Destination = f.Airline, f.Telephony, f.Origin, f.Destination
+
+	if f.OnGround {
+		a.AltBaro = "ground"
+	} else if f.Altitude != 0 {
+		a.AltBaro = f.Altitude
+	}
+
+	v := f.Velocity
+	if v.SpeedType != "" {
+		speed, angle := v.Speed, v.Angle
+		switch v.SpeedType {
+		case "IAS":
+			a.Ias, a.MagHeading = &speed, &angle
+		case "TAS":
+			a.Tas, a.MagHeading = &speed, &angle
+		default:
+			a.Gs, a.Track = &speed, &angle
+		}
+
+		rate := v.VertRate
+		if v.RateSource == "GNSS" {
+			a.GeomRate = &rate
+		} else if v.RateSource != "" {
+			a.BaroRate = &rate
+		}
+	}
+
+	if !f.LastPositionTime.IsZero() {
+		lat, lon := f.Position.Latitude, f.Position.Longitude
+		seenPos := ageSeconds(now, f.LastPositionTime)
+		a.Lat, a.Lon, a.SeenPos = &lat, &lon, &seenPos
+		dst := math.Round(geo.MetersToNauticalMiles(f.Distance)*1000) / 1000
+		dir := math.Round(f.Bearing*10) / 10
+		a.Dst, a.Dir = &dst, &dir
+		if f.MLAT {
+			a.MLAT = []string{"lat", "lon"}
+		}
+	}
+
+	return a
+}
+
+func aircraftFile(tracker *streaming.Tracker) AircraftFile {
+	now := tracker.Now()
+
+	flights := tracker.Flights()
+	file := AircraftFile{
+		Now:      unixSeconds(now),
+		Messages: tracker.Totals().Accepted,
+		Aircraft: make([]Aircraft, 0, len(flights)),
+	}
+
+	for _, f := range flights {
+		file.Aircraft = append(file.Aircraft, NewAircraft(f, now))
+	}
+
+	return file
+}
+
+func receiverFile(tracker *streaming.Tracker) ReceiverFile {
+	lat, lon := tracker.Receiver()
+
+	return ReceiverFile{
+		Version: Version,
+		Refresh: 1000,
+		History: 0,
+		Lat:     lat,
+		Lon:     lon,
+	}
+}
+
+func statsPeriod(p streaming.Period) StatsPeriod {
+	return StatsPeriod{
+		Start:     unixSeconds(p.Start),
+		End:       unixSeconds(p.End),
+		Messages:  p.Accepted,
+		Local:     StatsLocal{Accepted: []uint64{p.Accepted}},
+		Tracks:    StatsTracks{All: p.NewFlights},
+		Positions: p.Positions,
+	}
+}
+
+func statsFile(tracker *streaming.Tracker) StatsFile {
+	now := tracker.Now()
+
+	return StatsFile{
+		Latest:    statsPeriod(tracker.Stats(now.Sub(now.Truncate(time.Minute)))),
+		Last1Min:  statsPeriod(tracker.Stats(time.Minute)),
+		Last5Min:  statsPeriod(tracker.Stats(5 * time.Minute)),
+		Last15Min: statsPeriod(tracker.Stats(15 * time.Minute)),
+		Total:     statsPeriod(tracker.Totals()),
+	}
+}
+
+func trackFile(f models.Flight) TrackFile {
+	file := TrackFile{Hex: strings.ToLower(f.Icao), Track: make([][]interface{}, 0, len(f.Track))}
+
+	for _, p := range f.Track {
+		var alt interface{} = p.Altitude
+		if p.OnGround {
+			alt = "ground"
+		}
+		file.Track = append(file.Track, []interface{}{unixSeconds(p.Time), p.Latitude, p.Longitude, alt})
+	}
+
+	return file
+}
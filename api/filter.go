@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// BBox is a latitude/longitude bounding box. A box with West greater than East crosses the antimeridian.
+type BBox struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// Contains reports whether the position lies inside the box.
+func (b BBox) Contains(lat float64, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return lon >= b.West && lon <= b.East
+	}
+	return lon >= b.West || lon <= b.East
+}
+
+// Filter selects the aircraft a client is interested in. Unset criteria match every aircraft.
+type Filter struct {
+	// BBox only matches aircraft with a position inside the box.
+	BBox *BBox
+	// MinAltitude and MaxAltitude only match aircraft with a known altitude in the band, in feet. Aircraft on the
+	// ground are at altitude 0.
+	MinAltitude *int
+	MaxAltitude *int
+	// Icaos only matches the listed addresses.
+	Icaos map[string]bool
+}
+
+// ParseFilter reads a filter from the query parameters bbox=south,west,north,east, min_alt, max_alt and icao, a comma
+// separated list of addresses.
+func ParseFilter(q url.Values) (Filter, error) {
+	var f Filter
+
+	if s := q.Get("bbox"); s != "" {
+		parts := strings.Split(s, ",")
+		if len(parts) != 4 {
+			return Filter{}, fmt.Errorf("bbox needs 4 values, got %d", len(parts))
+		}
+
+		var v [4]float64
+		for i, p := range parts {
+			n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return Filter{}, fmt.Errorf("invalid bbox value %q", p)
+			}
+			v[i] = n
+		}
+		if v[0] > v[2] {
+			return Filter{}, fmt.Errorf("bbox south %v is north of %v", v[0], v[2])
+		}
+
+		f.BBox = &BBox{South: v[0], West: v[1], North: v[2], East: v[3]}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"min_alt", &f.MinAltitude}, {"max_alt", &f.MaxAltitude}} {
+		if s := q.Get(p.name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return Filter{}, fmt.Errorf("invalid %s %q", p.name, s)
+			}
+			*p.dst = &n
+		}
+	}
+
+	if s := q.Get("icao"); s != "" {
+		f.Icaos = make(map[string]bool)
+		for _, icao := range strings.Split(s, ",") {
+			f.Icaos[strings.ToUpper(strings.TrimSpace(icao))] = true
+		}
+	}
+
+	return f, nil
+}
+
+// Match reports whether the flight passes the filter.
+func (f Filter) Match(flight models.Flight) bool {
+	if f.Icaos != nil && !f.Icaos[flight.Icao] {
+		return false
+	}
+
+	if f.BBox != nil {
+		if flight.LastPositionTime.IsZero() || !f.BBox.Contains(flight.Position.Latitude, flight.Position.Longitude) {
+			return false
+		}
+	}
+
+	if f.MinAltitude != nil || f.MaxAltitude != nil {
+		alt := flight.Altitude
+		if flight.OnGround {
+			alt = 0
+		} else if alt == 0 {
+			return false
+		}
+
+		if f.MinAltitude != nil && alt < *f.MinAltitude {
+			return false
+		}
+		if f.MaxAltitude != nil && alt > *f.MaxAltitude {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+// Package api serves the tracker state over HTTP, using the JSON schema of dump1090 so that existing web frontends
+// such as tar1090 can be pointed at goModeS.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+// Version is reported in receiver.json.
+const Version = "goModeS"
+
+// Server serves aircraft.json, receiver.json, stats.json, per-aircraft tracks and a WebSocket stream of changes at ws.
+// All of them are available both at the root and below /data/, where dump1090 keeps its files.
+type Server struct {
+	tracker *streaming.Tracker
+	mux     *http.ServeMux
+}
+
+// NewServer returns a Server for the given tracker.
+func NewServer(tracker *streaming.Tracker) *Server {
+	s := &Server{tracker: tracker, mux: http.NewServeMux()}
+
+	for _, prefix := range []string{"/", "/data/"} {
+		s.mux.HandleFunc(prefix+"aircraft.json", s.handleAircraft)
+		s.mux.HandleFunc(prefix+"receiver.json", s.handleReceiver)
+		s.mux.HandleFunc(prefix+"stats.json", s.handleStats)
+		s.mux.HandleFunc(prefix+"track/", s.handleTrack)
+		s.mux.HandleFunc(prefix+"ws", s.handleWebSocket)
+	}
+
+	return s
+}
+
+// Handle registers an additional handler, so other packages can extend the API.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// frontends are often served from a different origin
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		// Shutdown does not wait for WebSocket connections, they end with the request context instead
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *Server) handleAircraft(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, aircraftFile(s.tracker))
+}
+
+func (s *Server) handleReceiver(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, receiverFile(s.tracker))
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, statsFile(s.tracker))
+}
+
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	icao := strings.ToUpper(strings.TrimSuffix(name, ".json"))
+
+	f, ok := s.tracker.Flight(icao)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeJSON(w, trackFile(f))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
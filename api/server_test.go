@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+func testTracker() *streaming.Tracker {
+	now := time.Unix(1457996410, 0)
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(func() time.Time { return now }))
+
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: time.Unix(1457996400, 0), RSSI: -12.5})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: time.Unix(1457996402, 0)})
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: time.Unix(1457996405, 0)})
+
+	return tracker
+}
+
+func get(t *testing.T, s *Server, path string, v interface{}) int {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("invalid JSON from %v: %v", path, err)
+		}
+	}
+
+	return rec.Code
+}
+
+func TestAircraftJSON(t *testing.T) {
+	s := NewServer(testTracker())
+
+	for _, path := range []string{"/data/aircraft.json", "/aircraft.json"} {
+		var file struct {
+			Now      float64                  `json:"now"`
+			Messages uint64                   `json:"messages"`
+			Aircraft []map[string]interface{} `json:"aircraft"`
+		}
+		if code := get(t, s, path, &file); code != http.StatusOK {
+			t.Fatalf("%v status incorrect, wanted %v got %v", path, http.StatusOK, code)
+		}
+
+		if file.Now != 1457996410 {
+			t.Fatalf("now incorrect, wanted %v got %v", 1457996410, file.Now)
+		}
+		if file.Messages != 3 {
+			t.Fatalf("messages incorrect, wanted %v got %v", 3, file.Messages)
+		}
+		if len(file.Aircraft) != 2 {
+			t.Fatalf("aircraft count incorrect, wanted %v got %v", 2, len(file.Aircraft))
+		}
+
+		positioned := file.Aircraft[0]
+		if positioned["hex"] != "40621d" {
+			t.Fatalf("hex incorrect, wanted %v got %v", "40621d", positioned["hex"])
+		}
+		if positioned["lat"] != 52.2572 || positioned["lon"] != 3.91937 {
+			t.Fatalf("position incorrect, got %v %v", positioned["lat"], positioned["lon"])
+		}
+		if positioned["r_dst"] != 15.726 || positioned["r_dir"] != 349.1 {
+			t.Fatalf("range incorrect, got %v %v", positioned["r_dst"], positioned["r_dir"])
+		}
+		if positioned["alt_baro"] != 38000.0 {
+			t.Fatalf("alt_baro incorrect, wanted %v got %v", 38000, positioned["alt_baro"])
+		}
+		if positioned["seen_pos"] != 8.0 || positioned["seen"] != 8.0 {
+			t.Fatalf("seen incorrect, got %v %v", positioned["seen"], positioned["seen_pos"])
+		}
+		if positioned["rssi"] != -12.5 {
+			t.Fatalf("rssi incorrect, wanted %v got %v", -12.5, positioned["rssi"])
+		}
+
+		identified := file.Aircraft[1]
+		if identified["flight"] != "KLM1023" {
+			t.Fatalf("flight incorrect, wanted %v got %v", "KLM1023", identified["flight"])
+		}
+		if _, ok := identified["lat"]; ok {
+			t.Fatalf("lat should be omitted without a position")
+		}
+	}
+}
+
+func TestReceiverJSON(t *testing.T) {
+	var file ReceiverFile
+	if code := get(t, NewServer(testTracker()), "/data/receiver.json", &file); code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, code)
+	}
+
+	if file.Lat != 52.0 || file.Lon != 4.0 {
+		t.Fatalf("receiver position incorrect, got %v %v", file.Lat, file.Lon)
+	}
+	if file.Refresh != 1000 {
+		t.Fatalf("refresh incorrect, wanted %v got %v", 1000, file.Refresh)
+	}
+}
+
+func TestAircraftJSONHeading(t *testing.T) {
+	now := time.Unix(1457996410, 0)
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(func() time.Time { return now }))
+	// true airspeed with a magnetic heading
+	tracker.Update(models.Frame{Message: "8DA05F219B06B6AF189400CBC33F", Received: now})
+
+	var file struct {
+		Aircraft []map[string]interface{} `json:"aircraft"`
+	}
+	if code := get(t, NewServer(tracker), "/data/aircraft.json", &file); code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, code)
+	}
+
+	a := file.Aircraft[0]
+	if a["tas"] != 375.0 || a["mag_heading"] != 243.98 {
+		t.Fatalf("airspeed incorrect, wanted %v kt TAS at %v° got %v at %v", 375, 243.98, a["tas"], a["mag_heading"])
+	}
+	if _, ok := a["track"]; ok {
+		t.Fatalf("track should only be set with a ground speed, got %v", a["track"])
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	var file StatsFile
+	if code := get(t, NewServer(testTracker()), "/data/stats.json", &file); code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, code)
+	}
+
+	if file.Total.Messages != 3 {
+		t.Fatalf("total messages incorrect, wanted %v got %v", 3, file.Total.Messages)
+	}
+	if file.Total.Tracks.All != 2 {
+		t.Fatalf("total tracks incorrect, wanted %v got %v", 2, file.Total.Tracks.All)
+	}
+	if file.Last1Min.Messages != 3 {
+		t.Fatalf("last1min messages incorrect, wanted %v got %v", 3, file.Last1Min.Messages)
+	}
+}
+
+func TestStatsJSONLatest(t *testing.T) {
+	// half a minute later, latest still covers the whole current minute
+	now := time.Unix(1457996430, 0)
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(func() time.Time { return now }))
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: time.Unix(1457996405, 0)})
+
+	var file StatsFile
+	if code := get(t, NewServer(tracker), "/data/stats.json", &file); code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, code)
+	}
+
+	if file.Latest.Start != 1457996400 || file.Latest.End != 1457996430 {
+		t.Fatalf("latest period incorrect, wanted %v to %v got %v to %v", 1457996400, 1457996430, file.Latest.Start, file.Latest.End)
+	}
+	if file.Latest.Messages != 1 {
+		t.Fatalf("latest messages incorrect, wanted %v got %v", 1, file.Latest.Messages)
+	}
+}
+
+func TestTrackJSON(t *testing.T) {
+	s := NewServer(testTracker())
+
+	var file TrackFile
+	if code := get(t, s, "/data/track/40621d.json", &file); code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, code)
+	}
+
+	if file.Hex != "40621d" {
+		t.Fatalf("hex incorrect, wanted %v got %v", "40621d", file.Hex)
+	}
+	if len(file.Track) != 2 {
+		t.Fatalf("track length incorrect, wanted %v got %v", 2, len(file.Track))
+	}
+
+	if code := get(t, s, "/data/track/ABCDEF.json", &file); code != http.StatusNotFound {
+		t.Fatalf("status for unknown aircraft incorrect, wanted %v got %v", http.StatusNotFound, code)
+	}
+}
+
+func TestNewAircraftRegistry(t *testing.T) {
+	now := time.Unix(1457996410, 0)
+	f := models.Flight{Icao: "AE1234", LastSeen: now, Registration: "05-5140", TypeCode: "C17", Manufacturer: "Boeing", Model: "C-17A", Operator: "USAF", Military: true}
+
+	a := NewAircraft(f, now)
+	if a.Registration != "05-5140" || a.Type != "C17" || a.Desc != "Boeing C-17A" || a.Operator != "USAF" || a.DBFlags != 1 {
+		t.Fatalf("registry fields incorrect, got %+v", a)
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+const (
+	// wsBuffer is the number of tracker events queued per client before it is considered too slow and disconnected.
+	wsBuffer = 1024
+	// wsWriteTimeout bounds every write to a client.
+	wsWriteTimeout = 10 * time.Second
+	// wsPingInterval is how often idle clients are pinged.
+	wsPingInterval = 30 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	// like the JSON files, the stream may be used by frontends served from elsewhere
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Update is a single message on the WebSocket stream. For "new" the aircraft is complete, for "updated" it only holds
+// hex and the fields that changed since the last message about that aircraft (null if a field is no longer known),
+// and for "expired" only hex. An aircraft that no longer passes the client's filter is reported as expired.
+type Update struct {
+	Type     string                 `json:"type"`
+	Now      float64                `json:"now"`
+	Aircraft map[string]interface{} `json:"aircraft"`
+}
+
+// volatileFields change with every message and are left out of deltas, the message time tells the client the age.
+var volatileFields = []string{"seen", "seen_pos"}
+
+// wsClient tracks what was sent to a single WebSocket client.
+type wsClient struct {
+	conn   *websocket.Conn
+	filter Filter
+	sent   map[string]map[string]interface{}
+}
+
+// handleWebSocket streams tracker events. The client first receives every matching aircraft as "new", followed by
+// the changes as they happen. Filters are given as query parameters, see ParseFilter.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// subscribe before the snapshot so no change falls in between
+	sub := s.tracker.Subscribe(wsBuffer)
+	defer sub.Cancel()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	c := &wsClient{conn: conn, filter: filter, sent: make(map[string]map[string]interface{})}
+
+	// the reader handles pongs and close frames, and notices when the client goes away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	now := s.tracker.Now()
+	for _, f := range s.tracker.Flights() {
+		if err := c.handle(streaming.Event{Type: streaming.EventNew, Time: now, Flight: f}, now); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			c.close(websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-gone:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case e, ok := <-sub.C:
+			if !ok {
+				c.close(websocket.CloseTryAgainLater, "client too slow")
+				return
+			}
+			if err := c.handle(e, e.Time); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) close(code int, reason string) {
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+}
+
+// handle sends whatever the event changes from the client's point of view.
+func (c *wsClient) handle(e streaming.Event, now time.Time) error {
+	a := NewAircraft(e.Flight, now)
+	prev, known := c.sent[a.Hex]
+
+	if e.Type == streaming.EventExpired || !c.filter.Match(e.Flight) {
+		if !known {
+			return nil
+		}
+		delete(c.sent, a.Hex)
+		return c.write("expired", now, map[string]interface{}{"hex": a.Hex})
+	}
+
+	fields, err := aircraftFields(a)
+	if err != nil {
+		return err
+	}
+
+	if !known {
+		c.sent[a.Hex] = fields
+		return c.write("new", now, fields)
+	}
+
+	delta := diffFields(prev, fields)
+	c.sent[a.Hex] = fields
+	if len(delta) == 0 {
+		return nil
+	}
+	delta["hex"] = a.Hex
+
+	return c.write("updated", now, delta)
+}
+
+func (c *wsClient) write(typ string, now time.Time, aircraft map[string]interface{}) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return c.conn.WriteJSON(Update{Type: typ, Now: unixSeconds(now), Aircraft: aircraft})
+}
+
+// aircraftFields converts an aircraft to its JSON fields.
+func aircraftFields(a Aircraft) (map[string]interface{}, error) {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(b, &fields)
+	return fields, err
+}
+
+// diffFields returns the fields that differ between prev and next, ignoring volatile fields. Fields missing from next
+// are returned as nil. Values are compared deeply, lists such as mlat and receivers are not comparable with !=.
+func diffFields(prev map[string]interface{}, next map[string]interface{}) map[string]interface{} {
+	delta := make(map[string]interface{})
+
+	for k, v := range next {
+		if old, ok := prev[k]; !ok || !reflect.DeepEqual(old, v) {
+			delta[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			delta[k] = nil
+		}
+	}
+
+	for _, k := range volatileFields {
+		delete(delta, k)
+	}
+
+	return delta
+}
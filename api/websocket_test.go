@@ -0,0 +1,190 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+func readUpdate(t *testing.T, conn *websocket.Conn) Update {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var u Update
+	if err := conn.ReadJSON(&u); err != nil {
+		t.Fatalf("reading update: %v", err)
+	}
+
+	return u
+}
+
+func TestWebSocket(t *testing.T) {
+	now := time.Unix(1457996400, 0)
+	clock := &testClock{now: now}
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(clock.Now))
+
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: now})
+
+	srv := httptest.NewServer(NewServer(tracker))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/data/ws?icao=40621D&bbox=51,3,53,5"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// not in the ICAO list
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: now.Add(time.Second)})
+
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: now.Add(2 * time.Second)})
+	u := readUpdate(t, conn)
+	if u.Type != "new" {
+		t.Fatalf("type incorrect, wanted %v got %v", "new", u.Type)
+	}
+	if u.Aircraft["hex"] != "40621d" || u.Aircraft["alt_baro"] != 38000.0 {
+		t.Fatalf("aircraft incorrect, got %v", u.Aircraft)
+	}
+	if u.Now != 1457996402 {
+		t.Fatalf("now incorrect, wanted %v got %v", 1457996402, u.Now)
+	}
+
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: now.Add(4 * time.Second)})
+	u = readUpdate(t, conn)
+	if u.Type != "updated" {
+		t.Fatalf("type incorrect, wanted %v got %v", "updated", u.Type)
+	}
+	if u.Aircraft["lat"] != 52.2572 || u.Aircraft["lon"] != 3.91937 {
+		t.Fatalf("position incorrect, got %v %v", u.Aircraft["lat"], u.Aircraft["lon"])
+	}
+	if _, ok := u.Aircraft["alt_baro"]; ok {
+		t.Fatalf("unchanged alt_baro should not be sent")
+	}
+
+	clock.Set(now.Add(2 * time.Minute))
+	tracker.Expire()
+	u = readUpdate(t, conn)
+	if u.Type != "expired" || u.Aircraft["hex"] != "40621d" {
+		t.Fatalf("expected expiry of 40621d, got %v %v", u.Type, u.Aircraft)
+	}
+}
+
+func TestWebSocketListFields(t *testing.T) {
+	now := time.Unix(1457996400, 0)
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(func() time.Time { return now }))
+
+	srv := httptest.NewServer(NewServer(tracker))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/data/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// a multilaterated aircraft, updated while its mlat list stays the same
+	tracker.UpdateMLAT("4840D6", decode.Position{Latitude: 52.1, Longitude: 4.1}, now)
+	if u := readUpdate(t, conn); u.Type != "new" || u.Aircraft["mlat"] == nil {
+		t.Fatalf("expected a new multilaterated aircraft, got %v %v", u.Type, u.Aircraft)
+	}
+	tracker.UpdateMLAT("4840D6", decode.Position{Latitude: 52.2, Longitude: 4.1}, now.Add(time.Second))
+	u := readUpdate(t, conn)
+	if u.Type != "updated" || u.Aircraft["lat"] != 52.2 {
+		t.Fatalf("expected an updated position, got %v %v", u.Type, u.Aircraft)
+	}
+	if _, ok := u.Aircraft["mlat"]; ok {
+		t.Fatalf("unchanged mlat should not be sent")
+	}
+
+	// an aircraft heard by two receivers, then updated
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: now, Receiver: "a"})
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: now, Receiver: "b"})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: now.Add(2 * time.Second), Receiver: "a"})
+	for {
+		u = readUpdate(t, conn)
+		if u.Aircraft["hex"] == "40621d" && u.Aircraft["receivers"] != nil {
+			break
+		}
+	}
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: now.Add(4 * time.Second), Receiver: "a"})
+	u = readUpdate(t, conn)
+	if u.Type != "updated" || u.Aircraft["hex"] != "40621d" {
+		t.Fatalf("expected an update of 40621d, got %v %v", u.Type, u.Aircraft)
+	}
+	if _, ok := u.Aircraft["receivers"]; ok {
+		t.Fatalf("unchanged receivers should not be sent")
+	}
+}
+
+func TestWebSocketBadFilter(t *testing.T) {
+	srv := httptest.NewServer(NewServer(streaming.NewTracker(52.0, 4.0)))
+	defer srv.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws?bbox=1,2,3", nil)
+	if err == nil {
+		t.Fatalf("expected the handshake to fail")
+	}
+	if resp.StatusCode != 400 {
+		t.Fatalf("status incorrect, wanted %v got %v", 400, resp.StatusCode)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	airborne := models.Flight{Icao: "40621D", Altitude: 38000, Position: decode.Position{Latitude: 52.25, Longitude: 3.9}, LastPositionTime: time.Unix(1, 0)}
+	noPosition := models.Flight{Icao: "4840D6", Altitude: 3000}
+	ground := models.Flight{Icao: "484506", OnGround: true, Position: airborne.Position, LastPositionTime: time.Unix(1, 0)}
+
+	tests := []struct {
+		query string
+		want  []bool
+	}{
+		{"", []bool{true, true, true}},
+		{"bbox=52,3,53,4", []bool{true, false, true}},
+		{"bbox=52,4,53,3", []bool{false, false, false}},
+		{"bbox=52,170,53,4", []bool{true, false, true}},
+		{"min_alt=10000", []bool{true, false, false}},
+		{"max_alt=5000", []bool{false, true, true}},
+		{"icao=4840d6,484506", []bool{false, true, true}},
+	}
+
+	for _, test := range tests {
+		q, _ := url.ParseQuery(test.query)
+		f, err := ParseFilter(q)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", test.query, err)
+		}
+
+		for i, flight := range []models.Flight{airborne, noPosition, ground} {
+			if got := f.Match(flight); got != test.want[i] {
+				t.Errorf("%v: match of %v incorrect, wanted %v got %v", test.query, flight.Icao, test.want[i], got)
+			}
+		}
+	}
+}
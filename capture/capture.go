@@ -0,0 +1,136 @@
+// Package capture reads and writes recordings of received frames, keeping the receive time and signal metadata of
+// every frame so that a session can be replayed exactly.
+//
+// A capture is a text file with one frame per line:
+//
+//	2023-01-02T10:00:00.123456789Z 000000123456 -12.3 8D4840D6202CC371C32CE0576098
+//
+// The fields are the wall clock receive time, the receiver's 12 MHz MLAT timestamp in hex, the RSSI in dBFS and the
+// message. Lines starting with '#' are comments. Captures may be gzip compressed.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// Header is written at the top of every capture file.
+const Header = "# goModeS capture v1\n"
+
+// FormatFrame formats a frame as a capture line, including the trailing newline.
+func FormatFrame(frame models.Frame) string {
+	return fmt.Sprintf("%s %012X %s %s\n",
+		frame.Received.UTC().Format(time.RFC3339Nano),
+		frame.Timestamp,
+		strconv.FormatFloat(frame.RSSI, 'f', 1, 64),
+		frame.Message)
+}
+
+// ParseFrame parses a single capture line.
+func ParseFrame(line string) (models.Frame, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return models.Frame{}, fmt.Errorf("expected 4 fields, got %d", len(fields))
+	}
+
+	received, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return models.Frame{}, fmt.Errorf("invalid receive time: %w", err)
+	}
+
+	ts, err := strconv.ParseUint(fields[1], 16, 64)
+	if err != nil {
+		return models.Frame{}, fmt.Errorf("invalid timestamp: %w", err)
+	}
+
+	rssi, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return models.Frame{}, fmt.Errorf("invalid rssi: %w", err)
+	}
+
+	return models.Frame{
+		Message:   strings.ToUpper(fields[3]),
+		Timestamp: ts,
+		RSSI:      rssi,
+		Received:  received,
+	}, nil
+}
+
+// Reader reads frames from a capture.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a Reader for r. Gzip compressed input is detected and decompressed transparently.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(2)
+	if err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(gz)
+	}
+
+	return &Reader{r: br}, nil
+}
+
+// ReadFrame returns the next frame in the capture, or io.EOF at the end.
+func (c *Reader) ReadFrame() (models.Frame, error) {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return models.Frame{}, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		return ParseFrame(line)
+	}
+}
+
+// Writer writes frames to a capture.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteFrame appends a frame to the capture.
+func (c *Writer) WriteFrame(frame models.Frame) error {
+	_, err := io.WriteString(c.w, FormatFrame(frame))
+	return err
+}
+
+// Open opens a capture file for reading.
+func Open(path string) (*Reader, io.Closer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return r, f, nil
+}
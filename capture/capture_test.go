@@ -0,0 +1,135 @@
+package capture
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestFormatParseFrame(t *testing.T) {
+	frame := models.Frame{
+		Message:   "8D4840D6202CC371C32CE0576098",
+		Timestamp: 0x123456,
+		RSSI:      -12.3,
+		Received:  time.Date(2023, 1, 2, 10, 0, 0, 123456789, time.UTC),
+	}
+
+	line := FormatFrame(frame)
+	want := "2023-01-02T10:00:00.123456789Z 000000123456 -12.3 8D4840D6202CC371C32CE0576098\n"
+	if line != want {
+		t.Fatalf("Line incorrect, wanted %q got %q", want, line)
+	}
+
+	actual, err := ParseFrame(line)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if actual != frame {
+		t.Fatalf("Frame incorrect, wanted %v got %v", frame, actual)
+	}
+}
+
+func readAll(t *testing.T, path string) []models.Frame {
+	r, f, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer f.Close()
+
+	var frames []models.Frame
+	for {
+		frame, err := r.ReadFrame()
+		if err == io.EOF {
+			return frames
+		}
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		frames = append(frames, frame)
+	}
+}
+
+func files(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestRecorderRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(RecorderOptions{Dir: dir, MaxSize: 150})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		frame := models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: start.Add(time.Duration(i) * time.Millisecond)}
+		if err := rec.WriteFrame(frame); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	rec.Close()
+
+	names := files(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("File count incorrect, wanted %v got %v", 2, len(names))
+	}
+
+	total := 0
+	for _, name := range names {
+		total += len(readAll(t, name))
+	}
+	if total != 4 {
+		t.Fatalf("Frame count incorrect, wanted %v got %v", 4, total)
+	}
+}
+
+func TestRecorderRotatesByAgeWithGzip(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := NewRecorder(RecorderOptions{Dir: dir, MaxAge: time.Minute, Gzip: true})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, 30 * time.Second, 61 * time.Second} {
+		frame := models.Frame{Message: "5D4840D6000000", RSSI: -3.5, Received: start.Add(offset)}
+		if err := rec.WriteFrame(frame); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	rec.Close()
+
+	names := files(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("File count incorrect, wanted %v got %v", 2, len(names))
+	}
+	if !strings.HasSuffix(names[0], ".cap.gz") {
+		t.Fatalf("expected gzip file name, got %v", names[0])
+	}
+
+	frames := readAll(t, names[0])
+	if len(frames) != 2 || frames[1].RSSI != -3.5 || !frames[1].Received.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("Frames incorrect, got %v", frames)
+	}
+
+	if err := rec.WriteFrame(models.Frame{}); err != ErrClosed {
+		t.Fatalf("wanted ErrClosed, got %v", err)
+	}
+}
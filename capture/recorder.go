@@ -0,0 +1,172 @@
+package capture
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// ErrClosed is returned when writing to a closed Recorder.
+var ErrClosed = errors.New("recorder is closed")
+
+// RecorderOptions configures a Recorder.
+type RecorderOptions struct {
+	// Dir is the directory capture files are created in.
+	Dir string
+	// Prefix is the start of every file name, defaults to "gomodes".
+	Prefix string
+	// MaxSize starts a new file once the current one has grown past this many bytes. Zero disables size rotation.
+	MaxSize int64
+	// MaxAge starts a new file once the current one spans this much receive time. Zero disables time rotation.
+	MaxAge time.Duration
+	// Gzip compresses the files.
+	Gzip bool
+}
+
+// Recorder writes frames to a series of capture files, starting a new file when the current one gets too big or too
+// old. It is not safe for concurrent use.
+type Recorder struct {
+	opts RecorderOptions
+
+	file    *os.File
+	gz      *gzip.Writer
+	counter *countingWriter
+	started time.Time
+	closed  bool
+}
+
+// NewRecorder returns a Recorder. The first file is created when the first frame is written.
+func NewRecorder(opts RecorderOptions) (*Recorder, error) {
+	if opts.Prefix == "" {
+		opts.Prefix = "gomodes"
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{opts: opts}, nil
+}
+
+// WriteFrame writes a frame, rotating to a new file first if needed. Time based rotation uses the frame's receive time.
+func (r *Recorder) WriteFrame(frame models.Frame) error {
+	if r.closed {
+		return ErrClosed
+	}
+
+	if frame.Received.IsZero() {
+		frame.Received = time.Now()
+	}
+
+	if r.file == nil || r.needsRotation(frame.Received) {
+		if err := r.rotate(frame.Received); err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = r.counter
+	if r.gz != nil {
+		w = r.gz
+	}
+
+	_, err := io.WriteString(w, FormatFrame(frame))
+	return err
+}
+
+// File returns the path of the file currently being written, empty before the first frame.
+func (r *Recorder) File() string {
+	if r.file == nil {
+		return ""
+	}
+
+	return r.file.Name()
+}
+
+// Close flushes and closes the current file.
+func (r *Recorder) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return r.closeFile()
+}
+
+func (r *Recorder) needsRotation(t time.Time) bool {
+	if r.opts.MaxSize > 0 && r.counter.n >= r.opts.MaxSize {
+		return true
+	}
+
+	if r.opts.MaxAge > 0 && t.Sub(r.started) >= r.opts.MaxAge {
+		return true
+	}
+
+	return false
+}
+
+func (r *Recorder) rotate(t time.Time) error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.cap", r.opts.Prefix, t.UTC().Format("20060102T150405.000000000"))
+	if r.opts.Gzip {
+		name += ".gz"
+	}
+
+	f, err := os.OpenFile(filepath.Join(r.opts.Dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	r.file = f
+	r.counter = &countingWriter{w: f}
+	r.started = t
+
+	var w io.Writer = r.counter
+	if r.opts.Gzip {
+		r.gz = gzip.NewWriter(r.counter)
+		w = r.gz
+	}
+
+	_, err = io.WriteString(w, Header)
+	return err
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+		r.gz = nil
+	}
+
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file = nil
+
+	return err
+}
+
+// countingWriter counts the bytes that reach the file. With compression enabled this lags behind slightly because
+// the gzip writer buffers.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
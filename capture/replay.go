@@ -0,0 +1,160 @@
+package capture
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// mlatMask is the range of the 48 bit 12 MHz MLAT counter.
+const mlatMask = 1<<48 - 1
+
+// TickReader derives receive times from the receiver's 12 MHz MLAT counter, for recordings in formats that carry
+// no wall clock time such as Beast or timestamped AVR. The first frame is placed at the base time. Frames without a
+// counter value get the time of the previous frame.
+type TickReader struct {
+	r    formats.FrameReader
+	base time.Time
+
+	started  bool
+	lastTick uint64
+	last     time.Time
+}
+
+// NewTickReader returns a TickReader that reads from r.
+func NewTickReader(r formats.FrameReader, base time.Time) *TickReader {
+	return &TickReader{r: r, base: base, last: base}
+}
+
+// ReadFrame returns the next frame with its receive time replaced.
+func (t *TickReader) ReadFrame() (models.Frame, error) {
+	frame, err := t.r.ReadFrame()
+	if err != nil {
+		return models.Frame{}, err
+	}
+
+	if frame.Timestamp != 0 {
+		if t.started {
+			// the counter wraps around after about 270 days
+			delta := (frame.Timestamp - t.lastTick) & mlatMask
+			t.last = t.last.Add(time.Duration(delta) * time.Second / 12e6)
+		}
+		t.started = true
+		t.lastTick = frame.Timestamp
+	}
+
+	frame.Received = t.last
+
+	return frame, nil
+}
+
+// ReplayOptions configures a Replayer.
+type ReplayOptions struct {
+	// Speed is the playback rate, 1 for real time, 10 for ten times faster. Zero replays as fast as possible.
+	Speed float64
+	// Start skips frames received before this time, if set.
+	Start time.Time
+	// End stops the replay at the first frame received after this time, if set.
+	End time.Time
+	// StartOffset skips frames received within this long of the first frame.
+	StartOffset time.Duration
+	// EndOffset stops the replay at the first frame received later than this long after the first frame, if set.
+	EndOffset time.Duration
+}
+
+// Replayer paces frames from a recording according to their receive times and provides a clock that follows the
+// recording, for use with streaming.WithClock.
+type Replayer struct {
+	r    formats.FrameReader
+	opts ReplayOptions
+
+	// wall clock and sleep, replaceable for tests
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	first time.Time
+
+	mu         sync.Mutex
+	anchor     time.Time
+	anchorWall time.Time
+	current    time.Time
+}
+
+// NewReplayer returns a Replayer that reads from r.
+func NewReplayer(r formats.FrameReader, opts ReplayOptions) *Replayer {
+	return &Replayer{r: r, opts: opts, now: time.Now, sleep: time.Sleep}
+}
+
+// ReadFrame returns the next frame within the replay window, blocking until it is due.
+func (p *Replayer) ReadFrame() (models.Frame, error) {
+	for {
+		frame, err := p.r.ReadFrame()
+		if err != nil {
+			return models.Frame{}, err
+		}
+
+		t := frame.Received
+		if p.first.IsZero() {
+			p.first = t
+		}
+
+		if !p.opts.Start.IsZero() && t.Before(p.opts.Start) || t.Sub(p.first) < p.opts.StartOffset {
+			continue
+		}
+		if !p.opts.End.IsZero() && t.After(p.opts.End) || p.opts.EndOffset > 0 && t.Sub(p.first) > p.opts.EndOffset {
+			return models.Frame{}, io.EOF
+		}
+
+		p.wait(t)
+
+		return frame, nil
+	}
+}
+
+func (p *Replayer) wait(t time.Time) {
+	p.mu.Lock()
+	if p.anchor.IsZero() {
+		p.anchor = t
+		p.anchorWall = p.now()
+	}
+
+	var delay time.Duration
+	if p.opts.Speed > 0 {
+		due := p.anchorWall.Add(time.Duration(float64(t.Sub(p.anchor)) / p.opts.Speed))
+		delay = due.Sub(p.now())
+	}
+	p.mu.Unlock()
+
+	if delay > 0 {
+		p.sleep(delay)
+	}
+
+	p.mu.Lock()
+	p.current = t
+	p.mu.Unlock()
+}
+
+// Now returns the current time in the recording. During real time or accelerated replay it keeps advancing between
+// frames. When replaying as fast as possible it is the receive time of the last frame.
+func (p *Replayer) Now() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.anchor.IsZero() {
+		return p.now()
+	}
+
+	if p.opts.Speed <= 0 {
+		return p.current
+	}
+
+	now := p.anchor.Add(time.Duration(float64(p.now().Sub(p.anchorWall)) * p.opts.Speed))
+	if now.Before(p.current) {
+		return p.current
+	}
+
+	return now
+}
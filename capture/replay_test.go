@@ -0,0 +1,92 @@
+package capture
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+)
+
+const replayCapture = `# goModeS capture v1
+2023-01-02T10:00:00Z 000000000000 0.0 8D4840D6202CC371C32CE0576098
+2023-01-02T10:00:02Z 000000000000 0.0 8D4840D6202CC371C32CE0576098
+2023-01-02T10:00:10Z 000000000000 0.0 8D4840D6202CC371C32CE0576098
+2023-01-02T10:00:20Z 000000000000 0.0 8D4840D6202CC371C32CE0576098
+`
+
+func TestReplayerPacesFrames(t *testing.T) {
+	r, _ := NewReader(strings.NewReader(replayCapture))
+	p := NewReplayer(r, ReplayOptions{Speed: 2, EndOffset: 15 * time.Second})
+
+	wall := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	p.now = func() time.Time { return wall }
+	p.sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		wall = wall.Add(d)
+	}
+
+	var count int
+	for {
+		_, err := p.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		count++
+	}
+
+	if count != 3 {
+		t.Fatalf("Frame count incorrect, wanted %v got %v", 3, count)
+	}
+
+	want := []time.Duration{time.Second, 4 * time.Second}
+	if len(slept) != len(want) || slept[0] != want[0] || slept[1] != want[1] {
+		t.Fatalf("Delays incorrect, wanted %v got %v", want, slept)
+	}
+
+	// the clock keeps running at replay speed
+	wall = wall.Add(time.Second)
+	if actual := p.Now(); !actual.Equal(time.Date(2023, 1, 2, 10, 0, 12, 0, time.UTC)) {
+		t.Fatalf("Clock incorrect, got %v", actual)
+	}
+}
+
+func TestReplayerStartWindow(t *testing.T) {
+	r, _ := NewReader(strings.NewReader(replayCapture))
+	p := NewReplayer(r, ReplayOptions{Start: time.Date(2023, 1, 2, 10, 0, 5, 0, time.UTC)})
+
+	frame, err := p.ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	want := time.Date(2023, 1, 2, 10, 0, 10, 0, time.UTC)
+	if !frame.Received.Equal(want) || !p.Now().Equal(want) {
+		t.Fatalf("Replay start incorrect, wanted %v got %v", want, frame.Received)
+	}
+}
+
+func TestTickReader(t *testing.T) {
+	input := "@0000000000018D4840D6202CC371C32CE0576098;\n" +
+		"@000000B71B018D4840D6202CC371C32CE0576098;\n" +
+		"*8D4840D6202CC371C32CE0576098;\n"
+
+	base := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	r := NewTickReader(formats.NewRawReader(strings.NewReader(input)), base)
+
+	want := []time.Time{base, base.Add(time.Second), base.Add(time.Second)}
+	for _, w := range want {
+		frame, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !frame.Received.Equal(w) {
+			t.Fatalf("Receive time incorrect, wanted %v got %v", w, frame.Received)
+		}
+	}
+}
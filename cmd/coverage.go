@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/config"
+	"github.com/pragmatic-zac/goModeS/coverage"
+	"github.com/pragmatic-zac/goModeS/streaming"
+	"github.com/spf13/cobra"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// coverageInterval is how often the coverage is saved while running.
+const coverageInterval = time.Minute
+
+var coveragePath string
+var coverageGeoJSON string
+var coveragePNG string
+var coverageReceiver string
+var coverageSize int
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Export the receiver coverage recorded with --coverage",
+}
+
+var coverageExportCmd = &cobra.Command{
+	Use:   "export <file>",
+	Short: "Write recorded coverage as GeoJSON polygons or a PNG polar plot",
+	Long: `Reads a coverage file written by connect, replay or serve and prints the maximum range of every receiver.
+--geojson writes a polygon for every receiver and altitude band, --png a polar plot of one receiver, the first one
+unless --receiver is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plots, err := coverage.LoadPlots(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		for _, p := range plots {
+			fmt.Printf("%s: %.1f km since %s\n", p.Receiver, p.MaxRange()/1000, p.Since.Format(time.RFC3339))
+		}
+
+		if coverageGeoJSON != "" {
+			err := writeFile(coverageGeoJSON, func(w io.Writer) error {
+				return coverage.WriteGeoJSON(w, plots)
+			})
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+
+		if coveragePNG != "" {
+			var plot *coverage.Plot
+			for i := range plots {
+				if coverageReceiver == "" || plots[i].Receiver == coverageReceiver {
+					plot = &plots[i]
+					break
+				}
+			}
+			if plot == nil {
+				fmt.Printf("no coverage recorded for receiver %q\n", coverageReceiver)
+				return
+			}
+
+			err := writeFile(coveragePNG, func(w io.Writer) error {
+				return coverage.WritePNG(w, *plot, coverageSize)
+			})
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	coverageExportCmd.Flags().StringVar(&coverageGeoJSON, "geojson", "", "GeoJSON file to write")
+	coverageExportCmd.Flags().StringVar(&coveragePNG, "png", "", "PNG file to write")
+	coverageExportCmd.Flags().StringVar(&coverageReceiver, "receiver", "", "receiver to plot, defaults to the first")
+	coverageExportCmd.Flags().IntVar(&coverageSize, "size", 800, "width and height of the plot in pixels")
+
+	coverageCmd.AddCommand(coverageExportCmd)
+	rootCmd.AddCommand(coverageCmd)
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// coverageOptions returns the tracker option to record coverage, restored from path, none if path is empty. Inputs
+// with a position are measured from there, everything else from the receiver location.
+func coverageOptions(path string, lat float64, lon float64, inputs []config.Input) ([]streaming.TrackerOption, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	c := coverage.New(lat, lon)
+	for _, in := range inputs {
+		if in.Position != nil {
+			c.SetLocation(in.Name, in.Position.Lat, in.Position.Lon)
+		}
+	}
+	if err := c.Load(path); err != nil {
+		return nil, err
+	}
+
+	return []streaming.TrackerOption{streaming.WithCoverage(c)}, nil
+}
+
+// saveCoverage saves the tracker's coverage to path at an interval and when ctx is cancelled.
+func saveCoverage(ctx context.Context, wg *sync.WaitGroup, tracker *streaming.Tracker, path string) {
+	defer wg.Done()
+
+	c := tracker.Coverage()
+	if c == nil {
+		return
+	}
+
+	ticker := time.NewTicker(coverageInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.Save(path); err != nil {
+				log.Println("Error saving coverage:", err)
+			}
+			return
+		case <-ticker.C:
+			if err := c.Save(path); err != nil {
+				log.Println("Error saving coverage:", err)
+			}
+		}
+	}
+}
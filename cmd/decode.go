@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/capture"
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var decodeFile string
+var decodeOutput string
+var decodeLat float64
+var decodeLon float64
+var decodeCmd = &cobra.Command{
+	Use:   "decode [hex...]",
+	Short: "Decode messages given as arguments, on stdin or in a file",
+	Long: `Decodes Mode S messages and prints every decodable field.
+
+Messages are read from the arguments, from --file, or from stdin when neither is given. Input lines may be plain hex,
+any AVR variant (*, @, %, <) or goModeS capture lines, and files may be gzip compressed. Positions are only decoded
+when --lat and --lon are given.
+
+Output is one JSON object per line (json), an aligned table (table) or CSV with a header row (csv). Messages that
+cannot be decoded are reported on stderr, and make the command exit with status 1.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var w decodeWriter
+		switch decodeOutput {
+		case "json":
+			w = &jsonDecodeWriter{enc: json.NewEncoder(os.Stdout)}
+		case "table":
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			w = &tableDecodeWriter{tw: tw}
+		case "csv":
+			w = &csvDecodeWriter{cw: csv.NewWriter(os.Stdout)}
+		default:
+			fmt.Fprintf(os.Stderr, "unsupported output %q, expected json, table or csv\n", decodeOutput)
+			os.Exit(1)
+		}
+
+		var latRef, lonRef *float64
+		if cmd.Flags().Changed("lat") && cmd.Flags().Changed("lon") {
+			latRef, lonRef = &decodeLat, &decodeLon
+		}
+
+		if err := w.header(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		var failed int
+		var err error
+		if len(args) > 0 {
+			for _, arg := range args {
+				if !decodeLine(arg, latRef, lonRef, w) {
+					failed++
+				}
+			}
+		} else if decodeFile != "" {
+			failed, err = decodeFromFile(decodeFile, latRef, lonRef, w)
+		} else {
+			failed, err = decodeFromReader(os.Stdin, latRef, lonRef, w)
+		}
+
+		if ferr := w.flush(); err == nil {
+			err = ferr
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d input lines could not be decoded\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	decodeCmd.Flags().StringVarP(&decodeFile, "file", "f", "", "file to read messages from")
+	decodeCmd.Flags().StringVarP(&decodeOutput, "output", "O", "json", "output format (json, table or csv)")
+	decodeCmd.Flags().Float64VarP(&decodeLat, "lat", "l", 0, "reference latitude for position decoding")
+	decodeCmd.Flags().Float64VarP(&decodeLon, "lon", "o", 0, "reference longitude for position decoding")
+
+	rootCmd.AddCommand(decodeCmd)
+}
+
+// decodeRecord is a decoded message together with the reception metadata from the input, if any.
+type decodeRecord struct {
+	Time      *time.Time `json:"time,omitempty"`
+	Timestamp uint64     `json:"timestamp,omitempty"`
+	RSSI      float64    `json:"rssi,omitempty"`
+	decode.Decoded
+}
+
+// decodeFromFile decodes every line of a file, and returns the number of lines that could not be decoded.
+func decodeFromFile(path string, latRef *float64, lonRef *float64, w decodeWriter) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return decodeFromReader(f, latRef, lonRef, w)
+}
+
+// decodeFromReader decodes every line read from r, and returns the number of lines that could not be decoded.
+func decodeFromReader(r io.Reader, latRef *float64, lonRef *float64, w decodeWriter) (int, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	var failed int
+	scanner := bufio.NewScanner(br)
+	for scanner.Scan() {
+		if !decodeLine(scanner.Text(), latRef, lonRef, w) {
+			failed++
+		}
+	}
+
+	return failed, scanner.Err()
+}
+
+// decodeLine decodes and writes one input line. It reports false when the line could not be decoded; blank lines and
+// comments are skipped.
+func decodeLine(line string, latRef *float64, lonRef *float64, w decodeWriter) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return true
+	}
+
+	frame, err := parseInputLine(line)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", line, err)
+		return false
+	}
+
+	var d decode.Decoded
+	if latRef != nil && lonRef != nil {
+		d, err = decode.DecodeWithRef(frame.Message, *latRef, *lonRef)
+	} else {
+		d, err = decode.Decode(frame.Message)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", line, err)
+		return false
+	}
+
+	rec := decodeRecord{Timestamp: frame.Timestamp, RSSI: frame.RSSI, Decoded: d}
+	if !frame.Received.IsZero() {
+		rec.Time = &frame.Received
+	}
+
+	if err := w.write(rec); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+
+	return true
+}
+
+// parseInputLine accepts a plain hex message, an AVR line or a capture line.
+func parseInputLine(line string) (models.Frame, error) {
+	switch line[0] {
+	case '*', '@', '%', '<':
+		frame, err := formats.ParseAVR(line)
+		// the receive time is only meaningful for captures
+		frame.Received = time.Time{}
+		return frame, err
+	}
+
+	if len(strings.Fields(line)) == 4 {
+		return capture.ParseFrame(line)
+	}
+
+	return models.Frame{Message: strings.ToUpper(line)}, nil
+}
+
+type decodeWriter interface {
+	header() error
+	write(rec decodeRecord) error
+	flush() error
+}
+
+type jsonDecodeWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonDecodeWriter) header() error                { return nil }
+func (j *jsonDecodeWriter) write(rec decodeRecord) error { return j.enc.Encode(rec) }
+func (j *jsonDecodeWriter) flush() error                 { return nil }
+
+var decodeColumns = []string{"time", "timestamp", "rssi", "message", "df", "icao", "crc", "typecode", "category",
+	"callsign", "squawk", "altitude", "odd_even", "cpr_lat", "cpr_lon", "latitude", "longitude", "speed", "track",
+	"vert_rate", "speed_type", "rate_source"}
+
+func decodeRow(rec decodeRecord) []string {
+	optInt := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	optInt64 := func(v *int64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatInt(*v, 10)
+	}
+
+	row := make([]string, 0, len(decodeColumns))
+
+	if rec.Time != nil {
+		row = append(row, rec.Time.Format(time.RFC3339Nano))
+	} else {
+		row = append(row, "")
+	}
+	if rec.Timestamp != 0 {
+		row = append(row, strconv.FormatUint(rec.Timestamp, 10))
+	} else {
+		row = append(row, "")
+	}
+	if rec.RSSI != 0 {
+		row = append(row, strconv.FormatFloat(rec.RSSI, 'f', 1, 64))
+	} else {
+		row = append(row, "")
+	}
+
+	row = append(row, rec.Message, strconv.Itoa(rec.Df), rec.Icao, fmt.Sprintf("%06X", rec.Crc),
+		optInt64(rec.Typecode), optInt64(rec.Category), rec.Callsign, rec.Squawk, optInt(rec.Altitude),
+		optInt(rec.OddEven), optInt64(rec.CprLat), optInt64(rec.CprLon))
+
+	if rec.Position != nil {
+		row = append(row, strconv.FormatFloat(rec.Position.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(rec.Position.Longitude, 'f', -1, 64))
+	} else {
+		row = append(row, "", "")
+	}
+
+	if rec.Velocity != nil {
+		row = append(row, strconv.FormatFloat(rec.Velocity.Speed, 'f', -1, 64),
+			strconv.FormatFloat(rec.Velocity.Angle, 'f', -1, 64), strconv.Itoa(int(rec.Velocity.VertRate)),
+			rec.Velocity.SpeedType, rec.Velocity.RateSource)
+	} else {
+		row = append(row, "", "", "", "", "")
+	}
+
+	return row
+}
+
+type csvDecodeWriter struct {
+	cw *csv.Writer
+}
+
+func (c *csvDecodeWriter) header() error { return c.cw.Write(decodeColumns) }
+func (c *csvDecodeWriter) write(rec decodeRecord) error {
+	return c.cw.Write(decodeRow(rec))
+}
+func (c *csvDecodeWriter) flush() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// tableDecodeWriter leaves out the reception metadata to keep the table readable in a terminal.
+type tableDecodeWriter struct {
+	tw *tabwriter.Writer
+}
+
+func (t *tableDecodeWriter) header() error {
+	_, err := fmt.Fprintln(t.tw, strings.ToUpper(strings.Join(decodeColumns[3:], "\t")))
+	return err
+}
+func (t *tableDecodeWriter) write(rec decodeRecord) error {
+	row := decodeRow(rec)[3:]
+	for i, v := range row {
+		if v == "" {
+			row[i] = "-"
+		}
+	}
+	_, err := fmt.Fprintln(t.tw, strings.Join(row, "\t"))
+	return err
+}
+func (t *tableDecodeWriter) flush() error { return t.tw.Flush() }
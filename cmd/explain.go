@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/spf13/cobra"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <hex>",
+	Short: "Print a message field by field",
+	Long: `Breaks a message down into its fields, showing each field's bit range (counting from 1), raw bits, value and
+decoded meaning.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		annotations, err := decode.Explain(decode.CleanMessage(args[0]))
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "BITS\tFIELD\tDESCRIPTION\tRAW\tVALUE\tMEANING")
+
+		for _, a := range annotations {
+			value := fmt.Sprint(a.Value)
+			if a.Field.End-a.Field.Start > 24 {
+				// long fields are only meaningful as raw bits
+				value = "-"
+			} else if a.Field.End-a.Field.Start == 24 {
+				value = fmt.Sprintf("%06X", a.Value)
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", decode.FormatBits(a.Field), a.Field.Name,
+				a.Field.Description, a.Bits, value, strings.TrimSpace(a.Meaning))
+		}
+
+		tw.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"github.com/pragmatic-zac/goModeS/config"
+	"github.com/pragmatic-zac/goModeS/mlat"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/streaming"
+	"sync"
+)
+
+// newSolver returns a multilateration solver for the inputs that have a position, identified by their name.
+func newSolver(inputs []config.Input, tracker *streaming.Tracker) (*mlat.Solver, error) {
+	var receivers []mlat.Receiver
+	for _, in := range inputs {
+		if p := in.Position; p != nil {
+			receivers = append(receivers, mlat.Receiver{ID: in.Name, Lat: p.Lat, Lon: p.Lon, Alt: p.Alt})
+		}
+	}
+
+	return mlat.New(receivers, mlat.Options{
+		Altitude: func(icao string) (int, bool) {
+			f, ok := tracker.Flight(icao)
+			return f.Altitude, ok && f.Altitude != 0
+		},
+	})
+}
+
+// forwardFrames passes the frames of one input on to the tracker and to multilateration.
+func forwardFrames(ctx context.Context, wg *sync.WaitGroup, in <-chan models.Frame, msgChan chan<- models.Frame, mlatChan chan<- models.Frame) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-in:
+			select {
+			case <-ctx.Done():
+				return
+			case msgChan <- frame:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case mlatChan <- frame:
+			}
+		}
+	}
+}
+
+// runMLAT multilaterates the frames and applies the positions to the tracker.
+func runMLAT(ctx context.Context, wg *sync.WaitGroup, solver *mlat.Solver, mlatChan <-chan models.Frame, tracker *streaming.Tracker) {
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-mlatChan:
+			for _, res := range solver.Add(frame.Receiver, frame) {
+				tracker.UpdateMLAT(res.Icao, res.Position, res.Time)
+			}
+		}
+	}
+}
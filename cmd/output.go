@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/config"
+	"github.com/pragmatic-zac/goModeS/formats"
+	"github.com/pragmatic-zac/goModeS/output"
+	"github.com/spf13/cobra"
+	"sync"
+)
+
+var rawOut string
+var beastOut string
+var sbsOut string
+var feeds []string
+var feedMode string
+
+// addOutputFlags adds the network output flags to a command that decodes frames.
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&rawOut, "raw-out", "", "serve accepted frames as AVR text on this address, e.g. :30002")
+	cmd.Flags().StringVar(&beastOut, "beast-out", "", "serve accepted frames in Beast format on this address, e.g. :30005")
+	cmd.Flags().StringVar(&sbsOut, "sbs-out", "", "serve accepted frames as BaseStation messages on this address, e.g. :30003")
+	cmd.Flags().StringArrayVar(&feeds, "feed", nil, "send accepted frames to this aggregator host:port (repeatable)")
+	cmd.Flags().StringVar(&feedMode, "feed-mode", "beast", "format sent to aggregators (beast or raw)")
+}
+
+// flagOutputs returns the outputs and feeds given with the output flags.
+func flagOutputs() ([]config.Output, []config.Feed) {
+	var outputs []config.Output
+	for _, o := range []config.Output{{Mode: "raw", Listen: rawOut}, {Mode: "beast", Listen: beastOut}, {Mode: "sbs", Listen: sbsOut}} {
+		if o.Listen != "" {
+			outputs = append(outputs, o)
+		}
+	}
+
+	var feedConfigs []config.Feed
+	for _, addr := range feeds {
+		feedConfigs = append(feedConfigs, config.Feed{Address: addr, Mode: feedMode})
+	}
+
+	return outputs, feedConfigs
+}
+
+// startOutputs starts a server for every output and a feeder for every aggregator. They stop when ctx is cancelled.
+// Along with the writers it returns a status function for every feeder.
+func startOutputs(ctx context.Context, wg *sync.WaitGroup, servers []config.Output, feeds []config.Feed, latRef float64, lonRef float64) ([]formats.FrameWriter, []func() string, error) {
+	var outputs []formats.FrameWriter
+	var statuses []func() string
+
+	for _, o := range servers {
+		enc, err := output.Encoder(o.Mode, latRef, lonRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, err := output.Listen(o.Listen, enc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s output: %w", o.Mode, err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Serve(ctx); err != nil {
+				fmt.Println("Error serving output:", err)
+			}
+		}()
+
+		outputs = append(outputs, s)
+	}
+
+	for _, fc := range feeds {
+		f, err := output.NewFeeder(output.FeederOptions{Addr: fc.Address, Mode: fc.Mode, Buffer: fc.Buffer})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.Run(ctx)
+		}()
+
+		statuses = append(statuses, func() string {
+			return "feed " + f.Status().String()
+		})
+		outputs = append(outputs, f)
+	}
+
+	return outputs, statuses, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/capture"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/source"
+	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var recordAddress string
+var recordDir string
+var recordMaxSize int64
+var recordMaxAge time.Duration
+var recordGzip bool
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "Record every frame received from a receiver to disk",
+	Long: `Connects to a receiver running on a provided port and writes every frame, together with its receive time and
+signal metadata, to capture files that can be replayed later.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mode == "sbs" {
+			fmt.Println("sbs sources carry decoded data, not frames, and cannot be recorded")
+			return
+		}
+
+		rec, err := capture.NewRecorder(capture.RecorderOptions{
+			Dir:     recordDir,
+			MaxSize: recordMaxSize * 1024 * 1024,
+			MaxAge:  recordMaxAge,
+			Gzip:    recordGzip,
+		})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer rec.Close()
+
+		src, err := source.Parse(recordAddress, source.Options{})
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+
+		msgChan := make(chan models.Frame)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// stops the recording when a file or stdin ends
+			defer cancel()
+			if err := readSource(ctx, src, mode, msgChan, nil); err != nil {
+				fmt.Println("Error reading source:", err)
+			}
+		}()
+		wg.Add(1)
+		go recordMessages(ctx, msgChan, &wg, rec, cancel)
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+		select {
+		case <-sigChan:
+		case <-ctx.Done():
+		}
+
+		cancel()
+		wg.Wait()
+
+		fmt.Println("Recording stopped.")
+	},
+}
+
+func init() {
+	recordCmd.Flags().StringVarP(&recordAddress, "address", "a", "", sourceUsage)
+	recordCmd.Flags().StringVarP(&mode, "mode", "m", "", "mode of source (raw, beast, iq2000 or iq2400)")
+	recordCmd.Flags().StringVarP(&recordDir, "dir", "d", ".", "directory to write capture files to")
+	recordCmd.Flags().Int64Var(&recordMaxSize, "max-size", 0, "start a new file after this many megabytes (0 disables)")
+	recordCmd.Flags().DurationVar(&recordMaxAge, "max-age", 0, "start a new file after this long, e.g. 1h (0 disables)")
+	recordCmd.Flags().BoolVarP(&recordGzip, "gzip", "z", false, "gzip compress capture files")
+
+	recordCmd.MarkFlagRequired("address")
+	recordCmd.MarkFlagRequired("mode")
+
+	rootCmd.AddCommand(recordCmd)
+}
+
+func recordMessages(ctx context.Context, msgChan <-chan models.Frame, wg *sync.WaitGroup, rec *capture.Recorder, cancel context.CancelFunc) {
+	defer wg.Done()
+
+	var count int
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Recorded %d frames.\n", count)
+			return
+		case msg := <-msgChan:
+			if err := rec.WriteFrame(msg); err != nil {
+				fmt.Println("Error writing frame:", err)
+				cancel()
+				continue
+			}
+			count++
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/registry"
+	"github.com/pragmatic-zac/goModeS/streaming"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"strings"
+)
+
+var registryPath string
+var routesPath string
+var registryOut string
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the aircraft database used to look up registrations, types and operators",
+}
+
+var registryImportCmd = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "Build the aircraft database from downloaded files",
+	Long: `Builds the aircraft database from OpenSky Network's aircraftDatabase.csv and tar1090-db's aircraft.csv.gz, or
+a previous database, in any combination. Later files take precedence over earlier ones. The result is written to
+--out, gzip compressed if the name ends in .gz, and can be used with --registry.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db := registry.New()
+		for _, path := range args {
+			n, err := importRegistry(db, path)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Printf("%s: %d aircraft\n", path, n)
+		}
+
+		if err := writeRegistry(db, registryOut); err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		fmt.Printf("Wrote %d aircraft to %s\n", db.Len(), registryOut)
+	},
+}
+
+func init() {
+	registryImportCmd.Flags().StringVarP(&registryOut, "out", "O", "aircraft.csv.gz", "database file to write")
+
+	registryCmd.AddCommand(registryImportCmd)
+	rootCmd.AddCommand(registryCmd)
+}
+
+func importRegistry(db *registry.DB, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := db.Import(f)
+	if err != nil {
+		return n, fmt.Errorf("%s: %w", path, err)
+	}
+	return n, nil
+}
+
+// writeRegistry writes the database to a temporary file first, so a failed import does not destroy the previous one.
+func writeRegistry(db *registry.DB, path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	err = db.Write(w)
+	if gz != nil && err == nil {
+		err = gz.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// lookupOptions returns the tracker options to look up aircraft in the database at registryPath and callsigns in the
+// routes file at routesPath. Either path may be empty.
+func lookupOptions(registryPath string, routesPath string) ([]streaming.TrackerOption, error) {
+	var opts []streaming.TrackerOption
+
+	if registryPath != "" {
+		db, err := registry.Load(registryPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, streaming.WithRegistry(db))
+	}
+
+	if routesPath != "" {
+		routes, err := registry.LoadRoutes(routesPath)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, streaming.WithRoutes(routes))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/pragmatic-zac/goModeS/capture"
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/streaming"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var replayFormat string
+var replaySpeed float64
+var replayStart string
+var replayEnd string
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Display table of aircraft from a recorded capture",
+	Long: `Replays a recorded capture (goModeS capture, raw AVR, Beast or RTL-SDR IQ samples, optionally gzip compressed) through the same
+decoding pipeline as connect, preserving the original receive times.
+
+Use --speed 1 for real time, a larger value to replay faster, or 0 to replay as fast as possible. --start and --end
+take either an RFC3339 time or a duration relative to the first frame, e.g. 10m.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := capture.ReplayOptions{Speed: replaySpeed}
+		var err error
+		if opts.Start, opts.StartOffset, err = parseReplayBound(replayStart); err != nil {
+			fmt.Println("invalid start:", err)
+			return
+		}
+		if opts.End, opts.EndOffset, err = parseReplayBound(replayEnd); err != nil {
+			fmt.Println("invalid end:", err)
+			return
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		defer f.Close()
+
+		reader, err := replayReader(f, replayFormat)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+
+		trackerOpts, err := lookupOptions(registryPath, routesPath)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		coverageOpts, err := coverageOptions(coveragePath, latRef, lonRef, nil)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		trackerOpts = append(trackerOpts, coverageOpts...)
+
+		replayer := capture.NewReplayer(reader, opts)
+		tracker := streaming.NewTracker(latRef, lonRef, append(trackerOpts, streaming.WithClock(replayer.Now))...)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+
+		msgChan := make(chan models.Frame)
+
+		servers, feedConfigs := flagOutputs()
+		outputs, statuses, err := startOutputs(ctx, &wg, servers, feedConfigs, latRef, lonRef)
+		if err != nil {
+			fmt.Println(err.Error())
+			cancel()
+			wg.Wait()
+			return
+		}
+		statusLines = append(statusLines, statuses...)
+
+		wg.Add(1)
+		go replayFrames(ctx, replayer, msgChan, &wg)
+		wg.Add(1)
+		go processMessages(ctx, msgChan, nil, &wg, tracker, outputs)
+		wg.Add(1)
+		go renderLoop(ctx, &wg, tracker)
+		wg.Add(1)
+		go serveAPI(ctx, &wg, tracker, httpAddr, nil)
+		wg.Add(1)
+		go saveCoverage(ctx, &wg, tracker, coveragePath)
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+
+		cancel()
+		wg.Wait()
+
+		fmt.Println("Successfully shut down.")
+	},
+}
+
+func init() {
+	replayCmd.Flags().StringVarP(&replayFormat, "format", "f", "capture", "format of the file (capture, raw, beast, iq2000 or iq2400)")
+	replayCmd.Flags().Float64VarP(&replaySpeed, "speed", "s", 1, "playback speed, 1 is real time, 0 is as fast as possible")
+	replayCmd.Flags().StringVar(&replayStart, "start", "", "skip frames before this time or offset")
+	replayCmd.Flags().StringVar(&replayEnd, "end", "", "stop at this time or offset")
+	replayCmd.Flags().Float64VarP(&latRef, "lat", "l", 0, "receiver latitude")
+	replayCmd.Flags().Float64VarP(&lonRef, "lon", "o", 0, "receiver longitude")
+	replayCmd.Flags().StringVar(&httpAddr, "http", "", "serve aircraft.json and friends on this address, e.g. :8080")
+	replayCmd.Flags().StringVar(&registryPath, "registry", "", "aircraft database to look up registrations, types and operators in, see registry import")
+	replayCmd.Flags().StringVar(&routesPath, "routes", "", "CSV file of callsign, origin and destination to look up routes in")
+	replayCmd.Flags().StringVar(&coveragePath, "coverage", "", "file to record the range reached per bearing and altitude in, kept across restarts")
+	addOutputFlags(replayCmd)
+
+	replayCmd.MarkFlagRequired("lat")
+	replayCmd.MarkFlagRequired("lon")
+
+	rootCmd.AddCommand(replayCmd)
+}
+
+// replayFrames passes the frames of a recording on until it ends or ctx is cancelled.
+func replayFrames(ctx context.Context, reader formats.FrameReader, msgChan chan<- models.Frame, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	readChan := make(chan models.Frame)
+	errChan := make(chan error)
+
+	// start a goroutine to read data from the recording
+	// bufio blocks if we don't do this, and we never get graceful shutdown
+	go func() {
+		for {
+			msg, err := reader.ReadFrame()
+			if err != nil {
+				errChan <- err
+				return
+			}
+			readChan <- msg
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-readChan:
+			select {
+			case msgChan <- msg:
+			case <-ctx.Done():
+				return
+			}
+		case err := <-errChan:
+			if err != io.EOF {
+				fmt.Println("Error reading message:", err)
+			}
+			return
+		}
+	}
+}
+
+// replayReader returns a reader for a recording. Receive times of raw and Beast recordings are rebuilt from their MLAT
+// timestamps.
+func replayReader(r io.Reader, format string) (formats.FrameReader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	} else {
+		r = br
+	}
+
+	if format == "capture" {
+		return capture.NewReader(r)
+	}
+
+	reader, err := formats.NewReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return capture.NewTickReader(reader, time.Now()), nil
+}
+
+// parseReplayBound parses either an absolute RFC3339 time or a duration relative to the start of the recording.
+func parseReplayBound(s string) (time.Time, time.Duration, error) {
+	if s == "" {
+		return time.Time{}, 0, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Time{}, d, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("expected an RFC3339 time or a duration, got %q", s)
+	}
+
+	return t, 0, nil
+}
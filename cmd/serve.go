@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"github.com/pragmatic-zac/goModeS/capture"
+	"github.com/pragmatic-zac/goModeS/config"
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/source"
+	"github.com/pragmatic-zac/goModeS/streaming"
+	"github.com/spf13/cobra"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var configPath string
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run without a terminal UI, configured by a YAML or TOML file",
+	Long: `Reads the inputs, receiver location, tracker settings, outputs and sinks from a YAML or TOML config file and
+runs until interrupted. SIGHUP reloads the config file; if the new config is invalid the running one is kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+
+		tracker, err := newServeTracker(cfg)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		p, err := startPipeline(cfg, tracker)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		log.Printf("Serving %d inputs", len(cfg.Inputs))
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+		for sig := range sigChan {
+			if sig != syscall.SIGHUP {
+				break
+			}
+
+			newCfg, err := config.Load(configPath)
+			if err != nil {
+				log.Println("Reload failed, keeping the running config:", err)
+				continue
+			}
+
+			// aircraft are kept unless they would be decoded differently
+			newTracker := tracker
+			if newCfg.Receiver != cfg.Receiver || newCfg.Tracker != cfg.Tracker || newCfg.Registry != cfg.Registry ||
+				newCfg.Routes != cfg.Routes || newCfg.Coverage != cfg.Coverage || !samePositions(newCfg.Inputs, cfg.Inputs) {
+				// the new tracker restores the coverage from the file, bring it up to date first
+				if c := tracker.Coverage(); c != nil {
+					if err := c.Save(cfg.Coverage); err != nil {
+						log.Println("Error saving coverage:", err)
+					}
+				}
+				if newTracker, err = newServeTracker(newCfg); err != nil {
+					log.Println("Reload failed, keeping the running config:", err)
+					continue
+				}
+			}
+
+			// listeners must be closed before they can be opened again
+			p.stop()
+
+			if p, err = startPipeline(newCfg, newTracker); err != nil {
+				log.Println("Reload failed, restoring the previous config:", err)
+				if p, err = startPipeline(cfg, tracker); err != nil {
+					log.Println(err)
+					return
+				}
+				continue
+			}
+
+			cfg, tracker = newCfg, newTracker
+			log.Printf("Reloaded %s", configPath)
+		}
+
+		p.stop()
+		log.Println("Successfully shut down.")
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&configPath, "config", "c", "", "YAML or TOML config file")
+
+	serveCmd.MarkFlagRequired("config")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func newServeTracker(cfg config.Config) (*streaming.Tracker, error) {
+	opts, err := lookupOptions(cfg.Registry, cfg.Routes)
+	if err != nil {
+		return nil, err
+	}
+	coverageOpts, err := coverageOptions(cfg.Coverage, cfg.Receiver.Lat, cfg.Receiver.Lon, cfg.Inputs)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, coverageOpts...)
+
+	opts = append(opts,
+		streaming.WithExpiry(time.Duration(cfg.Tracker.Expiry)),
+		streaming.WithMergeWindow(time.Duration(cfg.Tracker.MergeWindow)))
+	return streaming.NewTracker(cfg.Receiver.Lat, cfg.Receiver.Lon, opts...), nil
+}
+
+// samePositions reports whether the inputs of two configs have the same names and antenna positions, which the
+// coverage of each input is measured from.
+func samePositions(a []config.Input, b []config.Input) bool {
+	positions := func(inputs []config.Input) map[string]config.Position {
+		m := make(map[string]config.Position)
+		for _, in := range inputs {
+			if in.Position != nil {
+				m[in.Name] = *in.Position
+			}
+		}
+		return m
+	}
+
+	pa, pb := positions(a), positions(b)
+	if len(pa) != len(pb) {
+		return false
+	}
+	for name, p := range pa {
+		if q, ok := pb[name]; !ok || p != q {
+			return false
+		}
+	}
+	return true
+}
+
+// pipeline is everything started for one config: sources, outputs, sinks and the HTTP API.
+type pipeline struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	rec    *capture.Recorder
+}
+
+func startPipeline(cfg config.Config, tracker *streaming.Tracker) (*pipeline, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &pipeline{cancel: cancel}
+
+	outputs, statuses, err := startOutputs(ctx, &p.wg, cfg.Outputs, cfg.Sinks.Feeds, cfg.Receiver.Lat, cfg.Receiver.Lon)
+	if err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	if r := cfg.Sinks.Record; r != nil {
+		p.rec, err = capture.NewRecorder(capture.RecorderOptions{
+			Dir:     r.Dir,
+			Prefix:  r.Prefix,
+			MaxSize: r.MaxSize * 1024 * 1024,
+			MaxAge:  time.Duration(r.MaxAge),
+			Gzip:    r.Gzip,
+		})
+		if err != nil {
+			p.stop()
+			return nil, err
+		}
+		outputs = append(outputs, p.rec)
+	}
+
+	msgChan := make(chan models.Frame)
+	sbsChan := make(chan formats.SBSMessage)
+
+	var mlatChan chan models.Frame
+	if cfg.MLAT {
+		solver, err := newSolver(cfg.Inputs, tracker)
+		if err != nil {
+			p.stop()
+			return nil, err
+		}
+		mlatChan = make(chan models.Frame)
+		p.wg.Add(1)
+		go runMLAT(ctx, &p.wg, solver, mlatChan, tracker)
+	}
+
+	var sources []*source.Source
+	for _, in := range cfg.Inputs {
+		src, err := source.Parse(in.Address, source.Options{Name: in.Name})
+		if err != nil {
+			p.stop()
+			return nil, err
+		}
+		sources = append(sources, src)
+		statuses = append(statuses, func() string {
+			return "input " + src.Status().String()
+		})
+
+		// frames of positioned inputs are multilaterated as well
+		frames := msgChan
+		if mlatChan != nil && in.Position != nil {
+			frames = make(chan models.Frame)
+			p.wg.Add(1)
+			go forwardFrames(ctx, &p.wg, frames, msgChan, mlatChan)
+		}
+
+		in := in
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			if err := readSource(ctx, src, in.Mode, frames, sbsChan); err != nil {
+				log.Printf("Input %s: %v", in.Name, err)
+			}
+		}()
+	}
+
+	p.wg.Add(1)
+	go processMessages(ctx, msgChan, sbsChan, &p.wg, tracker, outputs)
+	p.wg.Add(1)
+	go serveAPI(ctx, &p.wg, tracker, cfg.HTTP, sources)
+	p.wg.Add(1)
+	go statusLoop(ctx, &p.wg, tracker, time.Duration(cfg.LogInterval), statuses)
+	p.wg.Add(1)
+	go saveCoverage(ctx, &p.wg, tracker, cfg.Coverage)
+
+	return p, nil
+}
+
+// stop shuts everything down and waits for it to finish.
+func (p *pipeline) stop() {
+	p.cancel()
+	p.wg.Wait()
+
+	if p.rec != nil {
+		if err := p.rec.Close(); err != nil {
+			log.Println("Error closing capture file:", err)
+		}
+	}
+}
+
+// statusLoop expires aircraft, which the TUI otherwise does, and logs the state of the pipeline at the interval.
+func statusLoop(ctx context.Context, wg *sync.WaitGroup, tracker *streaming.Tracker, interval time.Duration, statuses []func() string) {
+	defer wg.Done()
+
+	expire := time.NewTicker(time.Second)
+	defer expire.Stop()
+
+	var logChan <-chan time.Time
+	if interval > 0 {
+		logTicker := time.NewTicker(interval)
+		defer logTicker.Stop()
+		logChan = logTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expire.C:
+			tracker.Expire()
+		case <-logChan:
+			total := tracker.Totals()
+			log.Printf("%d aircraft, %d messages accepted, %d positions since start", len(tracker.Flights()), total.Accepted, total.Positions)
+			for _, status := range statuses {
+				log.Println(status())
+			}
+		}
+	}
+}
@@ -1,58 +1,111 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	tm "github.com/buger/goterm"
+	"github.com/pragmatic-zac/goModeS/api"
+	"github.com/pragmatic-zac/goModeS/coverage"
+	"github.com/pragmatic-zac/goModeS/formats"
+	"github.com/pragmatic-zac/goModeS/metrics"
 	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/source"
 	"github.com/pragmatic-zac/goModeS/streaming"
 	"github.com/spf13/cobra"
-	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 )
 
-var address string
+// sourceUsage describes the --address flag, see source.Parse.
+const sourceUsage = "source to read from: host:port, tcp://host:port, udp://host:port to listen, unix:///path, rtltcp://host:port?gain=40, file:///path or - for stdin"
+
+var addresses []string
 var mode string
 var latRef float64
 var lonRef float64
+var httpAddr string
+
+// statusLines are printed below the aircraft table, e.g. the state of connections.
+var statusLines []func() string
+
 var connectCmd = &cobra.Command{
 	Use:   "connect",
 	Short: "Display table of aircraft tracked by receiver running on provided port",
-	Long:  `Connects to a receiver running on a provided port. Decodes messages and displays tracked aircraft in a table.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if mode != "raw" {
-			println("only raw format is currently supported!")
-		}
-
-		trackedFlights := make(map[string]models.Flight)
+	Long: `Connects to a receiver running on a provided port. Decodes messages and displays tracked aircraft in a table.
 
-		// network stuff
-		tcpAddr, err := net.ResolveTCPAddr("tcp", address)
+Repeat --address to merge several receivers into one table. Copies of a frame heard by more than one of them are
+applied once, and every aircraft lists the receivers that heard it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts, err := lookupOptions(registryPath, routesPath)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
-		conn, err := net.DialTCP("tcp", nil, tcpAddr)
+		coverageOpts, err := coverageOptions(coveragePath, latRef, lonRef, nil)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
-		defer conn.Close()
+		opts = append(opts, coverageOpts...)
+		tracker := streaming.NewTracker(latRef, lonRef, opts...)
+
+		if mode != "sbs" {
+			if _, err := formats.NewReader(mode, nil); err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+		}
+
+		var sources []*source.Source
+		for _, addr := range addresses {
+			src, err := source.Parse(addr, source.Options{})
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			sources = append(sources, src)
+			statusLines = append(statusLines, func() string {
+				return "input " + src.Status().String()
+			})
+		}
 
 		// set up channels and such
 		ctx, cancel := context.WithCancel(context.Background())
 		var wg sync.WaitGroup
 
-		msgChan := make(chan string)
+		msgChan := make(chan models.Frame)
+		sbsChan := make(chan formats.SBSMessage)
 
-		go handleConnection(ctx, conn, msgChan, &wg)
-		go processMessages(ctx, msgChan, &wg, trackedFlights)
-		go renderLoop(ctx, &wg, trackedFlights)
+		servers, feedConfigs := flagOutputs()
+		outputs, statuses, err := startOutputs(ctx, &wg, servers, feedConfigs, latRef, lonRef)
+		if err != nil {
+			fmt.Println(err.Error())
+			cancel()
+			wg.Wait()
+			return
+		}
+		statusLines = append(statusLines, statuses...)
+
+		for _, src := range sources {
+			src := src
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				readSource(ctx, src, mode, msgChan, sbsChan)
+			}()
+		}
+		wg.Add(1)
+		go processMessages(ctx, msgChan, sbsChan, &wg, tracker, outputs)
+		wg.Add(1)
+		go renderLoop(ctx, &wg, tracker)
+		wg.Add(1)
+		go serveAPI(ctx, &wg, tracker, httpAddr, sources)
+		wg.Add(1)
+		go saveCoverage(ctx, &wg, tracker, coveragePath)
 
 		// Wait for SIGINT or SIGTERM to trigger a graceful shutdown
 		sigChan := make(chan os.Signal, 1)
@@ -67,10 +120,15 @@ var connectCmd = &cobra.Command{
 }
 
 func init() {
-	connectCmd.Flags().StringVarP(&address, "address", "a", "", "address to connect to (include port)")
-	connectCmd.Flags().StringVarP(&mode, "mode", "m", "", "mode of source (currently only raw supported)")
+	connectCmd.Flags().StringArrayVarP(&addresses, "address", "a", nil, sourceUsage+", repeat to merge several")
+	connectCmd.Flags().StringVarP(&mode, "mode", "m", "", "mode of source (raw, beast, sbs, or iq2000 and iq2400 for RTL-SDR samples)")
 	connectCmd.Flags().Float64VarP(&latRef, "lat", "l", 0, "receiver latitude")
 	connectCmd.Flags().Float64VarP(&lonRef, "lon", "o", 0, "receiver longitude")
+	connectCmd.Flags().StringVar(&httpAddr, "http", "", "serve aircraft.json and friends on this address, e.g. :8080")
+	connectCmd.Flags().StringVar(&registryPath, "registry", "", "aircraft database to look up registrations, types and operators in, see registry import")
+	connectCmd.Flags().StringVar(&routesPath, "routes", "", "CSV file of callsign, origin and destination to look up routes in")
+	connectCmd.Flags().StringVar(&coveragePath, "coverage", "", "file to record the range reached per bearing and altitude in, kept across restarts")
+	addOutputFlags(connectCmd)
 
 	connectCmd.MarkFlagRequired("address")
 	connectCmd.MarkFlagRequired("mode")
@@ -80,42 +138,40 @@ func init() {
 	rootCmd.AddCommand(connectCmd)
 }
 
-func handleConnection(ctx context.Context, conn net.Conn, msgChan chan<- string, wg *sync.WaitGroup) {
-	wg.Add(1)
+// serveAPI serves the JSON API and the Prometheus metrics when an HTTP address was given.
+func serveAPI(ctx context.Context, wg *sync.WaitGroup, tracker *streaming.Tracker, addr string, sources []*source.Source) {
 	defer wg.Done()
 
-	readChan := make(chan string)
-	errChan := make(chan error)
+	if addr == "" {
+		return
+	}
 
-	// start a goroutine to read data from the connection
-	// bufio blocks if we don't do this, and we never get graceful shutdown
-	go func() {
-		for {
-			msg, err := bufio.NewReader(conn).ReadString('\n')
-			if err != nil {
-				errChan <- err
-				return
-			}
-			readChan <- msg
+	server := api.NewServer(tracker)
+	server.Handle("/metrics", metrics.NewHandler(tracker, sources))
+	if c := tracker.Coverage(); c != nil {
+		h := coverage.NewHandler(c)
+		for _, prefix := range []string{"/", "/data/"} {
+			server.Handle(prefix+"coverage.geojson", h)
+			server.Handle(prefix+"coverage.png", h)
 		}
-	}()
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			close(msgChan)
-			return
-		case msg := <-readChan:
-			msgChan <- msg
-		case err := <-errChan:
-			fmt.Println("Error reading message:", err)
-			return
-		}
+	if err := server.ListenAndServe(ctx, addr); err != nil {
+		fmt.Println("Error serving HTTP:", err)
 	}
 }
 
-func processMessages(ctx context.Context, msgChan <-chan string, wg *sync.WaitGroup, flightsState map[string]models.Flight) {
-	wg.Add(1)
+// readSource reads frames, or BaseStation messages in sbs mode, until ctx is cancelled or a file or stdin ends. Network
+// sources are reconnected whenever they fail.
+func readSource(ctx context.Context, src *source.Source, mode string, msgChan chan<- models.Frame, sbsChan chan<- formats.SBSMessage) error {
+	if mode == "sbs" {
+		return src.ReadSBS(ctx, sbsChan)
+	}
+	return src.ReadFrames(ctx, mode, msgChan)
+}
+
+// processMessages feeds the tracker, and passes every frame it accepts on to the outputs.
+func processMessages(ctx context.Context, msgChan <-chan models.Frame, sbsChan <-chan formats.SBSMessage, wg *sync.WaitGroup, tracker *streaming.Tracker, outputs []formats.FrameWriter) {
 	defer wg.Done()
 
 	for {
@@ -123,16 +179,18 @@ func processMessages(ctx context.Context, msgChan <-chan string, wg *sync.WaitGr
 		case <-ctx.Done():
 			return
 		case msg := <-msgChan:
-			// ignore other messages for now
-			if len(msg) == 31 {
-				streaming.DecodeAdsB(msg, flightsState, latRef, lonRef)
+			if tracker.Update(msg) {
+				for _, o := range outputs {
+					o.WriteFrame(msg)
+				}
 			}
+		case msg := <-sbsChan:
+			tracker.ApplySBS(msg)
 		}
 	}
 }
 
-func renderLoop(ctx context.Context, wg *sync.WaitGroup, flightsState map[string]models.Flight) {
-	wg.Add(1)
+func renderLoop(ctx context.Context, wg *sync.WaitGroup, tracker *streaming.Tracker) {
 	defer wg.Done()
 
 	tm.Clear()
@@ -143,17 +201,32 @@ func renderLoop(ctx context.Context, wg *sync.WaitGroup, flightsState map[string
 			tm.Clear()
 			return
 		default:
+			tracker.Expire()
+
 			tm.MoveCursor(1, 1)
 
 			tbl := tm.NewTable(0, 10, 5, ' ', 0)
-			fmt.Fprintf(tbl, "ICAO\t Callsign \t Altitude \t Speed \tHeading \t VertRate \t Lat \t Lon \n")
+			fmt.Fprintf(tbl, "ICAO\t Callsign \t Airline \t Route \t Reg \t Type \t Squawk \t Altitude \t Speed \tHeading \t VertRate \t Lat \t Lon \t RSSI \n")
 
-			for _, f := range flightsState {
-				fmt.Fprintf(tbl, "%s \t %s \t %d \t %f \t %f \t %d \t %f \t %f \n", f.Icao, f.Callsign, f.Altitude, f.Velocity.Speed, f.Velocity.Angle, f.Velocity.VertRate, f.Position.Latitude, f.Position.Longitude)
+			for _, f := range tracker.Flights() {
+				fmt.Fprintf(tbl, "%s \t %s \t %s \t %s \t %s \t %s \t %s \t %d \t %f \t %f \t %d \t %f \t %f \t %.1f \n", f.Icao, f.Callsign, f.Airline, route(f), f.Registration, f.TypeCode, f.Squawk, f.Altitude, f.Velocity.Speed, f.Velocity.Angle, f.Velocity.VertRate, f.Position.Latitude, f.Position.Longitude, f.RSSI)
 			}
 
 			tm.Println(tbl)
 
+			if targets := tracker.ModeAC(); len(targets) > 0 {
+				codes := make([]string, 0, len(targets))
+				for _, target := range targets {
+					codes = append(codes, target.Squawk)
+				}
+				tm.Println("Mode A/C only: " + strings.Join(codes, " ") + "\033[K")
+			}
+
+			for _, status := range statusLines {
+				// clear the rest of the line, the status may have become shorter
+				tm.Println(status() + "\033[K")
+			}
+
 			tm.Flush()
 
 			// do this once a second
@@ -161,3 +234,11 @@ func renderLoop(ctx context.Context, wg *sync.WaitGroup, flightsState map[string
 		}
 	}
 }
+
+// route formats the origin and destination of a flight as EHAM-EGLL, empty when unknown.
+func route(f models.Flight) string {
+	if f.Origin == "" {
+		return ""
+	}
+	return f.Origin + "-" + f.Destination
+}
@@ -0,0 +1,259 @@
+// Package config reads the configuration of the gomodes serve daemon from a YAML or TOML file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as a string such as "90s" or "1h".
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config is the complete daemon configuration.
+type Config struct {
+	Receiver Receiver `yaml:"receiver" toml:"receiver"`
+	Inputs   []Input  `yaml:"inputs" toml:"inputs"`
+	Tracker  Tracker  `yaml:"tracker" toml:"tracker"`
+	// HTTP is the listen address of the JSON API, empty to disable it.
+	HTTP    string   `yaml:"http" toml:"http"`
+	Outputs []Output `yaml:"outputs" toml:"outputs"`
+	Sinks   Sinks    `yaml:"sinks" toml:"sinks"`
+	// LogInterval is how often the state of inputs and sinks is logged, negative to disable. Defaults to 1 minute.
+	LogInterval Duration `yaml:"log_interval" toml:"log_interval"`
+	// Registry is the aircraft database written by gomodes registry import, empty for none.
+	Registry string `yaml:"registry" toml:"registry"`
+	// Routes is a CSV file of callsign, origin and destination, empty for none.
+	Routes string `yaml:"routes" toml:"routes"`
+	// Coverage is the file the range reached by each input is recorded in, empty to not record it.
+	Coverage string `yaml:"coverage" toml:"coverage"`
+	// MLAT enables multilateration from the timestamps of the inputs that have a position.
+	MLAT bool `yaml:"mlat" toml:"mlat"`
+}
+
+// Receiver is the location of the antenna, used to decode positions.
+type Receiver struct {
+	Lat float64 `yaml:"lat" toml:"lat"`
+	Lon float64 `yaml:"lon" toml:"lon"`
+}
+
+// Input is a source of receiver data, see source.Parse for the address syntax.
+type Input struct {
+	Address string `yaml:"address" toml:"address"`
+	// Name identifies the input in logs, metrics and the receivers of each aircraft. Defaults to the address.
+	Name string `yaml:"name" toml:"name"`
+	// Mode is the format of the data, "raw", "beast", "sbs", or "iq2000" and "iq2400" for IQ samples.
+	Mode string `yaml:"mode" toml:"mode"`
+	// Position is the location of the input's antenna, needed for multilateration.
+	Position *Position `yaml:"position" toml:"position"`
+}
+
+// Position is the location of an antenna. Alt is the height above the WGS84 ellipsoid in meters.
+type Position struct {
+	Lat float64 `yaml:"lat" toml:"lat"`
+	Lon float64 `yaml:"lon" toml:"lon"`
+	Alt float64 `yaml:"alt" toml:"alt"`
+}
+
+// Tracker holds the tracker settings.
+type Tracker struct {
+	// Expiry is how long an aircraft is kept after it was last heard. Defaults to 60 seconds.
+	Expiry Duration `yaml:"expiry" toml:"expiry"`
+	// MergeWindow is how long identical frames from different inputs are taken to be copies of one transmission. It
+	// must cover the difference in latency between the inputs, negative to apply every copy. Defaults to 2 seconds.
+	MergeWindow Duration `yaml:"merge_window" toml:"merge_window"`
+}
+
+// Output is a TCP server re-broadcasting accepted frames.
+type Output struct {
+	// Mode is the format served, "raw", "beast" or "sbs".
+	Mode   string `yaml:"mode" toml:"mode"`
+	Listen string `yaml:"listen" toml:"listen"`
+}
+
+// Sinks are the places accepted frames are sent to.
+type Sinks struct {
+	Feeds  []Feed  `yaml:"feeds" toml:"feeds"`
+	Record *Record `yaml:"record" toml:"record"`
+}
+
+// Feed is a remote aggregator.
+type Feed struct {
+	Address string `yaml:"address" toml:"address"`
+	// Mode is the format sent, "beast" or "raw". Defaults to "beast".
+	Mode string `yaml:"mode" toml:"mode"`
+	// Buffer is the number of frames kept while disconnected, 0 for the default.
+	Buffer int `yaml:"buffer" toml:"buffer"`
+}
+
+// Record writes capture files.
+type Record struct {
+	Dir    string `yaml:"dir" toml:"dir"`
+	Prefix string `yaml:"prefix" toml:"prefix"`
+	// MaxSize is the size in megabytes after which a new file is started, 0 to disable.
+	MaxSize int64 `yaml:"max_size" toml:"max_size"`
+	// MaxAge is the age after which a new file is started, 0 to disable.
+	MaxAge Duration `yaml:"max_age" toml:"max_age"`
+	Gzip   bool     `yaml:"gzip" toml:"gzip"`
+}
+
+// Load reads a configuration file. The format is chosen by the extension, .yaml, .yml or .toml. Unknown keys are an
+// error, so that typos do not go unnoticed.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("%s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return Config{}, fmt.Errorf("%s: unknown config format, use .yaml, .yml or .toml", path)
+	}
+
+	cfg.setDefaults()
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.Tracker.Expiry == 0 {
+		c.Tracker.Expiry = Duration(60 * time.Second)
+	}
+	if c.Tracker.MergeWindow == 0 {
+		c.Tracker.MergeWindow = Duration(2 * time.Second)
+	}
+	for i := range c.Inputs {
+		if c.Inputs[i].Name == "" {
+			c.Inputs[i].Name = c.Inputs[i].Address
+		}
+	}
+	if c.LogInterval == 0 {
+		c.LogInterval = Duration(time.Minute)
+	}
+	for i := range c.Sinks.Feeds {
+		if c.Sinks.Feeds[i].Mode == "" {
+			c.Sinks.Feeds[i].Mode = "beast"
+		}
+	}
+}
+
+// Validate checks the configuration for mistakes.
+func (c Config) Validate() error {
+	if c.Receiver.Lat < -90 || c.Receiver.Lat > 90 || c.Receiver.Lon < -180 || c.Receiver.Lon > 180 {
+		return fmt.Errorf("receiver location %v, %v is out of range", c.Receiver.Lat, c.Receiver.Lon)
+	}
+
+	if len(c.Inputs) == 0 {
+		return errors.New("no inputs configured")
+	}
+	names := make(map[string]bool)
+	for i, in := range c.Inputs {
+		if in.Address == "" {
+			return fmt.Errorf("input %d: missing address", i+1)
+		}
+		if names[in.Name] {
+			return fmt.Errorf("input %d: duplicate name %q", i+1, in.Name)
+		}
+		names[in.Name] = true
+		if !oneOf(in.Mode, "raw", "beast", "sbs", "iq2000", "iq2400") {
+			return fmt.Errorf("input %d: unsupported mode %q", i+1, in.Mode)
+		}
+		if p := in.Position; p != nil {
+			if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+				return fmt.Errorf("input %d: position %v, %v is out of range", i+1, p.Lat, p.Lon)
+			}
+			if in.Mode == "sbs" {
+				return fmt.Errorf("input %d: sbs inputs have no timestamps to multilaterate with", i+1)
+			}
+		}
+	}
+
+	if c.MLAT {
+		positioned := 0
+		for _, in := range c.Inputs {
+			if in.Position != nil {
+				positioned++
+			}
+		}
+		if positioned < 3 {
+			return fmt.Errorf("mlat needs at least 3 inputs with a position, got %d", positioned)
+		}
+	}
+
+	if c.Tracker.Expiry < 0 {
+		return errors.New("tracker expiry must be positive")
+	}
+
+	for i, o := range c.Outputs {
+		if o.Listen == "" {
+			return fmt.Errorf("output %d: missing listen address", i+1)
+		}
+		if !oneOf(o.Mode, "raw", "beast", "sbs") {
+			return fmt.Errorf("output %d: unsupported mode %q", i+1, o.Mode)
+		}
+	}
+
+	for i, f := range c.Sinks.Feeds {
+		if f.Address == "" {
+			return fmt.Errorf("feed %d: missing address", i+1)
+		}
+		if !oneOf(f.Mode, "raw", "beast") {
+			return fmt.Errorf("feed %d: unsupported mode %q", i+1, f.Mode)
+		}
+	}
+
+	if r := c.Sinks.Record; r != nil && r.Dir == "" {
+		return errors.New("record: missing dir")
+	}
+
+	return nil
+}
+
+func oneOf(s string, values ...string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
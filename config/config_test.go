@@ -0,0 +1,194 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testYAML = `
+receiver:
+  lat: 51.99
+  lon: 4.37
+inputs:
+  - address: localhost:30005
+    mode: beast
+    position:
+      lat: 52.01
+      lon: 4.36
+      alt: 45
+  - address: udp://:30006
+    name: roof
+    mode: raw
+tracker:
+  expiry: 90s
+  merge_window: 1s
+http: ":8080"
+registry: /var/lib/gomodes/aircraft.csv.gz
+routes: /var/lib/gomodes/routes.csv
+coverage: /var/lib/gomodes/coverage.json
+outputs:
+  - mode: sbs
+    listen: ":30003"
+sinks:
+  feeds:
+    - address: feed.example.com:30004
+  record:
+    dir: /var/lib/gomodes
+    max_size: 100
+    max_age: 1h
+    gzip: true
+`
+
+const testTOML = `
+http = ":8080"
+registry = "/var/lib/gomodes/aircraft.csv.gz"
+routes = "/var/lib/gomodes/routes.csv"
+coverage = "/var/lib/gomodes/coverage.json"
+
+[receiver]
+lat = 51.99
+lon = 4.37
+
+[[inputs]]
+address = "localhost:30005"
+mode = "beast"
+position = { lat = 52.01, lon = 4.36, alt = 45 }
+
+[[inputs]]
+address = "udp://:30006"
+name = "roof"
+mode = "raw"
+
+[tracker]
+expiry = "90s"
+merge_window = "1s"
+
+[[outputs]]
+mode = "sbs"
+listen = ":30003"
+
+[[sinks.feeds]]
+address = "feed.example.com:30004"
+
+[sinks.record]
+dir = "/var/lib/gomodes"
+max_size = 100
+max_age = "1h"
+gzip = true
+`
+
+var wantConfig = Config{
+	Receiver: Receiver{Lat: 51.99, Lon: 4.37},
+	Inputs: []Input{
+		{Address: "localhost:30005", Name: "localhost:30005", Mode: "beast", Position: &Position{Lat: 52.01, Lon: 4.36, Alt: 45}},
+		{Address: "udp://:30006", Name: "roof", Mode: "raw"},
+	},
+	Tracker:  Tracker{Expiry: Duration(90 * time.Second), MergeWindow: Duration(time.Second)},
+	HTTP:     ":8080",
+	Registry: "/var/lib/gomodes/aircraft.csv.gz",
+	Routes:   "/var/lib/gomodes/routes.csv",
+	Coverage: "/var/lib/gomodes/coverage.json",
+	Outputs:  []Output{{Mode: "sbs", Listen: ":30003"}},
+	Sinks: Sinks{
+		Feeds:  []Feed{{Address: "feed.example.com:30004", Mode: "beast"}},
+		Record: &Record{Dir: "/var/lib/gomodes", MaxSize: 100, MaxAge: Duration(time.Hour), Gzip: true},
+	},
+	LogInterval: Duration(time.Minute),
+}
+
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	for name, content := range map[string]string{"gomodes.yaml": testYAML, "gomodes.toml": testTOML} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if !reflect.DeepEqual(cfg, wantConfig) {
+				t.Fatalf("config incorrect, wanted %+v got %+v", wantConfig, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadLogInterval(t *testing.T) {
+	tests := []struct {
+		content string
+		want    time.Duration
+	}{
+		{"", time.Minute},
+		{"log_interval: 10s\n", 10 * time.Second},
+		{"log_interval: -1s\n", -time.Second},
+	}
+
+	for _, test := range tests {
+		cfg, err := Load(writeConfig(t, "gomodes.yaml", test.content+"inputs:\n  - address: localhost:30005\n    mode: beast\n"))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if time.Duration(cfg.LogInterval) != test.want {
+			t.Fatalf("%q: log interval incorrect, wanted %v got %v", test.content, test.want, time.Duration(cfg.LogInterval))
+		}
+	}
+}
+
+func TestLoadMergeWindow(t *testing.T) {
+	tests := []struct {
+		content string
+		want    time.Duration
+	}{
+		{"", 2 * time.Second},
+		{"tracker:\n  merge_window: 500ms\n", 500 * time.Millisecond},
+		{"tracker:\n  merge_window: -1s\n", -time.Second},
+	}
+
+	for _, test := range tests {
+		cfg, err := Load(writeConfig(t, "gomodes.yaml", test.content+"inputs:\n  - address: localhost:30005\n    mode: beast\n"))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if time.Duration(cfg.Tracker.MergeWindow) != test.want {
+			t.Fatalf("%q: merge window incorrect, wanted %v got %v", test.content, test.want, time.Duration(cfg.Tracker.MergeWindow))
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"gomodes.yaml", "inputs:\n  - address: localhost:30005\n    mode: beast\n    typo: 1\n", "typo"},
+		{"gomodes.toml", "[[inputs]]\naddress = \"localhost:30005\"\nmode = \"beast\"\ntypo = 1\n", "typo"},
+		{"gomodes.yaml", "receiver:\n  lat: 12\n", "no inputs"},
+		{"gomodes.yaml", "inputs:\n  - address: localhost:30005\n    mode: avr\n", "unsupported mode"},
+		{"gomodes.yaml", "inputs:\n  - address: localhost:30005\n    mode: raw\nsinks:\n  feeds:\n    - address: x:1\n      mode: sbs\n", "feed 1"},
+		{"gomodes.yaml", "inputs:\n  - address: localhost:30005\n    mode: raw\ntracker:\n  expiry: soon\n", "soon"},
+		{"gomodes.yaml", "inputs:\n  - address: localhost:30003\n    mode: sbs\n    position: {lat: 52, lon: 4}\n", "timestamps"},
+		{"gomodes.yaml", "mlat: true\ninputs:\n  - address: localhost:30005\n    mode: beast\n    position: {lat: 52, lon: 4}\n", "at least 3"},
+		{"gomodes.yaml", "inputs:\n  - address: a:1\n    mode: raw\n  - address: b:1\n    name: a:1\n    mode: raw\n", "duplicate name"},
+		{"gomodes.json", "{}", "unknown config format"},
+	}
+
+	for _, test := range tests {
+		_, err := Load(writeConfig(t, test.name, test.content))
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%q: error incorrect, wanted %q got %v", test.content, test.wantErr, err)
+		}
+	}
+}
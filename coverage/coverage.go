@@ -0,0 +1,325 @@
+// Package coverage records how far each receiver hears aircraft: the furthest position decoded in every bearing sector
+// and altitude band. Coverage builds up over the lifetime of a receiver, so it is saved to disk and restored on start,
+// and can be exported as GeoJSON polygons or a PNG polar plot.
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/geo"
+)
+
+// Sectors is the number of bearing sectors, each SectorWidth degrees wide. Sector 0 is centred on true north.
+const (
+	Sectors     = 72
+	SectorWidth = 360.0 / Sectors
+)
+
+// Bands are the lower limits, in feet, of the altitude bands. Aircraft on the ground are in the lowest band.
+var Bands = []int{0, 5000, 10000, 20000, 30000}
+
+// moved is the distance, in meters, a receiver can be away from the location of a saved plot before the plot is
+// discarded on restore.
+const moved = 100.0
+
+// fileVersion is the version of the saved format.
+const fileVersion = 1
+
+// Cell is the furthest position heard in a sector and altitude band. Range is zero when nothing was heard.
+type Cell struct {
+	Range    float64   `json:"range"`
+	Lat      float64   `json:"lat"`
+	Lon      float64   `json:"lon"`
+	Altitude int       `json:"alt"`
+	Time     time.Time `json:"time"`
+}
+
+// Plot is the coverage of one receiver.
+type Plot struct {
+	Receiver string  `json:"receiver"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	// Since is when the receiver's coverage started to be recorded.
+	Since time.Time `json:"since"`
+	// Cells are indexed by band, then sector.
+	Cells [][]Cell `json:"cells"`
+}
+
+func newPlot(receiver string, lat float64, lon float64, since time.Time) *Plot {
+	p := &Plot{Receiver: receiver, Lat: lat, Lon: lon, Since: since, Cells: make([][]Cell, len(Bands))}
+	for i := range p.Cells {
+		p.Cells[i] = make([]Cell, Sectors)
+	}
+	return p
+}
+
+// MaxRange returns the furthest distance heard in any sector and band.
+func (p Plot) MaxRange() float64 {
+	r := 0.0
+	for _, band := range p.Cells {
+		for _, cell := range band {
+			r = math.Max(r, cell.Range)
+		}
+	}
+	return r
+}
+
+func (p *Plot) copy() Plot {
+	c := *p
+	c.Cells = make([][]Cell, len(p.Cells))
+	for i := range p.Cells {
+		c.Cells[i] = append([]Cell(nil), p.Cells[i]...)
+	}
+	return c
+}
+
+// band returns the altitude band of an altitude in feet.
+func band(alt int) int {
+	b := 0
+	for i, limit := range Bands {
+		if alt >= limit {
+			b = i
+		}
+	}
+	return b
+}
+
+// sector returns the sector of a bearing in degrees.
+func sector(bearing float64) int {
+	return int(math.Floor(bearing/SectorWidth+0.5)) % Sectors
+}
+
+// Coverage is the coverage of a set of receivers. It is safe for concurrent use.
+type Coverage struct {
+	mu sync.Mutex
+	// lat and lon locate receivers without a location of their own
+	lat       float64
+	lon       float64
+	locations map[string][2]float64
+	plots     map[string]*Plot
+}
+
+// New returns an empty Coverage for receivers at the given location.
+func New(lat float64, lon float64) *Coverage {
+	return &Coverage{
+		lat:       lat,
+		lon:       lon,
+		locations: make(map[string][2]float64),
+		plots:     make(map[string]*Plot),
+	}
+}
+
+// SetLocation sets the location of a receiver that is not at the location given to New. Ranges already recorded for
+// the receiver are discarded.
+func (c *Coverage) SetLocation(receiver string, lat float64, lon float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.locations[receiver] = [2]float64{lat, lon}
+	delete(c.plots, receiver)
+}
+
+func (c *Coverage) location(receiver string) (float64, float64) {
+	if l, ok := c.locations[receiver]; ok {
+		return l[0], l[1]
+	}
+	return c.lat, c.lon
+}
+
+// Add records a position heard by a receiver at the given time, with its altitude in feet. It reports whether the
+// position is the furthest in its sector and band so far.
+func (c *Coverage) Add(receiver string, lat float64, lon float64, alt int, t time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rxLat, rxLon := c.location(receiver)
+	r := geo.Distance(rxLat, rxLon, lat, lon)
+	// a bad decode would otherwise stay in the plot for good
+	if r > geo.MaxPlausibleRange {
+		return false
+	}
+
+	p, ok := c.plots[receiver]
+	if !ok {
+		p = newPlot(receiver, rxLat, rxLon, t)
+		c.plots[receiver] = p
+	}
+
+	cell := &p.Cells[band(alt)][sector(geo.Bearing(rxLat, rxLon, lat, lon))]
+	if r <= cell.Range {
+		return false
+	}
+	*cell = Cell{Range: r, Lat: lat, Lon: lon, Altitude: alt, Time: t}
+
+	return true
+}
+
+// Plots returns a copy of the coverage of every receiver, sorted by receiver.
+func (c *Coverage) Plots() []Plot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	plots := make([]Plot, 0, len(c.plots))
+	for _, p := range c.plots {
+		plots = append(plots, p.copy())
+	}
+	sort.Slice(plots, func(i, j int) bool {
+		return plots[i].Receiver < plots[j].Receiver
+	})
+
+	return plots
+}
+
+// file is the saved format.
+type file struct {
+	Version int    `json:"version"`
+	Sectors int    `json:"sectors"`
+	Bands   []int  `json:"bands"`
+	Plots   []Plot `json:"plots"`
+}
+
+// Write writes the coverage as JSON.
+func (c *Coverage) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(file{Version: fileVersion, Sectors: Sectors, Bands: Bands, Plots: c.Plots()})
+}
+
+// errIncompatible is returned for files written with different sectors or bands.
+var errIncompatible = errors.New("coverage file with different sectors or altitude bands")
+
+func readFile(r io.Reader) (file, error) {
+	var f file
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return file{}, err
+	}
+	if f.Version != fileVersion {
+		return file{}, fmt.Errorf("unsupported coverage file version %d", f.Version)
+	}
+	if f.Sectors != Sectors || !equal(f.Bands, Bands) {
+		return file{}, errIncompatible
+	}
+
+	for _, p := range f.Plots {
+		if len(p.Cells) != len(Bands) {
+			return file{}, errors.New("coverage file with a wrong number of bands")
+		}
+		for _, band := range p.Cells {
+			if len(band) != Sectors {
+				return file{}, errors.New("coverage file with a wrong number of sectors")
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// Read restores coverage written by Write. Plots of receivers that have moved since are discarded, and so is
+// everything when the file was written with different sectors or bands.
+func (c *Coverage) Read(r io.Reader) error {
+	f, err := readFile(r)
+	if errors.Is(err, errIncompatible) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, saved := range f.Plots {
+		lat, lon := c.location(saved.Receiver)
+		if geo.Distance(lat, lon, saved.Lat, saved.Lon) > moved {
+			continue
+		}
+
+		p, ok := c.plots[saved.Receiver]
+		if !ok {
+			p = newPlot(saved.Receiver, lat, lon, saved.Since)
+			c.plots[saved.Receiver] = p
+		}
+		if saved.Since.Before(p.Since) {
+			p.Since = saved.Since
+		}
+		for b := range saved.Cells {
+			for s, cell := range saved.Cells[b] {
+				if cell.Range > p.Cells[b][s].Range {
+					p.Cells[b][s] = cell
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func equal(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Load restores the coverage saved at path. A missing file is not an error, there is nothing to restore yet.
+func (c *Coverage) Load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := c.Read(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadPlots reads the plots of every receiver saved at path, wherever the receivers are now.
+func LoadPlots(path string) ([]Plot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	saved, err := readFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return saved.Plots, nil
+}
+
+// Save writes the coverage to path, through a temporary file so that a failed write keeps the previous one.
+func (c *Coverage) Save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	err = c.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
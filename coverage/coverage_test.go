@@ -0,0 +1,188 @@
+package coverage
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/geo"
+)
+
+var start = time.Unix(1457996400, 0)
+
+// add records a position at the given bearing and range from 52, 4.
+func add(c *Coverage, receiver string, bearing float64, r float64, alt int) bool {
+	lat, lon := geo.Destination(52, 4, bearing, r)
+	return c.Add(receiver, lat, lon, alt, start)
+}
+
+func TestAdd(t *testing.T) {
+	c := New(52, 4)
+
+	if !add(c, "roof", 0, 100e3, 35000) || !add(c, "roof", 0, 150e3, 36000) {
+		t.Fatalf("Add incorrect, wanted the furthest position to be recorded")
+	}
+	if add(c, "roof", 1, 120e3, 37000) {
+		t.Fatalf("Add incorrect, a shorter range in the same sector and band was recorded")
+	}
+	add(c, "roof", 92, 40e3, 0)
+	add(c, "roof", 180, 1200e3, 3000)
+	add(c, "garden", 270, 20e3, 12000)
+
+	plots := c.Plots()
+	if len(plots) != 2 || plots[0].Receiver != "garden" || plots[1].Receiver != "roof" {
+		t.Fatalf("Plots incorrect, got %v", plots)
+	}
+
+	roof := plots[1]
+	if got := roof.Cells[4][0]; math.Abs(got.Range-150e3) > 1 || got.Altitude != 36000 || !got.Time.Equal(start) {
+		t.Fatalf("cell incorrect, wanted a range of %v at %v got %+v", 150e3, 36000, got)
+	}
+	if got := roof.Cells[0][18].Range; math.Abs(got-40e3) > 1 {
+		t.Fatalf("cell incorrect, wanted %v got %v", 40e3, got)
+	}
+	// implausible ranges are not recorded
+	if got := roof.Cells[0][36].Range; got != 0 {
+		t.Fatalf("cell incorrect, wanted %v got %v", 0, got)
+	}
+	if got := roof.MaxRange(); math.Abs(got-150e3) > 1 {
+		t.Fatalf("MaxRange incorrect, wanted %v got %v", 150e3, got)
+	}
+	if got := plots[0].Cells[2][54].Range; math.Abs(got-20e3) > 1 {
+		t.Fatalf("cell incorrect, wanted %v got %v", 20e3, got)
+	}
+}
+
+func TestWriteAndRead(t *testing.T) {
+	c := New(52, 4)
+	add(c, "roof", 0, 100e3, 35000)
+	add(c, "garden", 90, 50e3, 35000)
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// the garden antenna moved, its coverage no longer applies
+	restored := New(52, 4)
+	restored.SetLocation("garden", 52.1, 4)
+	add(restored, "roof", 0, 80e3, 35000)
+	if err := restored.Read(&buf); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	plots := restored.Plots()
+	if len(plots) != 1 || plots[0].Receiver != "roof" {
+		t.Fatalf("Plots incorrect, wanted only roof got %v", plots)
+	}
+	if got := plots[0].Cells[4][0].Range; math.Abs(got-100e3) > 1 {
+		t.Fatalf("cell incorrect, wanted the saved range %v got %v", 100e3, got)
+	}
+
+	if err := restored.Read(bytes.NewBufferString(`{"version":2}`)); err == nil {
+		t.Fatalf("expected an error for an unknown version")
+	}
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	c := New(52, 4)
+	add(c, "roof", 0, 100e3, 35000)
+	add(c, "roof", 90, 50e3, 2000)
+
+	var buf bytes.Buffer
+	if err := WriteGeoJSON(&buf, c.Plots()); err != nil {
+		t.Fatalf("WriteGeoJSON failed: %v", err)
+	}
+
+	var fc featureCollection
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("feature count incorrect, wanted %v got %v", 2, len(fc.Features))
+	}
+
+	low := fc.Features[0]
+	if low.Properties.MinAltitude != 0 || low.Properties.MaxAltitude == nil || *low.Properties.MaxAltitude != 5000 {
+		t.Fatalf("properties incorrect, got %+v", low.Properties)
+	}
+	ring := low.Geometry.Coordinates[0]
+	if len(ring) != Sectors+1 || ring[0] != ring[len(ring)-1] {
+		t.Fatalf("ring incorrect, wanted %v closed positions got %v", Sectors+1, len(ring))
+	}
+	// the point due east of the receiver
+	east := ring[Sectors-1-18]
+	if d := geo.Distance(52, 4, east[1], east[0]); math.Abs(d-50e3) > 1 {
+		t.Fatalf("east point incorrect, wanted %v from the receiver got %v", 50e3, d)
+	}
+
+	if high := fc.Features[1]; high.Properties.MinAltitude != 30000 || high.Properties.MaxAltitude != nil {
+		t.Fatalf("properties incorrect, got %+v", high.Properties)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	c := New(52, 4)
+	add(c, "roof", 0, 100e3, 2000)
+	add(c, "roof", 180, 40e3, 35000)
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, c.Plots()[0], 200); err != nil {
+		t.Fatalf("WritePNG failed: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 200 {
+		t.Fatalf("size incorrect, wanted %v got %v", 200, img.Bounds().Dx())
+	}
+
+	tests := []struct {
+		x, y int
+		want [3]uint8
+	}{
+		{102, 40, [3]uint8{0xe6, 0x39, 0x46}}, // north, low band
+		{99, 120, [3]uint8{0x45, 0x7b, 0x9d}}, // south, high band
+		{60, 95, [3]uint8{0x10, 0x14, 0x18}},  // west, nothing heard
+	}
+	for _, test := range tests {
+		r, g, b, _ := img.At(test.x, test.y).RGBA()
+		if got := [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}; got != test.want {
+			t.Fatalf("pixel %v,%v incorrect, wanted %v got %v", test.x, test.y, test.want, got)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	c := New(52, 4)
+	add(c, "roof", 0, 100e3, 2000)
+	h := NewHandler(c)
+
+	tests := []struct {
+		path string
+		code int
+		typ  string
+	}{
+		{"/data/coverage.geojson", http.StatusOK, "application/geo+json"},
+		{"/data/coverage.png?size=100", http.StatusOK, "image/png"},
+		{"/data/coverage.png?receiver=roof", http.StatusOK, "image/png"},
+		{"/data/coverage.png?receiver=garden", http.StatusNotFound, ""},
+		{"/data/coverage.png?size=1", http.StatusBadRequest, ""},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if rec.Code != test.code {
+			t.Fatalf("%v: status incorrect, wanted %v got %v", test.path, test.code, rec.Code)
+		}
+		if test.typ != "" && rec.Header().Get("Content-Type") != test.typ {
+			t.Fatalf("%v: content type incorrect, wanted %v got %v", test.path, test.typ, rec.Header().Get("Content-Type"))
+		}
+	}
+}
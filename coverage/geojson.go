@@ -0,0 +1,75 @@
+package coverage
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pragmatic-zac/goModeS/geo"
+)
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string            `json:"type"`
+	Geometry   polygon           `json:"geometry"`
+	Properties featureProperties `json:"properties"`
+}
+
+type polygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+type featureProperties struct {
+	Receiver string `json:"receiver"`
+	// MinAltitude and MaxAltitude are the limits of the band in feet, MaxAltitude is omitted for the highest band
+	MinAltitude int     `json:"min_altitude"`
+	MaxAltitude *int    `json:"max_altitude,omitempty"`
+	MaxRange    float64 `json:"max_range"`
+}
+
+// WriteGeoJSON writes a FeatureCollection with a polygon for every receiver and altitude band something was heard in.
+// The polygon joins the furthest range of each sector, at the sector's centre bearing. Sectors where nothing was heard
+// pull the outline back to the receiver. Rings are counterclockwise, as RFC 7946 asks.
+func WriteGeoJSON(w io.Writer, plots []Plot) error {
+	fc := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+
+	for _, p := range plots {
+		for b, cells := range p.Cells {
+			maxRange := 0.0
+			ring := make([][2]float64, 0, Sectors+1)
+			for s := Sectors - 1; s >= 0; s-- {
+				cell := cells[s]
+				lat, lon := p.Lat, p.Lon
+				if cell.Range > 0 {
+					lat, lon = geo.Destination(p.Lat, p.Lon, float64(s)*SectorWidth, cell.Range)
+					if cell.Range > maxRange {
+						maxRange = cell.Range
+					}
+				}
+				// GeoJSON positions are longitude first
+				ring = append(ring, [2]float64{lon, lat})
+			}
+			if maxRange == 0 {
+				continue
+			}
+			ring = append(ring, ring[0])
+
+			props := featureProperties{Receiver: p.Receiver, MinAltitude: Bands[b], MaxRange: maxRange}
+			if b+1 < len(Bands) {
+				limit := Bands[b+1]
+				props.MaxAltitude = &limit
+			}
+			fc.Features = append(fc.Features, feature{
+				Type:       "Feature",
+				Geometry:   polygon{Type: "Polygon", Coordinates: [][][2]float64{ring}},
+				Properties: props,
+			})
+		}
+	}
+
+	return json.NewEncoder(w).Encode(fc)
+}
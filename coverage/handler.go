@@ -0,0 +1,65 @@
+package coverage
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultSize is the width and height of PNG plots served without a size.
+const defaultSize = 800
+
+// Handler serves the coverage as GeoJSON at a path ending in .geojson and as a PNG plot at a path ending in .png. The
+// plot is of the receiver named by the receiver query parameter, the first receiver by name without one, and is size
+// pixels square.
+type Handler struct {
+	coverage *Coverage
+}
+
+// NewHandler returns a Handler for the coverage.
+func NewHandler(c *Coverage) *Handler {
+	return &Handler{coverage: c}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache")
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, ".geojson"):
+		w.Header().Set("Content-Type", "application/geo+json")
+		if err := WriteGeoJSON(w, h.coverage.Plots()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	case strings.HasSuffix(r.URL.Path, ".png"):
+		h.servePNG(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) servePNG(w http.ResponseWriter, r *http.Request) {
+	size := defaultSize
+	if s := r.URL.Query().Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 64 || n > 4096 {
+			http.Error(w, "size must be between 64 and 4096", http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	plots := h.coverage.Plots()
+	receiver := r.URL.Query().Get("receiver")
+	for _, p := range plots {
+		if receiver == "" || p.Receiver == receiver {
+			w.Header().Set("Content-Type", "image/png")
+			if err := WritePNG(w, p, size); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
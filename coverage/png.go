@@ -0,0 +1,82 @@
+package coverage
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+var (
+	background = color.RGBA{0x10, 0x14, 0x18, 0xff}
+	grid       = color.RGBA{0x50, 0x58, 0x60, 0xff}
+	// bandColors are the colours of the altitude bands, lowest first
+	bandColors = []color.RGBA{
+		{0xe6, 0x39, 0x46, 0xff},
+		{0xf4, 0xa2, 0x61, 0xff},
+		{0xe9, 0xc4, 0x6a, 0xff},
+		{0x2a, 0x9d, 0x8f, 0xff},
+		{0x45, 0x7b, 0x9d, 0xff},
+	}
+)
+
+// ringSpacing returns the distance between range rings, in meters, for a plot reaching maxRange.
+func ringSpacing(maxRange float64) float64 {
+	if maxRange > 300e3 {
+		return 100e3
+	}
+	return 50e3
+}
+
+// WritePNG draws a polar plot of a receiver's coverage, size pixels square, with north up. Each altitude band is
+// filled up to its furthest range in every sector, lower bands drawn over higher ones: red, orange, yellow, green and
+// blue from the ground up. Range rings are 50 km apart, or 100 km when the coverage reaches beyond 300 km, and spokes
+// mark every 30 degrees.
+func WritePNG(w io.Writer, p Plot, size int) error {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	spacing := ringSpacing(p.MaxRange())
+	extent := math.Max(spacing, math.Ceil(p.MaxRange()/spacing)*spacing)
+	centre := float64(size) / 2
+	// meters per pixel, leaving a small margin around the outer ring
+	scale := extent / (centre - 4)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx := float64(x) + 0.5 - centre
+			dy := centre - float64(y) - 0.5
+			r := math.Hypot(dx, dy) * scale
+			s := sector(math.Mod(math.Atan2(dx, dy)*180/math.Pi+360, 360))
+
+			c := background
+			for b := range p.Cells {
+				if r <= p.Cells[b][s].Range {
+					c = bandColors[b%len(bandColors)]
+					break
+				}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	// range rings
+	for ring := spacing; ring <= extent; ring += spacing {
+		radius := ring / scale
+		steps := int(2 * math.Pi * radius * 2)
+		for i := 0; i < steps; i++ {
+			angle := 2 * math.Pi * float64(i) / float64(steps)
+			img.SetRGBA(int(centre+radius*math.Sin(angle)), int(centre-radius*math.Cos(angle)), grid)
+		}
+	}
+
+	// spokes
+	for bearing := 0.0; bearing < 360; bearing += 30 {
+		angle := bearing * math.Pi / 180
+		for radius := 0.0; radius <= extent/scale; radius += 0.5 {
+			img.SetRGBA(int(centre+radius*math.Sin(angle)), int(centre-radius*math.Cos(angle)), grid)
+		}
+	}
+
+	return png.Encode(w, img)
+}
@@ -15,8 +15,8 @@ import (
 //   - Latitude: a float64 that represents the latitude of the airborne position.
 //   - Longitude: a float64 that represents the longitude of the airborne position.
 type Position struct {
-	Latitude  float64
-	Longitude float64
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 // Velocity is a struct that represents the calculated airborne velocity information, including the speed, angle,
@@ -29,11 +29,11 @@ type Position struct {
 //   - SpeedType: a string that represents the type of speed, either "GS" for ground speed, "IAS" for indicated air speed, or "TAS" for true air speed.
 //   - RateSource: a string that represents the source of the vertical rate, either "GNSS" or "BARO".
 type Velocity struct {
-	Speed      float64
-	Angle      float64
-	VertRate   int32
-	SpeedType  string
-	RateSource string
+	Speed      float64 `json:"speed"`
+	Angle      float64 `json:"angle"`
+	VertRate   int32   `json:"vert_rate"`
+	SpeedType  string  `json:"speed_type"`
+	RateSource string  `json:"rate_source"`
 }
 
 // PositionInput is a struct that represents the necessary information to calculate the airborne position, including the
@@ -131,9 +131,7 @@ func Category(msg string) (int64, error) {
 		return 0, err
 	}
 
-	bin := msgBin[32:87]
-
-	return strconv.ParseInt(bin[5:8], 2, 32)
+	return strconv.ParseInt(FieldCategory.bits(msgBin), 2, 32)
 }
 
 // Callsign is a function that decodes the callsign value in an ADS-B message.
@@ -147,14 +145,16 @@ func Category(msg string) (int64, error) {
 func Callsign(msg string) (string, error) {
 	lookup := "#ABCDEFGHIJKLMNOPQRSTUVWXYZ##### ###############0123456789######"
 
-	bin, err := hexToBinary(msg[8:22])
+	msgBin, err := hexToBinary(msg)
 	if err != nil {
 		println(err)
 	}
 
+	bin := FieldCallsign.bits(msgBin)
+
 	var callsign strings.Builder
 
-	for i := 8; i < len(bin); i += 6 {
+	for i := 0; i < len(bin); i += 6 {
 		output, err := strconv.ParseInt(bin[i:i+6], 2, 32)
 		if err != nil {
 			println(err)
@@ -186,12 +186,9 @@ func AirbornePosition(input PositionInput) (Position, error) {
 		return Position{}, err
 	}
 
-	mb0 := bin0[32:]
-	mb1 := bin1[32:]
-
 	// check if the user mixed up odd/even messages
-	oddEven0, _ := strconv.ParseInt(mb0[21:22], 2, 32)
-	oddEven1, _ := strconv.ParseInt(mb1[21:22], 2, 32)
+	oddEven0, _ := strconv.ParseInt(FieldCprFormat.bits(bin0), 2, 32)
+	oddEven1, _ := strconv.ParseInt(FieldCprFormat.bits(bin1), 2, 32)
 	if oddEven0 == 0 && oddEven1 == 1 {
 
 	} else if oddEven0 == 1 && oddEven1 == 0 {
@@ -201,25 +198,25 @@ func AirbornePosition(input PositionInput) (Position, error) {
 		return Position{}, errors.New("both an even + odd message are required")
 	}
 
-	cprLatEven, err := strconv.ParseInt(mb0[22:39], 2, 64)
+	cprLatEven, err := strconv.ParseInt(FieldCprLat.bits(bin0), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	latCprE := float64(cprLatEven) / 131072
 
-	cprLonEven, err := strconv.ParseInt(mb0[39:56], 2, 64)
+	cprLonEven, err := strconv.ParseInt(FieldCprLon.bits(bin0), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	lonCprE := float64(cprLonEven) / 131072
 
-	cprLatOdd, err := strconv.ParseInt(mb1[22:39], 2, 64)
+	cprLatOdd, err := strconv.ParseInt(FieldCprLat.bits(bin1), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	latCprO := float64(cprLatOdd) / 131072
 
-	cprLonOdd, err := strconv.ParseInt(mb1[39:56], 2, 64)
+	cprLonOdd, err := strconv.ParseInt(FieldCprLon.bits(bin1), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
@@ -303,26 +300,24 @@ func AirbornePositionWithRef(msg string, latRef float64, lonRef float64) (Positi
 		return Position{}, err
 	}
 
-	bin := msgBin[32:]
-
-	cprLatInt, err := strconv.ParseInt(bin[22:39], 2, 64)
+	cprLatInt, err := strconv.ParseInt(FieldCprLat.bits(msgBin), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	cprLat := float64(cprLatInt) / 131072
 
-	cprLonInt, err := strconv.ParseInt(bin[39:56], 2, 64)
+	cprLonInt, err := strconv.ParseInt(FieldCprLon.bits(msgBin), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	cprLon := float64(cprLonInt) / 131072
 
-	i, _ := strconv.Atoi(bin[21:22])
+	i, _ := strconv.Atoi(FieldCprFormat.bits(msgBin))
 	var dLat float64
 	if i != 0 {
-		dLat = 90.0 / 59.0
+		dLat = 360.0 / 59.0
 	} else {
-		dLat = 90.0 / 60.0
+		dLat = 360.0 / 60.0
 	}
 
 	j := math.Floor(latRef/dLat) + math.Floor(0.5+((math.Mod(latRef, dLat)/dLat)-cprLat))
@@ -333,9 +328,9 @@ func AirbornePositionWithRef(msg string, latRef float64, lonRef float64) (Positi
 
 	var dLon float64
 	if ni > 0 {
-		dLon = 90.0 / ni
+		dLon = 360.0 / ni
 	} else {
-		dLon = 90.0
+		dLon = 360.0
 	}
 
 	m := math.Floor(lonRef/dLon) + math.Floor(0.5+((math.Mod(lonRef, dLon)/dLon)-cprLon))
@@ -371,25 +366,25 @@ func SurfacePosition(input PositionInput) (Position, error) {
 		return Position{}, err
 	}
 
-	cprLatEven, err := strconv.ParseInt(bin0[54:71], 2, 64)
+	cprLatEven, err := strconv.ParseInt(FieldCprLat.bits(bin0), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	latCprE := float64(cprLatEven) / 131072
 
-	cprLonEven, err := strconv.ParseInt(bin0[71:88], 2, 64)
+	cprLonEven, err := strconv.ParseInt(FieldCprLon.bits(bin0), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	lonCprE := float64(cprLonEven) / 131072
 
-	cprLatOdd, err := strconv.ParseInt(bin1[54:71], 2, 64)
+	cprLatOdd, err := strconv.ParseInt(FieldCprLat.bits(bin1), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	latCprO := float64(cprLatOdd) / 131072
 
-	cprLonOdd, err := strconv.ParseInt(bin1[71:88], 2, 64)
+	cprLonOdd, err := strconv.ParseInt(FieldCprLon.bits(bin1), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
@@ -483,21 +478,19 @@ func SurfacePositionWithRef(msg string, latRef float64, lonRef float64) (Positio
 		return Position{}, err
 	}
 
-	bin := msgBin[32:]
-
-	cprLatInt, err := strconv.ParseInt(bin[22:39], 2, 64)
+	cprLatInt, err := strconv.ParseInt(FieldCprLat.bits(msgBin), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	cprLat := float64(cprLatInt) / 131072
 
-	cprLonInt, err := strconv.ParseInt(bin[39:56], 2, 64)
+	cprLonInt, err := strconv.ParseInt(FieldCprLon.bits(msgBin), 2, 64)
 	if err != nil {
 		return Position{}, err
 	}
 	cprLon := float64(cprLonInt) / 131072
 
-	i, _ := strconv.Atoi(bin[21:22])
+	i, _ := strconv.Atoi(FieldCprFormat.bits(msgBin))
 	var dLat float64
 	if i != 0 {
 		dLat = 90.0 / 59.0
@@ -556,13 +549,11 @@ func SurfaceVelocity(msg string) (Velocity, error) {
 		return Velocity{}, err
 	}
 
-	bin := msgBin[32:]
-
 	// ground track
 	var trk float64
-	trkStatus, _ := strconv.Atoi(bin[12:13])
+	trkStatus, _ := strconv.Atoi(FieldTrackStatus.bits(msgBin))
 	if trkStatus == 1 {
-		tmp, err := strconv.ParseInt(bin[13:20], 2, 64)
+		tmp, err := strconv.ParseInt(FieldTrack.bits(msgBin), 2, 64)
 		if err != nil {
 			return Velocity{}, err
 		}
@@ -574,7 +565,7 @@ func SurfaceVelocity(msg string) (Velocity, error) {
 	}
 
 	// ground speed
-	mov, err := strconv.ParseInt(bin[5:12], 2, 64)
+	mov, err := strconv.ParseInt(FieldMovement.bits(msgBin), 2, 64)
 	if err != nil {
 		return Velocity{}, err
 	}
@@ -637,20 +628,18 @@ func AirborneVelocity(msg string) (Velocity, error) {
 		return Velocity{}, err
 	}
 
-	bin := msgBin[32:]
-
-	subtype, err := strconv.ParseInt(bin[5:8], 2, 64)
+	subtype, err := strconv.ParseInt(FieldVelSubtype.bits(msgBin), 2, 64)
 	if err != nil {
 		return Velocity{}, err
 	}
 
-	// check velocity components
-	ew, err := strconv.ParseInt(bin[14:24], 2, 64)
+	// check velocity components, for airspeed subtypes these hold the heading and airspeed
+	ew, err := strconv.ParseInt(FieldEWVel.bits(msgBin), 2, 64)
 	if err != nil {
 		return Velocity{}, err
 	}
 
-	ns, err := strconv.ParseInt(bin[25:35], 2, 64)
+	ns, err := strconv.ParseInt(FieldNSVel.bits(msgBin), 2, 64)
 	if err != nil {
 		return Velocity{}, err
 	}
@@ -666,8 +655,8 @@ func AirborneVelocity(msg string) (Velocity, error) {
 	var vs int32
 
 	if subtype == 1 || subtype == 2 {
-		ewBit, _ := strconv.Atoi(bin[13:14]) // direction EW
-		nsBit, _ := strconv.Atoi(bin[24:25]) // direction NS
+		ewBit, _ := strconv.Atoi(FieldEWSign.bits(msgBin)) // direction EW
+		nsBit, _ := strconv.Atoi(FieldNSSign.bits(msgBin)) // direction NS
 		if ewBit == 1 {
 			ewBit = -1
 		}
@@ -697,7 +686,7 @@ func AirborneVelocity(msg string) (Velocity, error) {
 		trk = roundFloat(trk, 2)
 		spdType = "GS"
 	} else {
-		status, _ := strconv.Atoi(bin[13:14])
+		status, _ := strconv.Atoi(FieldHeadingStatus.bits(msgBin))
 		if status == 0 {
 			trk = 0
 		} else {
@@ -716,7 +705,7 @@ func AirborneVelocity(msg string) (Velocity, error) {
 			spd = spd * 4
 		}
 
-		tBit, _ := strconv.Atoi(bin[24:25])
+		tBit, _ := strconv.Atoi(FieldAirspeedType.bits(msgBin))
 		if tBit == 0 {
 			spdType = "IAS"
 		} else {
@@ -724,7 +713,7 @@ func AirborneVelocity(msg string) (Velocity, error) {
 		}
 	}
 
-	srcBit, _ := strconv.Atoi(bin[35:36])
+	srcBit, _ := strconv.Atoi(FieldVrSource.bits(msgBin))
 	if srcBit == 0 {
 		vrSource = "GNSS"
 	} else {
@@ -732,12 +721,12 @@ func AirborneVelocity(msg string) (Velocity, error) {
 	}
 
 	var vrSign int64
-	vrSignBit, _ := strconv.Atoi(bin[36:37])
+	vrSignBit, _ := strconv.Atoi(FieldVrSign.bits(msgBin))
 	if vrSignBit == 1 {
 		vrSign = -1
 	}
 
-	vr, err := strconv.ParseInt(bin[37:46], 2, 64)
+	vr, err := strconv.ParseInt(FieldVr.bits(msgBin), 2, 64)
 	if err != nil {
 		return Velocity{}, err
 	}
@@ -818,11 +807,9 @@ func Altitude(msg string) (int, error) {
 		return 0, err
 	}
 
-	msgBin := bin[32:]
-
 	var alt int
 
-	altBin := msgBin[8:20]
+	altBin := FieldAlt.bits(bin)
 	if tc < 19 {
 		altCode := altBin[0:6] + "0" + altBin[6:]
 		alt, err = altitude(altCode)
@@ -847,6 +834,6 @@ func Altitude(msg string) (int, error) {
 //   - int: an integer that represents the calculated odd/even flag for the message. The value can be either 0 or 1.
 func OddEvenFlag(msg string) int {
 	bin, _ := hexToBinary(msg)
-	res, _ := strconv.Atoi(bin[53:54])
+	res, _ := strconv.Atoi(FieldCprFormat.bits(bin))
 	return res
 }
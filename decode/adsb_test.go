@@ -89,6 +89,31 @@ func TestSurfacePosition(t *testing.T) {
 	}
 }
 
+func TestAirbornePositionWithRef(t *testing.T) {
+	tests := []struct {
+		msg       string
+		wantedLat float64
+		wantedLon float64
+	}{
+		{"8D40621D58C382D690C8AC2863A7", 52.257202, 3.919373},
+		{"8D40621D58C386435CC412692AD6", 52.26578, 3.938913},
+	}
+
+	for _, test := range tests {
+		pos, err := AirbornePositionWithRef(test.msg, 52.0, 4.0)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if pos.Latitude != test.wantedLat {
+			t.Fatalf("Latitude incorrect, wanted %v got %v", test.wantedLat, pos.Latitude)
+		}
+		if pos.Longitude != test.wantedLon {
+			t.Fatalf("Longitude incorrect, wanted %v got %v", test.wantedLon, pos.Longitude)
+		}
+	}
+}
+
 func TestSurfacePositionWithRef(t *testing.T) {
 	msg0 := "8C4841753A9A153237AEF0F275BE"
 	latRef := 51.990
@@ -0,0 +1,78 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	syndromesOnce sync.Once
+	// syndromes maps the CRC remainder caused by a single flipped bit of a 112-bit message to that bit's position
+	syndromes map[int]int
+)
+
+// buildSyndromes computes the remainder of every single bit error. The CRC is linear, so the remainder of a damaged
+// message is the remainder of its error pattern. The downlink format bits are left out, flipping one of them would
+// turn another format into an extended squitter.
+func buildSyndromes() {
+	syndromes = make(map[int]int, 112)
+
+	for bit := 5; bit < 112; bit++ {
+		pattern := make([]byte, 14)
+		pattern[bit/8] = 0x80 >> uint(bit%8)
+
+		remainder, _ := crc(fmt.Sprintf("%X", pattern), false)
+		syndromes[remainder] = bit
+	}
+}
+
+// Correct is a function that repairs a DF17 or DF18 message with a single damaged bit, using its CRC.
+//
+// Parameters:
+//   - msg: 28 character hexadecimal string message.
+//
+// Returns:
+//   - string: the repaired message, or msg itself when its CRC was already correct.
+//   - int: the 0-based position of the bit that was flipped, -1 when nothing was changed.
+//   - error: an error when the message is not an extended squitter or is damaged in more than one bit.
+func Correct(msg string) (string, int, error) {
+	if len(msg) != 28 {
+		return msg, -1, errors.New("message should be exactly 28 characters long")
+	}
+
+	d, err := df(msg)
+	if err != nil {
+		return msg, -1, err
+	}
+	if d != 17 && d != 18 {
+		return msg, -1, fmt.Errorf("cannot correct DF%d, only extended squitters carry a plain CRC", d)
+	}
+
+	remainder, err := crc(msg, false)
+	if err != nil {
+		return msg, -1, err
+	}
+	if remainder == 0 {
+		return msg, -1, nil
+	}
+
+	syndromesOnce.Do(buildSyndromes)
+
+	bit, ok := syndromes[remainder]
+	if !ok {
+		return msg, -1, errors.New("message is damaged beyond repair")
+	}
+
+	// flip the bit in its hex digit
+	i := bit / 4
+	digit, err := strconv.ParseUint(msg[i:i+1], 16, 8)
+	if err != nil {
+		return msg, -1, err
+	}
+	digit ^= 0x8 >> uint(bit%4)
+
+	return strings.ToUpper(msg[:i] + strconv.FormatUint(digit, 16) + msg[i+1:]), bit, nil
+}
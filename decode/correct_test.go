@@ -0,0 +1,49 @@
+package decode
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// flipBit flips a single bit of a hexadecimal message.
+func flipBit(msg string, bit int) string {
+	i := bit / 4
+	digit, _ := strconv.ParseUint(msg[i:i+1], 16, 8)
+	return msg[:i] + fmt.Sprintf("%X", digit^(0x8>>uint(bit%4))) + msg[i+1:]
+}
+
+func TestCorrect(t *testing.T) {
+	msg := "8D4840D6202CC371C32CE0576098"
+
+	fixed, bit, err := Correct(msg)
+	if err != nil || fixed != msg || bit != -1 {
+		t.Fatalf("undamaged message changed, got %v %v %v", fixed, bit, err)
+	}
+
+	for _, b := range []int{5, 8, 40, 87, 88, 111} {
+		fixed, bit, err := Correct(flipBit(msg, b))
+		if err != nil {
+			t.Fatalf("bit %v: unexpected error %v", b, err)
+		}
+		if fixed != msg || bit != b {
+			t.Fatalf("bit %v: correction incorrect, wanted %v got %v at bit %v", b, msg, fixed, bit)
+		}
+	}
+}
+
+func TestCorrectFails(t *testing.T) {
+	tests := []string{
+		// two damaged bits
+		flipBit(flipBit("8D4840D6202CC371C32CE0576098", 20), 60),
+		// not an extended squitter
+		"A0001838CA3E51F0A8000047A36A",
+		"5D4840D6000000",
+	}
+
+	for _, msg := range tests {
+		if _, _, err := Correct(msg); err == nil {
+			t.Errorf("%v: expected error", msg)
+		}
+	}
+}
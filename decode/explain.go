@@ -0,0 +1,318 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Annotation is a struct that describes one field of a message, as produced by Explain.
+//
+// Fields:
+//   - Field: the field definition, including its bit range.
+//   - Bits: the raw bits of the field.
+//   - Value: the raw value of the field as an unsigned integer. Only meaningful for fields up to 63 bits long.
+//   - Meaning: a readable interpretation of the value.
+type Annotation struct {
+	Field   Field
+	Bits    string
+	Value   int64
+	Meaning string
+}
+
+var dfNames = map[int64]string{
+	0:  "Short air-air surveillance (ACAS)",
+	4:  "Surveillance, altitude reply",
+	5:  "Surveillance, identity reply",
+	11: "All-call reply",
+	16: "Long air-air surveillance (ACAS)",
+	17: "Extended squitter (ADS-B)",
+	18: "Extended squitter, non-transponder",
+	19: "Military extended squitter",
+	20: "Comm-B, altitude reply",
+	21: "Comm-B, identity reply",
+	24: "Comm-D (ELM)",
+}
+
+var caNames = map[int64]string{
+	0: "Level 1 transponder",
+	4: "Level 2+ transponder, on ground",
+	5: "Level 2+ transponder, airborne",
+	6: "Level 2+ transponder, on ground or airborne",
+	7: "Downlink request or flight status field applies",
+}
+
+var fsNames = map[int64]string{
+	0: "No alert, no SPI, airborne",
+	1: "No alert, no SPI, on ground",
+	2: "Alert, no SPI, airborne",
+	3: "Alert, no SPI, on ground",
+	4: "Alert, SPI, airborne or on ground",
+	5: "No alert, SPI, airborne or on ground",
+}
+
+var velSubtypeNames = map[int64]string{
+	1: "Ground speed, subsonic",
+	2: "Ground speed, supersonic",
+	3: "Airspeed, subsonic",
+	4: "Airspeed, supersonic",
+}
+
+// Explain is a function that breaks a message down into its fields, using the same field definitions and decoding
+// functions as the rest of the package.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//
+// Returns:
+//   - []Annotation: the fields of the message in transmission order.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func Explain(msg string) ([]Annotation, error) {
+	d, err := Decode(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	bin, err := hexToBinary(d.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Annotation
+	add := func(f Field, meaning string) {
+		if f.End > len(bin) {
+			return
+		}
+		a := Annotation{Field: f, Bits: f.bits(bin), Meaning: meaning}
+		if f.End-f.Start < 64 {
+			a.Value = f.value(bin)
+		}
+		out = append(out, a)
+	}
+
+	df := FieldDF.value(bin)
+	if name, ok := dfNames[df]; ok {
+		add(FieldDF, name)
+	} else {
+		add(FieldDF, "Unknown")
+	}
+
+	long := len(bin) == 112
+	parity := fmt.Sprintf("CRC remainder %06X", d.Crc)
+
+	switch df {
+	case 0, 16:
+		add(FieldVS, map[int64]string{0: "Airborne", 1: "On ground"}[FieldVS.value(bin)])
+		add(FieldCC, "")
+		add(FieldSL, "")
+		add(FieldRI, "")
+		add(FieldAC, altitudeMeaning(d.Altitude))
+		if long {
+			add(FieldMV, "")
+		}
+		add(apField(long), parity+", ICAO "+d.Icao)
+	case 4, 5, 20, 21:
+		add(FieldFS, fsNames[FieldFS.value(bin)])
+		add(FieldDR, "")
+		add(FieldUM, "")
+		if df == 4 || df == 20 {
+			add(FieldAC, altitudeMeaning(d.Altitude))
+		} else {
+			add(FieldID, "Squawk "+d.Squawk)
+		}
+		if long {
+			add(FieldMB, "")
+		}
+		add(apField(long), parity+", ICAO "+d.Icao)
+	case 11:
+		add(FieldCA, caNames[FieldCA.value(bin)])
+		add(FieldAA, d.Icao)
+		add(FieldPIShort, parity+piMeaning(d.Crc))
+	case 17, 18:
+		if df == 17 {
+			add(FieldCA, caNames[FieldCA.value(bin)])
+		} else {
+			add(FieldCF, "")
+		}
+		add(FieldAA, d.Icao)
+		if !long {
+			return nil, errors.New("extended squitter must be 28 characters long")
+		}
+		explainME(bin, d, add)
+		add(FieldPI, parity+piMeaning(d.Crc))
+	default:
+		if long {
+			add(Field{"DATA", "Message data", 5, 88}, "")
+			add(FieldAP, parity)
+		} else {
+			add(Field{"DATA", "Message data", 5, 32}, "")
+			add(FieldAPShort, parity)
+		}
+	}
+
+	return out, nil
+}
+
+// explainME annotates the subfields of the ADS-B message field.
+func explainME(bin string, d Decoded, add func(Field, string)) {
+	tc := FieldTC.value(bin)
+	add(FieldTC, typecodeMeaning(tc))
+
+	switch {
+	case tc >= 1 && tc <= 4:
+		add(FieldCategory, "")
+		add(FieldCallsign, d.Callsign)
+	case tc >= 5 && tc <= 8:
+		movement := ""
+		if d.Velocity != nil {
+			movement = fmt.Sprintf("%v kt", d.Velocity.Speed)
+		}
+		add(FieldMovement, movement)
+		add(FieldTrackStatus, map[int64]string{0: "Invalid", 1: "Valid"}[FieldTrackStatus.value(bin)])
+		track := ""
+		if d.Velocity != nil && FieldTrackStatus.value(bin) == 1 {
+			track = fmt.Sprintf("%v°", d.Velocity.Angle)
+		}
+		add(FieldTrack, track)
+		explainCpr(bin, add)
+	case tc >= 9 && tc <= 18 || tc >= 20 && tc <= 22:
+		add(FieldSS, "")
+		add(FieldSAF, "")
+		add(FieldAlt, altitudeMeaning(d.Altitude))
+		explainCpr(bin, add)
+	case tc == 19:
+		st := FieldVelSubtype.value(bin)
+		add(FieldVelSubtype, velSubtypeNames[st])
+		add(FieldIntentChange, "")
+		add(FieldIFR, "")
+		add(FieldNUC, "")
+		if st == 1 || st == 2 {
+			add(FieldEWSign, map[int64]string{0: "West to east", 1: "East to west"}[FieldEWSign.value(bin)])
+			add(FieldEWVel, componentMeaning(FieldEWVel.value(bin), st))
+			add(FieldNSSign, map[int64]string{0: "South to north", 1: "North to south"}[FieldNSSign.value(bin)])
+			ns := componentMeaning(FieldNSVel.value(bin), st)
+			if d.Velocity != nil {
+				// the ground speed and track follow from both components
+				ns += fmt.Sprintf(", ground speed %v kt on track %v°", d.Velocity.Speed, d.Velocity.Angle)
+			}
+			add(FieldNSVel, ns)
+		} else {
+			status := FieldHeadingStatus.value(bin)
+			add(FieldHeadingStatus, map[int64]string{0: "Not available", 1: "Available"}[status])
+			heading := "Not available"
+			if status == 1 {
+				heading = fmt.Sprintf("%.2f° magnetic", float64(FieldHeading.value(bin))/1024*360)
+			}
+			add(FieldHeading, heading)
+			speedType := map[int64]string{0: "IAS", 1: "TAS"}[FieldAirspeedType.value(bin)]
+			add(FieldAirspeedType, speedType)
+			add(FieldAirspeed, airspeedMeaning(FieldAirspeed.value(bin), st, speedType))
+		}
+		add(FieldVrSource, map[int64]string{0: "GNSS", 1: "Baro"}[FieldVrSource.value(bin)])
+		add(FieldVrSign, map[int64]string{0: "Up", 1: "Down"}[FieldVrSign.value(bin)])
+		add(FieldVr, signedMeaning(FieldVr.value(bin), FieldVrSign.value(bin), 64, "ft/min"))
+		add(FieldVelReserved, "")
+		add(FieldBaroDiffSign, map[int64]string{0: "GNSS above baro", 1: "GNSS below baro"}[FieldBaroDiffSign.value(bin)])
+		add(FieldBaroDiff, signedMeaning(FieldBaroDiff.value(bin), FieldBaroDiffSign.value(bin), 25, "ft"))
+	default:
+		add(Field{"DATA", "Message data", 37, 88}, "")
+	}
+}
+
+func explainCpr(bin string, add func(Field, string)) {
+	add(FieldTime, "")
+	add(FieldCprFormat, map[int64]string{0: "Even", 1: "Odd"}[FieldCprFormat.value(bin)])
+	add(FieldCprLat, fmt.Sprintf("%.6f of a zone", float64(FieldCprLat.value(bin))/131072))
+	add(FieldCprLon, fmt.Sprintf("%.6f of a zone", float64(FieldCprLon.value(bin))/131072))
+}
+
+// componentMeaning decodes a ground speed component, which is offset by one and multiplied by four when supersonic.
+func componentMeaning(v int64, subtype int64) string {
+	if v == 0 {
+		return "Not available"
+	}
+	if subtype == 2 {
+		return fmt.Sprintf("%d kt", (v-1)*4)
+	}
+	return fmt.Sprintf("%d kt", v-1)
+}
+
+// airspeedMeaning decodes an airspeed, which is offset by one and multiplied by four when supersonic.
+func airspeedMeaning(v int64, subtype int64, speedType string) string {
+	if v == 0 {
+		return "Not available"
+	}
+	if subtype == 4 {
+		return fmt.Sprintf("%d kt %s", (v-1)*4, speedType)
+	}
+	return fmt.Sprintf("%d kt %s", v-1, speedType)
+}
+
+// signedMeaning decodes a value that is offset by one, counts steps of the given size and is negative when its sign bit
+// is set, such as the vertical rate and the GNSS/baro difference.
+func signedMeaning(v int64, sign int64, step int64, unit string) string {
+	if v == 0 {
+		return "Not available"
+	}
+	if sign == 1 {
+		return fmt.Sprintf("%d %s", -(v-1)*step, unit)
+	}
+	return fmt.Sprintf("%d %s", (v-1)*step, unit)
+}
+
+func apField(long bool) Field {
+	if long {
+		return FieldAP
+	}
+	return FieldAPShort
+}
+
+func piMeaning(crc int) string {
+	if crc == 0 {
+		return " (valid)"
+	}
+	return " (corrupt)"
+}
+
+func altitudeMeaning(alt *int) string {
+	if alt == nil || *alt == 0 {
+		return "Unknown"
+	}
+	return fmt.Sprintf("%d ft", *alt)
+}
+
+func typecodeMeaning(tc int64) string {
+	switch {
+	case tc >= 1 && tc <= 4:
+		return "Aircraft identification"
+	case tc >= 5 && tc <= 8:
+		return "Surface position"
+	case tc >= 9 && tc <= 18:
+		return "Airborne position (baro altitude)"
+	case tc == 19:
+		return "Airborne velocity"
+	case tc >= 20 && tc <= 22:
+		return "Airborne position (GNSS height)"
+	case tc == 28:
+		return "Aircraft status"
+	case tc == 29:
+		return "Target state and status"
+	case tc == 31:
+		return "Operational status"
+	default:
+		return "Reserved"
+	}
+}
+
+// FormatBits is a function that formats a field's bit range the way The 1090MHz Riddle does, counting from 1.
+//
+// Parameters:
+//   - f: the field.
+//
+// Returns:
+//   - string: the bit range, e.g. "1-5", or a single bit number.
+func FormatBits(f Field) string {
+	if f.End-f.Start == 1 {
+		return fmt.Sprint(f.Start + 1)
+	}
+	return fmt.Sprintf("%d-%d", f.Start+1, f.End)
+}
@@ -0,0 +1,100 @@
+package decode
+
+import (
+	"testing"
+)
+
+func TestExplainIdentification(t *testing.T) {
+	annotations, err := Explain("8D4840D6202CC371C32CE0576098")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	want := []struct {
+		name    string
+		bits    string
+		meaning string
+	}{
+		{"DF", "1-5", "Extended squitter (ADS-B)"},
+		{"CA", "6-8", "Level 2+ transponder, airborne"},
+		{"AA", "9-32", "4840D6"},
+		{"TC", "33-37", "Aircraft identification"},
+		{"CAT", "38-40", ""},
+		{"CS", "41-88", "KLM1023"},
+		{"PI", "89-112", "CRC remainder 000000 (valid)"},
+	}
+
+	if len(annotations) != len(want) {
+		t.Fatalf("Annotation count incorrect, wanted %v got %v", len(want), len(annotations))
+	}
+
+	for i, w := range want {
+		a := annotations[i]
+		if a.Field.Name != w.name || FormatBits(a.Field) != w.bits || a.Meaning != w.meaning {
+			t.Errorf("Annotation %d incorrect, wanted %v %v %q got %v %v %q", i, w.name, w.bits, w.meaning,
+				a.Field.Name, FormatBits(a.Field), a.Meaning)
+		}
+	}
+}
+
+func TestExplainVelocity(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want map[string]string
+	}{
+		{"8D485020994409940838175B284F", map[string]string{
+			"V-EW": "8 kt",
+			"V-NS": "159 kt, ground speed 159 kt on track 182.88°",
+			"VR":   "-832 ft/min",
+			"DIF":  "550 ft",
+		}},
+		{"8DA05F219B06B6AF189400CBC33F", map[string]string{
+			"HDG":   "243.98° magnetic",
+			"T":     "TAS",
+			"AS":    "375 kt TAS",
+			"VR":    "-2304 ft/min",
+			"S-DIF": "GNSS above baro",
+			"DIF":   "Not available",
+		}},
+	}
+
+	for _, test := range tests {
+		annotations, err := Explain(test.msg)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		got := make(map[string]string)
+		for _, a := range annotations {
+			got[a.Field.Name] = a.Meaning
+		}
+		for name, want := range test.want {
+			if got[name] != want {
+				t.Errorf("%v: %v meaning incorrect, wanted %q got %q", test.msg, name, want, got[name])
+			}
+		}
+	}
+}
+
+func TestExplainCoversMessage(t *testing.T) {
+	for _, msg := range []string{"8D40058B58C901375147EFD09357", "8C4841753A9A153237AEF0F275BE", "8D485020994409940838175B284F", "A02014B400000000000000F9D514", "2A00516D492B80"} {
+		t.Run(msg, func(t *testing.T) {
+			annotations, err := Explain(msg)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			// fields must be in order and end at the last bit
+			end := 0
+			for _, a := range annotations {
+				if a.Field.Start < end {
+					t.Fatalf("field %v overlaps the previous one", a.Field.Name)
+				}
+				end = a.Field.End
+			}
+			if end != len(msg)*4 {
+				t.Fatalf("Last bit incorrect, wanted %v got %v", len(msg)*4, end)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package decode
+
+import (
+	"strconv"
+)
+
+// Field is a struct that describes a named range of bits within a message, as laid out in The 1090MHz Riddle.
+//
+// Fields:
+//   - Name: the short name of the field, e.g. "DF".
+//   - Description: a readable description of the field.
+//   - Start: the index of the first bit, counting from 0.
+//   - End: the index one past the last bit.
+type Field struct {
+	Name        string
+	Description string
+	Start       int
+	End         int
+}
+
+// Message header and parity fields.
+var (
+	FieldDF      = Field{"DF", "Downlink format", 0, 5}
+	FieldCA      = Field{"CA", "Capability", 5, 8}
+	FieldCF      = Field{"CF", "Control field", 5, 8}
+	FieldFS      = Field{"FS", "Flight status", 5, 8}
+	FieldVS      = Field{"VS", "Vertical status", 5, 6}
+	FieldCC      = Field{"CC", "Cross-link capability", 6, 7}
+	FieldSL      = Field{"SL", "Sensitivity level", 8, 11}
+	FieldRI      = Field{"RI", "Reply information", 13, 17}
+	FieldDR      = Field{"DR", "Downlink request", 8, 13}
+	FieldUM      = Field{"UM", "Utility message", 13, 19}
+	FieldAC      = Field{"AC", "Altitude code", 19, 32}
+	FieldID      = Field{"ID", "Identity (squawk)", 19, 32}
+	FieldAA      = Field{"AA", "Address announced (ICAO)", 8, 32}
+	FieldME      = Field{"ME", "Extended squitter message", 32, 88}
+	FieldMB      = Field{"MB", "Comm-B message", 32, 88}
+	FieldMV      = Field{"MV", "ACAS message", 32, 88}
+	FieldPI      = Field{"PI", "Parity/interrogator ID", 88, 112}
+	FieldPIShort = Field{"PI", "Parity/interrogator ID", 32, 56}
+	FieldAP      = Field{"AP", "Address/parity", 88, 112}
+	FieldAPShort = Field{"AP", "Address/parity", 32, 56}
+)
+
+// ADS-B message (ME) fields.
+var (
+	FieldTC = Field{"TC", "Type code", 32, 37}
+
+	// identification, TC 1-4
+	FieldCategory = Field{"CAT", "Aircraft category", 37, 40}
+	FieldCallsign = Field{"CS", "Callsign", 40, 88}
+
+	// airborne position, TC 9-18 and 20-22
+	FieldSS  = Field{"SS", "Surveillance status", 37, 39}
+	FieldSAF = Field{"SAF", "Single antenna flag", 39, 40}
+	FieldAlt = Field{"ALT", "Altitude", 40, 52}
+
+	// surface position, TC 5-8
+	FieldMovement    = Field{"MOV", "Movement", 37, 44}
+	FieldTrackStatus = Field{"S", "Ground track status", 44, 45}
+	FieldTrack       = Field{"TRK", "Ground track", 45, 52}
+
+	// both position types
+	FieldTime      = Field{"T", "UTC time sync", 52, 53}
+	FieldCprFormat = Field{"F", "CPR format", 53, 54}
+	FieldCprLat    = Field{"LAT-CPR", "Encoded latitude", 54, 71}
+	FieldCprLon    = Field{"LON-CPR", "Encoded longitude", 71, 88}
+
+	// airborne velocity, TC 19
+	FieldVelSubtype    = Field{"ST", "Subtype", 37, 40}
+	FieldIntentChange  = Field{"IC", "Intent change flag", 40, 41}
+	FieldIFR           = Field{"IFR", "IFR capability flag", 41, 42}
+	FieldNUC           = Field{"NUC", "Velocity uncertainty", 42, 45}
+	FieldEWSign        = Field{"S-EW", "East-west direction", 45, 46}
+	FieldEWVel         = Field{"V-EW", "East-west velocity", 46, 56}
+	FieldNSSign        = Field{"S-NS", "North-south direction", 56, 57}
+	FieldNSVel         = Field{"V-NS", "North-south velocity", 57, 67}
+	FieldHeadingStatus = Field{"SH", "Heading status", 45, 46}
+	FieldHeading       = Field{"HDG", "Heading", 46, 56}
+	FieldAirspeedType  = Field{"T", "Airspeed type", 56, 57}
+	FieldAirspeed      = Field{"AS", "Airspeed", 57, 67}
+	FieldVrSource      = Field{"VR-SRC", "Vertical rate source", 67, 68}
+	FieldVrSign        = Field{"S-VR", "Vertical rate sign", 68, 69}
+	FieldVr            = Field{"VR", "Vertical rate", 69, 78}
+	FieldVelReserved   = Field{"RESV", "Reserved", 78, 80}
+	FieldBaroDiffSign  = Field{"S-DIF", "GNSS/baro difference sign", 80, 81}
+	FieldBaroDiff      = Field{"DIF", "GNSS/baro altitude difference", 81, 88}
+)
+
+// bits returns the field's bits from a binary string message.
+func (f Field) bits(bin string) string {
+	return bin[f.Start:f.End]
+}
+
+// value returns the field's bits from a binary string message as an unsigned integer.
+func (f Field) value(bin string) int64 {
+	v, _ := strconv.ParseInt(f.bits(bin), 2, 64)
+	return v
+}
@@ -0,0 +1,207 @@
+package decode
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Decoded is a struct that holds every field that could be decoded from a single message. Fields that do not apply to
+// the message type are left empty.
+//
+// Fields:
+//   - Message: the hexadecimal message that was decoded.
+//   - Df: the Downlink Format.
+//   - Icao: the ICAO address, recovered from the parity field for surveillance replies.
+//   - Crc: the CRC remainder, 0 for an undamaged DF11, DF17 or DF18 message.
+//   - Typecode: the ADS-B typecode.
+//   - Category: the aircraft category of an identification message.
+//   - Callsign: the callsign of an identification message.
+//   - Squawk: the identity code of a surveillance identity reply.
+//   - Altitude: the altitude in feet.
+//   - OddEven: the CPR format of a position message, 0 for even and 1 for odd.
+//   - CprLat: the raw 17 bit encoded CPR latitude of a position message.
+//   - CprLon: the raw 17 bit encoded CPR longitude of a position message.
+//   - Position: the position, only decoded when a reference position is given.
+//   - Velocity: the velocity of a velocity or surface position message.
+type Decoded struct {
+	Message  string    `json:"message"`
+	Df       int       `json:"df"`
+	Icao     string    `json:"icao,omitempty"`
+	Crc      int       `json:"crc"`
+	Typecode *int64    `json:"typecode,omitempty"`
+	Category *int64    `json:"category,omitempty"`
+	Callsign string    `json:"callsign,omitempty"`
+	Squawk   string    `json:"squawk,omitempty"`
+	Altitude *int      `json:"altitude,omitempty"`
+	OddEven  *int      `json:"odd_even,omitempty"`
+	CprLat   *int64    `json:"cpr_lat,omitempty"`
+	CprLon   *int64    `json:"cpr_lon,omitempty"`
+	Position *Position `json:"position,omitempty"`
+	Velocity *Velocity `json:"velocity,omitempty"`
+}
+
+// Crc is a function that calculates the CRC remainder of a message.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//
+// Returns:
+//   - int: the 24 bit remainder, 0 for an undamaged DF11 (with interrogator code 0), DF17 or DF18 message. For other
+//     formats the remainder is the ICAO address, possibly combined with an interrogator code.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func Crc(msg string) (int, error) {
+	return crc(msg, false)
+}
+
+// crcTable holds the CRC remainder of every byte value, for CrcBytes.
+var crcTable = func() [256]uint32 {
+	const generator = 0xFFF409
+
+	var table [256]uint32
+	for i := range table {
+		c := uint32(i) << 16
+		for j := 0; j < 8; j++ {
+			if c&0x800000 != 0 {
+				c = c<<1 ^ generator
+			} else {
+				c <<= 1
+			}
+		}
+		table[i] = c & 0xFFFFFF
+	}
+	return table
+}()
+
+// CrcBytes is a function that calculates the CRC remainder of a binary message, like Crc but without the hexadecimal
+// round trip, for use on hot paths such as demodulation.
+//
+// Parameters:
+//   - msg: 7 or 14 byte message.
+//
+// Returns:
+//   - int: the 24 bit remainder, see Crc.
+func CrcBytes(msg []byte) int {
+	if len(msg) < 3 {
+		return 0
+	}
+
+	var c uint32
+	for _, b := range msg[:len(msg)-3] {
+		c = (c<<8 ^ crcTable[byte(c>>16)^b]) & 0xFFFFFF
+	}
+
+	parity := uint32(msg[len(msg)-3])<<16 | uint32(msg[len(msg)-2])<<8 | uint32(msg[len(msg)-1])
+	return int(c ^ parity)
+}
+
+// Decode is a function that decodes every field of a message that does not need a reference position.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//
+// Returns:
+//   - Decoded: a struct that contains all fields decoded from the message.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func Decode(msg string) (Decoded, error) {
+	return decodeMessage(msg, nil, nil)
+}
+
+// DecodeWithRef is a function that decodes every field of a message, decoding positions relative to a reference
+// position.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//   - latRef: a float64 that represents the latitude reference point used to calculate the position.
+//   - lonRef: a float64 that represents the longitude reference point used to calculate the position.
+//
+// Returns:
+//   - Decoded: a struct that contains all fields decoded from the message.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func DecodeWithRef(msg string, latRef float64, lonRef float64) (Decoded, error) {
+	return decodeMessage(msg, &latRef, &lonRef)
+}
+
+func decodeMessage(msg string, latRef *float64, lonRef *float64) (Decoded, error) {
+	msg = strings.ToUpper(strings.TrimSpace(msg))
+	if len(msg) != 14 && len(msg) != 28 {
+		return Decoded{}, errors.New("message should be exactly 14 or 28 characters long")
+	}
+	for _, c := range msg {
+		if !strings.ContainsRune("0123456789ABCDEF", c) {
+			return Decoded{}, errors.New("message is not hexadecimal")
+		}
+	}
+
+	df, err := Df(msg)
+	if err != nil {
+		return Decoded{}, err
+	}
+
+	d := Decoded{Message: msg, Df: df}
+	d.Icao, _ = Icao(msg)
+	d.Crc, _ = Crc(msg)
+
+	switch df {
+	case 0, 4, 16, 20:
+		if alt, err := AltitudeCode(msg); err == nil {
+			d.Altitude = &alt
+		}
+	case 5, 21:
+		if sq, err := Squawk(msg); err == nil {
+			d.Squawk = sq
+		}
+	case 17, 18:
+		decodeExtendedSquitter(msg, &d, latRef, lonRef)
+	}
+
+	return d, nil
+}
+
+func decodeExtendedSquitter(msg string, d *Decoded, latRef *float64, lonRef *float64) {
+	tc, err := Typecode(msg)
+	if err != nil {
+		return
+	}
+	d.Typecode = &tc
+
+	switch {
+	case tc >= 1 && tc <= 4:
+		if cat, err := Category(msg); err == nil {
+			d.Category = &cat
+		}
+		if cs, err := Callsign(msg); err == nil {
+			d.Callsign = strings.TrimRight(cs, " #")
+		}
+	case tc >= 5 && tc <= 18 || tc >= 20 && tc <= 22:
+		bin, _ := hexToBinary(msg)
+		oddEven := OddEvenFlag(msg)
+		cprLat, _ := strconv.ParseInt(FieldCprLat.bits(bin), 2, 64)
+		cprLon, _ := strconv.ParseInt(FieldCprLon.bits(bin), 2, 64)
+		d.OddEven, d.CprLat, d.CprLon = &oddEven, &cprLat, &cprLon
+
+		if tc <= 8 {
+			if vel, err := SurfaceVelocity(msg); err == nil {
+				d.Velocity = &vel
+			}
+		} else if alt, err := Altitude(msg); err == nil {
+			d.Altitude = &alt
+		}
+
+		if latRef != nil && lonRef != nil {
+			var pos Position
+			if tc <= 8 {
+				pos, err = SurfacePositionWithRef(msg, *latRef, *lonRef)
+			} else {
+				pos, err = AirbornePositionWithRef(msg, *latRef, *lonRef)
+			}
+			if err == nil {
+				d.Position = &pos
+			}
+		}
+	case tc == 19:
+		if vel, err := AirborneVelocity(msg); err == nil {
+			d.Velocity = &vel
+		}
+	}
+}
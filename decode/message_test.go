@@ -0,0 +1,84 @@
+package decode
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+var identificationTests = []struct {
+	msg string
+	df  int
+}{
+	{"8D4840D6202CC371C32CE0576098", 17},
+	// the same identification from a non-transponder device
+	{"904840D6202CC371C32CE02A6C6D", 18},
+}
+
+func TestDecodeIdentification(t *testing.T) {
+	for _, test := range identificationTests {
+		d, err := Decode(test.msg)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if d.Df != test.df || d.Icao != "4840D6" || d.Crc != 0 {
+			t.Fatalf("Header incorrect, got DF %v ICAO %v CRC %v", d.Df, d.Icao, d.Crc)
+		}
+		if d.Typecode == nil || *d.Typecode != 4 {
+			t.Fatalf("Typecode incorrect, wanted %v got %v", 4, d.Typecode)
+		}
+		if d.Callsign != "KLM1023" {
+			t.Fatalf("Callsign incorrect, wanted %v got %v", "KLM1023", d.Callsign)
+		}
+		if d.Position != nil || d.Velocity != nil || d.Altitude != nil {
+			t.Fatalf("unexpected fields decoded")
+		}
+	}
+}
+
+func TestDecodeWithRef(t *testing.T) {
+	d, err := DecodeWithRef("8C4841753A9A153237AEF0F275BE", 51.990, 4.375)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	wantedLat := 52.320561
+	wantedLon := 4.735735
+
+	if d.Position == nil || d.Position.Latitude != wantedLat || d.Position.Longitude != wantedLon {
+		t.Fatalf("Position incorrect, wanted %v,%v got %v", wantedLat, wantedLon, d.Position)
+	}
+	if d.Velocity == nil || d.Velocity.Speed != 17 {
+		t.Fatalf("Velocity incorrect, got %v", d.Velocity)
+	}
+}
+
+func TestDecodeSurveillance(t *testing.T) {
+	d, _ := Decode("A02014B400000000000000F9D514")
+	if d.Altitude == nil || *d.Altitude != 32300 {
+		t.Fatalf("Altitude incorrect, wanted %v got %v", 32300, d.Altitude)
+	}
+
+	d, _ = Decode("2A00516D492B80")
+	if d.Squawk != "0356" {
+		t.Fatalf("Squawk incorrect, wanted %v got %v", "0356", d.Squawk)
+	}
+
+	if _, err := Decode("8D4840D6202CC371C32CE05760"); err == nil {
+		t.Fatalf("expected error for wrong length")
+	}
+	if _, err := Decode("8D4840D6202CC371C32CE05760ZZ"); err == nil {
+		t.Fatalf("expected error for non hex message")
+	}
+}
+
+func TestCrcBytes(t *testing.T) {
+	for _, msg := range []string{"8D4840D6202CC371C32CE0576098", "8D4840D6A02CC371C32CE0576099", "5D4840D6000000", "A0001838CA3E51F0A8000047A36A"} {
+		b, _ := hex.DecodeString(msg)
+
+		want, _ := Crc(msg)
+		if got := CrcBytes(b); got != want {
+			t.Fatalf("%v: remainder incorrect, wanted %X got %X", msg, want, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package decode
+
+import (
+	"errors"
+	"strconv"
+)
+
+// ModeACSPI is the SPI pulse of a Mode A/C reply. Replies are laid out as by dump1090 and the Beast format: the four
+// octal digits A, B, C and D as hex digits, e.g. 0x7700 for squawk 7700, with the SPI pulse in bit 7.
+const ModeACSPI = 0x0080
+
+// ModeA is a function that decodes a Mode A/C reply as a Mode A identity. A Mode A/C reply does not tell which of the
+// two it is, that depends on the interrogation it answers.
+//
+// Parameters:
+//   - msg: 4 character hexadecimal string reply, see ModeACSPI.
+//
+// Returns:
+//   - string: a four digit octal string that represents the squawk code if successful.
+//   - bool: whether the SPI (ident) pulse was present.
+//   - error: an error that indicates whether an error occurred during the processing of the reply.
+func ModeA(msg string) (string, bool, error) {
+	code, err := modeACCode(msg)
+	if err != nil {
+		return "", false, err
+	}
+
+	squawk, err := idCode(modeACBits(code))
+	return squawk, code&ModeACSPI != 0, err
+}
+
+// ModeC is a function that decodes a Mode A/C reply as a Mode C (Gillham coded) pressure altitude.
+//
+// Parameters:
+//   - msg: 4 character hexadecimal string reply, see ModeACSPI.
+//
+// Returns:
+//   - int: an integer that represents the altitude in feet, in 100 ft steps.
+//   - error: an error if the reply is not a valid Gillham code, in which case it can only be a Mode A reply.
+func ModeC(msg string) (int, error) {
+	code, err := modeACCode(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	bits := modeACBits(code)
+	// D1 is only used above 62,700 ft and takes the place of the Q bit of a Mode S altitude code
+	if code&0x0001 != 0 {
+		return 0, errors.New("not a Mode C altitude")
+	}
+
+	alt, err := altitude(bits)
+	if err != nil {
+		return 0, err
+	}
+	if alt == 0 {
+		return 0, errors.New("not a Mode C altitude")
+	}
+
+	return alt, nil
+}
+
+func modeACCode(msg string) (int, error) {
+	if len(msg) != 4 {
+		return 0, errors.New("Mode A/C reply must be 4 characters long")
+	}
+
+	code, err := strconv.ParseUint(msg, 16, 16)
+	if err != nil {
+		return 0, errors.New("input must be a hexadecimal string")
+	}
+	// only the digits and SPI are used
+	if code&0x8808 != 0 {
+		return 0, errors.New("invalid Mode A/C reply")
+	}
+
+	return int(code), nil
+}
+
+// modeACBits returns the pulses of a reply in the order they are transmitted, C1 A1 C2 A2 C4 A4 X B1 D1 B2 D2 B4 D4,
+// which is also the layout of the identity and altitude codes of Mode S replies.
+func modeACBits(code int) string {
+	// bit positions of each pulse in the hex layout, in transmission order. X is never set.
+	positions := []int{4, 12, 5, 13, 6, 14, -1, 8, 0, 9, 1, 10, 2}
+
+	bits := make([]byte, len(positions))
+	for i, p := range positions {
+		bits[i] = '0'
+		if p >= 0 && code&(1<<uint(p)) != 0 {
+			bits[i] = '1'
+		}
+	}
+
+	return string(bits)
+}
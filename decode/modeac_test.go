@@ -0,0 +1,66 @@
+package decode
+
+import (
+	"testing"
+)
+
+var modeATests = []struct {
+	msg     string
+	want    string
+	wantSPI bool
+}{
+	{"7700", "7700", false},
+	{"1200", "1200", false},
+	{"03D6", "0356", true},
+	{"7777", "7777", false},
+}
+
+func TestModeA(t *testing.T) {
+	for _, test := range modeATests {
+		t.Run(test.msg, func(t *testing.T) {
+			squawk, spi, err := ModeA(test.msg)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if squawk != test.want {
+				t.Fatalf("Squawk incorrect, wanted %v got %v", test.want, squawk)
+			}
+			if spi != test.wantSPI {
+				t.Fatalf("SPI incorrect, wanted %v got %v", test.wantSPI, spi)
+			}
+		})
+	}
+}
+
+var modeCTests = []struct {
+	msg   string
+	want  int
+	valid bool
+}{
+	// B1 B2 give 500 ft step 4, C2 gives 100 ft step 3: 4*500 + 3*100 - 1300
+	{"0320", 1000, true},
+	{"6520", 10000, true},
+	{"0000", 0, false},
+	// C1 C2 C4 all set is not a valid 100 ft step
+	{"0370", 0, false},
+	// D1 is not used below 62,700 ft
+	{"0321", 0, false},
+	{"8320", 0, false},
+	{"032", 0, false},
+}
+
+func TestModeC(t *testing.T) {
+	for _, test := range modeCTests {
+		t.Run(test.msg, func(t *testing.T) {
+			alt, err := ModeC(test.msg)
+			if (err == nil) != test.valid {
+				t.Fatalf("error incorrect, wanted valid %v got %v", test.valid, err)
+			}
+
+			if alt != test.want {
+				t.Fatalf("Altitude incorrect, wanted %v got %v", test.want, alt)
+			}
+		})
+	}
+}
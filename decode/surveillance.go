@@ -0,0 +1,88 @@
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// AltitudeCode is a function that decodes the 13 bit altitude code of a surveillance or Comm-B altitude reply.
+// Usable with DF0, DF4, DF16, DF20 messages.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//
+// Returns:
+//   - int: an integer that represents the altitude in feet, 0 if unknown.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func AltitudeCode(msg string) (int, error) {
+	df, err := Df(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	if df != 0 && df != 4 && df != 16 && df != 20 {
+		return 0, errors.New("not an altitude reply, expecting DF0, DF4, DF16 or DF20")
+	}
+
+	bin, err := hexToBinary(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(bin) < 56 {
+		return 0, errors.New("message too short")
+	}
+
+	return altitude(FieldAC.bits(bin))
+}
+
+// Squawk is a function that decodes the 13 bit identity (Mode A) code of a surveillance or Comm-B identity reply.
+// Usable with DF5, DF21 messages.
+//
+// Parameters:
+//   - msg: 14 or 28 character hexadecimal string message.
+//
+// Returns:
+//   - string: a four digit octal string that represents the squawk code if successful.
+//   - error: an error that indicates whether an error occurred during the processing of the message.
+func Squawk(msg string) (string, error) {
+	df, err := Df(msg)
+	if err != nil {
+		return "", err
+	}
+
+	if df != 5 && df != 21 {
+		return "", errors.New("not an identity reply, expecting DF5 or DF21")
+	}
+
+	bin, err := hexToBinary(msg)
+	if err != nil {
+		return "", err
+	}
+
+	if len(bin) < 56 {
+		return "", errors.New("message too short")
+	}
+
+	return idCode(FieldID.bits(bin))
+}
+
+func idCode(binString string) (string, error) {
+	if len(binString) != 13 {
+		return "", errors.New("binary string must be 13 bits long")
+	}
+
+	bit := func(i int) int64 {
+		b, _ := strconv.ParseInt(binString[i:i+1], 2, 64)
+		return b
+	}
+
+	// bit order is C1 A1 C2 A2 C4 A4 X B1 D1 B2 D2 B4 D4
+	a := bit(5)<<2 | bit(3)<<1 | bit(1)
+	b := bit(11)<<2 | bit(9)<<1 | bit(7)
+	c := bit(4)<<2 | bit(2)<<1 | bit(0)
+	d := bit(12)<<2 | bit(10)<<1 | bit(8)
+
+	return fmt.Sprintf("%d%d%d%d", a, b, c, d), nil
+}
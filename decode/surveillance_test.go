@@ -0,0 +1,36 @@
+package decode
+
+import (
+	"testing"
+)
+
+func TestAltitudeCode(t *testing.T) {
+	msg := "A02014B400000000000000F9D514"
+
+	actual, _ := AltitudeCode(msg)
+
+	want := 32300
+
+	if actual != want {
+		t.Fatalf("Altitude incorrect, wanted %v got %v", want, actual)
+	}
+}
+
+var squawkTests = []struct {
+	msg  string
+	want string
+}{
+	{"2A00516D492B80", "0356"},
+	{"A800292DFFBBA9383FFCEB903D01", "1346"},
+}
+
+func TestSquawk(t *testing.T) {
+	for _, test := range squawkTests {
+		t.Run(test.msg, func(t *testing.T) {
+			actual, _ := Squawk(test.msg)
+			if actual != test.want {
+				t.Errorf("Squawk incorrect, wanted %v got %v", test.want, actual)
+			}
+		})
+	}
+}
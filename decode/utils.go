@@ -43,7 +43,7 @@ func df(msg string) (int, error) {
 		return 0, err
 	}
 
-	df, err := strconv.ParseInt(bin[0:5], 2, 32)
+	df, err := strconv.ParseInt(FieldDF.bits(bin), 2, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -78,16 +78,16 @@ func typecode(msg string) (int64, error) {
 		return 0, err
 	}
 
-	if df != 17 {
+	if df != 17 && df != 18 {
 		return 0, nil
 	}
 
-	bin, err := hexToBinary(msg[8:10])
+	bin, err := hexToBinary(msg[:10])
 	if err != nil {
 		return 0, nil
 	}
 
-	tc, err := strconv.ParseInt(bin[0:5], 2, 32)
+	tc, err := strconv.ParseInt(FieldTC.bits(bin), 2, 32)
 	if err != nil {
 		return 0, err
 	}
@@ -131,8 +131,8 @@ func roundFloat(val float64, precision uint) float64 {
 }
 
 func crc(msg string, encode bool) (int, error) {
-	if len(msg) != 28 {
-		return 0, errors.New("message should be exactly 28 characters long")
+	if len(msg) != 28 && len(msg) != 14 {
+		return 0, errors.New("message should be exactly 14 or 28 characters long")
 	}
 
 	G := []int{255, 250, 4, 128}
@@ -277,9 +277,26 @@ func contains(s *[]int, e *int) bool {
 	return false
 }
 
-// CleanMessage removes the '*' and ';' from a raw formatted message.
+// CleanMessage removes the '*' and ';' from a raw formatted message. The timestamp and signal level of the '@', '%' and
+// '<' AVR variants are removed as well, leaving only the hexadecimal message.
 func CleanMessage(dirtyMsg string) string {
-	charsToRemove := "*;"
+	charsToRemove := "*;\r\n"
+
+	dirtyMsg = strings.TrimSpace(dirtyMsg)
+	if len(dirtyMsg) > 0 {
+		switch dirtyMsg[0] {
+		case '@':
+			// 12 digit timestamp
+			if len(dirtyMsg) > 13 {
+				dirtyMsg = dirtyMsg[13:]
+			}
+		case '%', '<':
+			// 12 digit timestamp and 2 digit signal level
+			if len(dirtyMsg) > 15 {
+				dirtyMsg = dirtyMsg[15:]
+			}
+		}
+	}
 
 	var cleaned strings.Builder
 	for _, r := range dirtyMsg {
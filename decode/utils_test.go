@@ -67,6 +67,12 @@ func TestTypecode(t *testing.T) {
 	if actual != want {
 		t.Fatalf("Typecode incorrect, wanted %v got %v", want, actual)
 	}
+
+	// the same message sent as DF18
+	actual, _ = Typecode("904840D6202CC371C32CE02A6C6D")
+	if actual != want {
+		t.Fatalf("DF18 Typecode incorrect, wanted %v got %v", want, actual)
+	}
 }
 
 var crcTests = []struct {
@@ -94,6 +100,28 @@ func TestCrc(t *testing.T) {
 	}
 }
 
+var cleanTests = []struct {
+	msg  string
+	want string
+}{
+	{"*8D4840D6202CC371C32CE0576098;", "8D4840D6202CC371C32CE0576098"},
+	{"*8D4840D6202CC371C32CE0576098;\r\n", "8D4840D6202CC371C32CE0576098"},
+	{"@0000001234568D4840D6202CC371C32CE0576098;", "8D4840D6202CC371C32CE0576098"},
+	{"<000000123456A08D4840D6202CC371C32CE0576098;", "8D4840D6202CC371C32CE0576098"},
+	{"%000000123456A05D4840D6000000;", "5D4840D6000000"},
+}
+
+func TestCleanMessage(t *testing.T) {
+	for _, test := range cleanTests {
+		t.Run(test.msg, func(t *testing.T) {
+			actual := CleanMessage(test.msg)
+			if actual != test.want {
+				t.Errorf("Cleaned message incorrect, wanted %v got %v", test.want, actual)
+			}
+		})
+	}
+}
+
 func BenchmarkHexToBinary(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		hexToBinary("8D4840D6202CC371C32CE0576098")
@@ -0,0 +1,446 @@
+// Package demod turns raw IQ samples, as delivered by an RTL-SDR dongle tuned to 1090 MHz, into Mode S and Mode A/C
+// frames.
+//
+// Samples are interleaved unsigned 8-bit I and Q values centred on 127.5, at 2 or 2.4 MHz. Mode S replies are pulse
+// position modulated: an 8 µs preamble with pulses at 0, 1, 3.5 and 4.5 µs is followed by 56 or 112 bits of 1 µs,
+// each a 0.5 µs pulse in either the first half (1) or the second half (0) of the bit. Mode A/C replies are framed by two
+// pulses 20.3 µs apart, and returned as 4 character frames in the layout of decode.ModeA.
+package demod
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// Supported sample rates.
+const (
+	Rate2000 = 2000000
+	Rate2400 = 2400000
+)
+
+const (
+	// preambleUs is the length of the preamble, longUs the length of a complete 112 bit reply.
+	preambleUs = 8
+	longUs     = preambleUs + 112
+	// pulseRatio is how much stronger than the quiet parts of the preamble its pulses must be. Before the sub-sample
+	// phase is known, a pulse may be smeared over two samples, so candidates are first picked with coarseRatio.
+	pulseRatio  = 2
+	coarseRatio = 0.75
+	// minSNR is the minimum preamble power over the noise floor, 6 dB.
+	minSNR = 4
+	// icaoTTL is how long an address from a CRC checked reply is trusted for replies with address/parity.
+	icaoTTL = 60
+	// noiseAlpha is the weight of a new block in the running noise floor.
+	noiseAlpha = 0.1
+)
+
+// phases are the sub-sample offsets tried for every preamble, most likely first. The one whose bits pass the CRC wins.
+var phases = []float64{0, 0.2, -0.2, 0.4, -0.4}
+
+// magTable maps an I/Q byte pair to its magnitude, with full scale at 1.
+var magTable = func() []float32 {
+	t := make([]float32, 256*256)
+	for i := 0; i < 256; i++ {
+		for q := 0; q < 256; q++ {
+			di := (float64(i) - 127.5) / 128
+			dq := (float64(q) - 127.5) / 128
+			t[i<<8|q] = float32(math.Sqrt(di*di + dq*dq))
+		}
+	}
+	return t
+}()
+
+// Options configures a Demodulator.
+type Options struct {
+	// SampleRate is Rate2000 or Rate2400.
+	SampleRate int
+	// Start is the time of the first sample, used to stamp frames read from a recording. When zero, frames are
+	// stamped with the wall clock at the time they are demodulated.
+	Start time.Time
+}
+
+// Demodulator finds Mode S replies in a stream of IQ samples. It keeps state between calls, so a stream can be fed
+// in blocks of any size.
+type Demodulator struct {
+	opts Options
+	// spu is the number of samples per microsecond
+	spu float64
+
+	// mag holds the magnitudes not processed yet, starting at absolute sample offset
+	mag    []float32
+	offset uint64
+	// cum holds the running sums of mag, so windows of any length cost the same
+	cum []float64
+	// odd holds the I byte of an incomplete pair
+	odd    byte
+	hasOdd bool
+
+	// noise is the running mean noise power, 0 until the first block was seen
+	noise float64
+	// known maps addresses from CRC checked replies to the absolute sample they were last seen at
+	known map[uint32]uint64
+}
+
+// New returns a Demodulator for the options.
+func New(opts Options) (*Demodulator, error) {
+	if opts.SampleRate != Rate2000 && opts.SampleRate != Rate2400 {
+		return nil, fmt.Errorf("unsupported sample rate %d, use %d or %d", opts.SampleRate, Rate2000, Rate2400)
+	}
+
+	return &Demodulator{
+		opts:  opts,
+		spu:   float64(opts.SampleRate) / 1e6,
+		known: make(map[uint32]uint64),
+	}, nil
+}
+
+// NoiseFloor returns the estimated noise level in dBFS.
+func (d *Demodulator) NoiseFloor() float64 {
+	if d.noise == 0 {
+		return math.Inf(-1)
+	}
+	return roundFloat(10*math.Log10(d.noise), 1)
+}
+
+// Demodulate adds IQ samples and returns the replies found. Replies near the end of the block may only be returned by
+// the next call, once their last samples are known.
+func (d *Demodulator) Demodulate(iq []byte) []models.Frame {
+	d.appendSamples(iq)
+	return d.process(false)
+}
+
+// Flush returns the replies in the remaining samples, at the end of the stream.
+func (d *Demodulator) Flush() []models.Frame {
+	return d.process(true)
+}
+
+func (d *Demodulator) appendSamples(iq []byte) {
+	if d.hasOdd && len(iq) > 0 {
+		d.mag = append(d.mag, magTable[int(d.odd)<<8|int(iq[0])])
+		iq = iq[1:]
+		d.hasOdd = false
+	}
+
+	for i := 0; i+1 < len(iq); i += 2 {
+		d.mag = append(d.mag, magTable[int(iq[i])<<8|int(iq[i+1])])
+	}
+
+	if len(iq)%2 == 1 {
+		d.odd = iq[len(iq)-1]
+		d.hasOdd = true
+	}
+}
+
+// process scans every position that has room for a long reply after it, or every position when final.
+func (d *Demodulator) process(final bool) []models.Frame {
+	// samples needed from a preamble start to the end of a long reply, with room for the phase offsets
+	need := int(math.Ceil(longUs*d.spu)) + 2
+
+	limit := len(d.mag) - need
+	if final {
+		limit = len(d.mag)
+	}
+	if limit <= 1 {
+		return nil
+	}
+
+	d.cum = append(d.cum[:0], 0)
+	for _, m := range d.mag {
+		d.cum = append(d.cum, d.cum[len(d.cum)-1]+float64(m))
+	}
+
+	var frames []models.Frame
+	var noiseSum float64
+	var noiseCount int
+	// a reply found near the limit may extend past it, its samples must not be scanned again
+	consumed := limit
+
+	for i := 1; i < limit; i++ {
+		frame, end, ok := d.detect(i)
+		if !ok {
+			frame, end, ok = d.detectModeAC(i)
+		}
+		if ok {
+			frames = append(frames, frame)
+			if end > consumed {
+				consumed = end
+			}
+			i = end
+			continue
+		}
+
+		m := float64(d.mag[i])
+		noiseSum += m * m
+		noiseCount++
+	}
+
+	if noiseCount > 0 {
+		block := noiseSum / float64(noiseCount)
+		if d.noise == 0 {
+			d.noise = block
+		} else {
+			d.noise = (1-noiseAlpha)*d.noise + noiseAlpha*block
+		}
+	}
+
+	d.forget()
+
+	// keep the last scanned sample, scanning starts after the first sample
+	consumed--
+	if consumed > len(d.mag) {
+		consumed = len(d.mag)
+	}
+
+	d.mag = append(d.mag[:0], d.mag[consumed:]...)
+	d.offset += uint64(consumed)
+
+	return frames
+}
+
+// window returns the mean magnitude over length samples from start, weighting partly covered samples.
+func (d *Demodulator) window(start float64, length float64) float64 {
+	return (d.cumulative(start+length) - d.cumulative(start)) / length
+}
+
+// cumulative returns the sum of the magnitudes before sample position x, which may be fractional.
+func (d *Demodulator) cumulative(x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= float64(len(d.mag)) {
+		return d.cum[len(d.mag)]
+	}
+
+	k := int(x)
+	return d.cum[k] + (x-float64(k))*float64(d.mag[k])
+}
+
+// at returns the mean magnitude of the 0.5 µs starting us microseconds after start.
+func (d *Demodulator) at(start float64, us float64) float64 {
+	return d.window(start+us*d.spu, 0.5*d.spu)
+}
+
+// preamble reports whether the samples from start look like a Mode S preamble, with pulses at least ratio times
+// stronger than the quiet parts.
+func (d *Demodulator) preamble(start float64, ratio float64) bool {
+	p1, p2, p3, p4 := d.at(start, 0), d.at(start, 1), d.at(start, 3.5), d.at(start, 4.5)
+	pulse := math.Min(math.Min(p1, p2), math.Min(p3, p4))
+
+	// the quiet windows keep clear of the pulse edges, which are smeared over a neighbouring sample
+	quiet := math.Max(d.window(start+1.75*d.spu, 1.5*d.spu), d.window(start+5.25*d.spu, 2.5*d.spu))
+
+	if pulse <= ratio*quiet {
+		return false
+	}
+
+	level := (p1 + p2 + p3 + p4) / 4
+	return d.noise == 0 || level*level >= minSNR*d.noise
+}
+
+// detect tries to demodulate a reply whose preamble starts near sample i. It returns the frame and the last sample
+// it occupies.
+func (d *Demodulator) detect(i int) (models.Frame, int, bool) {
+	if !d.preamble(float64(i), coarseRatio) {
+		return models.Frame{}, 0, false
+	}
+
+	var repairable []byte
+	var repairPhase float64
+
+	for _, phase := range phases {
+		start := float64(i) + phase
+		if start < 0 || !d.preamble(start, pulseRatio) {
+			continue
+		}
+
+		msg, power, ok := d.slice(start)
+		if !ok {
+			continue
+		}
+
+		if d.valid(msg, i) {
+			return d.frame(msg, start, power), d.lastSample(start, len(msg)), true
+		}
+
+		df := msg[0] >> 3
+		if repairable == nil && len(msg) == 14 && (df == 17 || df == 18) {
+			repairable, repairPhase = msg, phase
+		}
+	}
+
+	if repairable != nil {
+		fixed, _, err := decode.Correct(strings.ToUpper(hex.EncodeToString(repairable)))
+		if err == nil {
+			msg, _ := hex.DecodeString(fixed)
+			start := float64(i) + repairPhase
+			_, power, _ := d.slice(start)
+			d.remember(msg, i)
+			return d.frame(msg, start, power), d.lastSample(start, len(msg)), true
+		}
+	}
+
+	return models.Frame{}, 0, false
+}
+
+// lastSample returns the sample a reply of msgBytes with a preamble at start ends in.
+func (d *Demodulator) lastSample(start float64, msgBytes int) int {
+	return int(math.Ceil(start + float64(preambleUs+msgBytes*8)*d.spu))
+}
+
+// overlap returns how much of sample k lies within [lo, hi), both in samples.
+func overlap(k int, lo float64, hi float64) float64 {
+	return math.Max(0, math.Min(float64(k+1), hi)-math.Max(float64(k), lo))
+}
+
+// amplitude estimates the pulse amplitude of a preamble at start, as the least squares fit of the four pulses to the
+// samples they cover.
+func (d *Demodulator) amplitude(start float64) float64 {
+	var num, den float64
+
+	for _, us := range []float64{0, 1, 3.5, 4.5} {
+		lo, hi := start+us*d.spu, start+(us+0.5)*d.spu
+		for k := int(math.Floor(lo)); float64(k) < hi && k < len(d.mag); k++ {
+			w := overlap(k, lo, hi)
+			num += float64(d.mag[k]) * w
+			den += w * w
+		}
+	}
+
+	return num / den
+}
+
+// slice reads the bits after a preamble at start. It returns the message and the power of its pulses, and false when
+// the downlink format has no known length or the samples run out.
+//
+// A pulse rarely lines up with the samples, so part of it spills into the neighbouring sample, where it overlaps the
+// other half of the bit or the next bit. Each bit is decided by correlating the samples with the difference between
+// the expected samples of a 1 and a 0 at this phase, after removing the spill of the previous pulse. The spill of the
+// next bit is not known yet and is taken as its average.
+func (d *Demodulator) slice(start float64) ([]byte, float64, bool) {
+	a := d.amplitude(start)
+	if a <= 0 {
+		return nil, 0, false
+	}
+
+	half := 0.5 * d.spu
+	// the previous pulse, far enough before the first bit not to matter initially
+	prevLo, prevHi := start, start
+
+	var msg [14]byte
+
+	nbits := 5
+	for n := 0; n < nbits; n++ {
+		lo := start + float64(preambleUs+n)*d.spu
+		mid, hi := lo+half, lo+2*half
+		if hi+half >= float64(len(d.mag)) {
+			return nil, 0, false
+		}
+
+		var stat, threshold float64
+		for k := int(math.Floor(lo)); float64(k) < hi; k++ {
+			w1, w0 := overlap(k, lo, mid), overlap(k, mid, hi)
+			diff := w1 - w0
+			if diff == 0 {
+				continue
+			}
+
+			stat += (float64(d.mag[k]) - a*overlap(k, prevLo, prevHi)) * diff
+			threshold += a * ((w1+w0)/2 + overlap(k, hi, hi+half)/2) * diff
+		}
+
+		if stat > threshold {
+			msg[n/8] |= 0x80 >> uint(n%8)
+			prevLo, prevHi = lo, mid
+		} else {
+			prevLo, prevHi = mid, hi
+		}
+
+		if n == 4 {
+			switch df := msg[0] >> 3; {
+			case df == 0 || df == 4 || df == 5 || df == 11:
+				nbits = 56
+			case df == 16 || df == 17 || df == 18 || df == 20 || df == 21:
+				nbits = 112
+			default:
+				return nil, 0, false
+			}
+		}
+	}
+
+	return msg[:nbits/8], a * a, true
+}
+
+// valid checks the CRC. Extended squitters and all call replies carry a plain CRC, other replies have it overlaid
+// with the address, which must then belong to an aircraft heard recently.
+func (d *Demodulator) valid(msg []byte, i int) bool {
+	rem := uint32(decode.CrcBytes(msg))
+
+	switch msg[0] >> 3 {
+	case 17, 18:
+		if rem != 0 {
+			return false
+		}
+	case 11:
+		// the interrogator code is overlaid on the lowest 7 bits
+		if rem&^0x7F != 0 {
+			return false
+		}
+	default:
+		seen, ok := d.known[rem]
+		return ok && d.offset+uint64(i)-seen < uint64(icaoTTL*d.opts.SampleRate)
+	}
+
+	d.remember(msg, i)
+	return true
+}
+
+func (d *Demodulator) remember(msg []byte, i int) {
+	icao := uint32(msg[1])<<16 | uint32(msg[2])<<8 | uint32(msg[3])
+	d.known[icao] = d.offset + uint64(i)
+}
+
+// forget drops addresses that were not heard for too long.
+func (d *Demodulator) forget() {
+	now := d.offset + uint64(len(d.mag))
+	ttl := uint64(icaoTTL * d.opts.SampleRate)
+
+	for icao, seen := range d.known {
+		if now-seen > ttl {
+			delete(d.known, icao)
+		}
+	}
+}
+
+// frame builds the frame for a message whose preamble starts at sample start.
+func (d *Demodulator) frame(msg []byte, start float64, power float64) models.Frame {
+	abs := float64(d.offset) + start
+
+	received := time.Now()
+	if !d.opts.Start.IsZero() {
+		received = d.opts.Start.Add(time.Duration(abs / float64(d.opts.SampleRate) * 1e9))
+	}
+
+	rssi := -50.0
+	if power > 0 {
+		rssi = math.Max(roundFloat(10*math.Log10(power), 1), -50)
+	}
+
+	return models.Frame{
+		Message: strings.ToUpper(hex.EncodeToString(msg)),
+		// the 12 MHz MLAT counter, derived from the sample position
+		Timestamp: uint64(abs*12e6/float64(d.opts.SampleRate)) & 0xFFFFFFFFFFFF,
+		RSSI:      rssi,
+		Received:  received,
+	}
+}
+
+func roundFloat(val float64, precision uint) float64 {
+	ratio := math.Pow(10, float64(precision))
+	return math.Round(val*ratio) / ratio
+}
@@ -0,0 +1,261 @@
+package demod
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// placed is a reply starting at a time in µs from the first sample.
+type placed struct {
+	msg string
+	at  float64
+}
+
+// synthesize returns IQ samples at the rate for replies with the given amplitude (full scale 1), with gaussian noise
+// of the given deviation and a random carrier phase per sample, as an RTL-SDR would see a slightly detuned signal.
+func synthesize(rate int, replies []placed, lengthUs float64, amp float64, noise float64, seed int64) []byte {
+	rng := rand.New(rand.NewSource(seed))
+	spu := float64(rate) / 1e6
+
+	// pulse intervals in µs
+	type pulse struct{ from, to float64 }
+	var pulses []pulse
+	for _, r := range replies {
+		if len(r.msg) == 4 {
+			// Mode A/C: F1, the code pulses, F2 and SPI
+			var code int
+			fmt.Sscanf(r.msg, "%X", &code)
+			starts := []float64{0, acF2Us}
+			for n, bit := range acSlots {
+				if bit >= 0 && code&(1<<uint(bit)) != 0 {
+					starts = append(starts, float64(n+1)*acSpacingUs)
+				}
+			}
+			if code&decode.ModeACSPI != 0 {
+				starts = append(starts, acSPIUs)
+			}
+			for _, p := range starts {
+				pulses = append(pulses, pulse{r.at + p, r.at + p + acPulseUs})
+			}
+			continue
+		}
+
+		for _, p := range []float64{0, 1, 3.5, 4.5} {
+			pulses = append(pulses, pulse{r.at + p, r.at + p + 0.5})
+		}
+		msg, _ := hex.DecodeString(r.msg)
+		for n := 0; n < len(msg)*8; n++ {
+			start := r.at + 8 + float64(n)
+			if msg[n/8]&(0x80>>uint(n%8)) == 0 {
+				start += 0.5
+			}
+			pulses = append(pulses, pulse{start, start + 0.5})
+		}
+	}
+
+	samples := int(lengthUs * spu)
+	out := make([]byte, 0, samples*2)
+	phase := rng.Float64() * 2 * math.Pi
+
+	for k := 0; k < samples; k++ {
+		// the part of the sample period covered by pulses
+		from, to := float64(k)/spu, float64(k+1)/spu
+		covered := 0.0
+		for _, p := range pulses {
+			lo, hi := math.Max(from, p.from), math.Min(to, p.to)
+			if hi > lo {
+				covered += hi - lo
+			}
+		}
+		a := amp * covered * spu
+
+		phase += 0.3
+		i := a*math.Cos(phase) + rng.NormFloat64()*noise
+		q := a*math.Sin(phase) + rng.NormFloat64()*noise
+
+		out = append(out, toByte(i), toByte(q))
+	}
+
+	return out
+}
+
+func toByte(v float64) byte {
+	s := math.Round(v*128 + 127.5)
+	return byte(math.Max(0, math.Min(255, s)))
+}
+
+// withParity completes a message whose parity is its CRC overlaid with the given value.
+func withParity(prefix string, overlay int) string {
+	data, _ := hex.DecodeString(prefix + "000000")
+	parity := decode.CrcBytes(data) ^ overlay
+	return fmt.Sprintf("%s%06X", prefix, parity)
+}
+
+func TestDemodulate(t *testing.T) {
+	es := "8D4840D6202CC371C32CE0576098"
+	allCall := withParity("5D4840D6", 0)
+	surveillance := withParity("20001838", 0x4840D6)
+	unknown := withParity("20001838", 0xABCDEF)
+
+	replies := []placed{
+		{es, 100.3},
+		{allCall, 400.77},
+		{surveillance, 700.1},
+		{unknown, 1000.45},
+		{es, 1300.6},
+	}
+	want := []placed{replies[0], replies[1], replies[2], replies[4]}
+
+	for _, rate := range []int{Rate2000, Rate2400} {
+		for seed := int64(1); seed <= 3; seed++ {
+			t.Run(fmt.Sprintf("%d/%d", rate, seed), func(t *testing.T) {
+				start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+				d, err := New(Options{SampleRate: rate, Start: start})
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				iq := synthesize(rate, replies, 1500, 0.5, 0.02, seed)
+
+				// odd block sizes split sample pairs and replies
+				frames := d.Demodulate(iq[:1001])
+				frames = append(frames, d.Demodulate(iq[1001:3333])...)
+				frames = append(frames, d.Demodulate(iq[3333:])...)
+				frames = append(frames, d.Flush()...)
+
+				if len(frames) != len(want) {
+					t.Fatalf("frame count incorrect, wanted %v got %v: %v", len(want), len(frames), frames)
+				}
+
+				for i, f := range frames {
+					if f.Message != want[i].msg {
+						t.Errorf("Message incorrect, wanted %v got %v", want[i].msg, f.Message)
+					}
+
+					wantTs := want[i].at * 12
+					if math.Abs(float64(f.Timestamp)-wantTs) > 6 {
+						t.Errorf("Timestamp incorrect, wanted %v got %v", wantTs, f.Timestamp)
+					}
+
+					wantReceived := start.Add(time.Duration(want[i].at * 1e3))
+					if diff := f.Received.Sub(wantReceived); diff > time.Microsecond || diff < -time.Microsecond {
+						t.Errorf("Received incorrect, wanted %v got %v", wantReceived, f.Received)
+					}
+
+					if f.RSSI > -4 || f.RSSI < -9 {
+						t.Errorf("RSSI incorrect, wanted about -6 got %v", f.RSSI)
+					}
+				}
+
+				// noise power is 2·0.02², about -31 dBFS
+				if nf := d.NoiseFloor(); nf > -27 || nf < -35 {
+					t.Errorf("NoiseFloor incorrect, wanted about -31 got %v", nf)
+				}
+			})
+		}
+	}
+}
+
+func TestDemodulateModeAC(t *testing.T) {
+	es := "8D4840D6202CC371C32CE0576098"
+	replies := []placed{
+		{es, 100},
+		{"7700", 1200.3},
+		{"03D6", 1600.7},
+		{es, 2000},
+		{"0320", 2500.45},
+	}
+
+	for _, rate := range []int{Rate2000, Rate2400} {
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			d, _ := New(Options{SampleRate: rate})
+			iq := synthesize(rate, replies, 3000, 0.5, 0.02, 1)
+
+			var frames []models.Frame
+			for len(iq) > 0 {
+				n := 4000
+				if n > len(iq) {
+					n = len(iq)
+				}
+				frames = append(frames, d.Demodulate(iq[:n])...)
+				iq = iq[n:]
+			}
+			frames = append(frames, d.Flush()...)
+
+			if len(frames) != len(replies) {
+				t.Fatalf("frame count incorrect, wanted %v got %v: %v", len(replies), len(frames), frames)
+			}
+			for i, f := range frames {
+				if f.Message != replies[i].msg {
+					t.Errorf("Message incorrect, wanted %v got %v", replies[i].msg, f.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDemodulateCorrectsBitError(t *testing.T) {
+	msg, _ := hex.DecodeString("8D4840D6202CC371C32CE0576098")
+	msg[6] ^= 0x10
+	damaged := fmt.Sprintf("%X", msg)
+
+	d, _ := New(Options{SampleRate: Rate2000})
+	frames := d.Demodulate(synthesize(Rate2000, []placed{{damaged, 50}}, 300, 0.5, 0.01, 1))
+	frames = append(frames, d.Flush()...)
+
+	if len(frames) != 1 {
+		t.Fatalf("frame count incorrect, wanted %v got %v", 1, len(frames))
+	}
+	if frames[0].Message != "8D4840D6202CC371C32CE0576098" {
+		t.Fatalf("Message incorrect, wanted %v got %v", "8D4840D6202CC371C32CE0576098", frames[0].Message)
+	}
+}
+
+func TestDemodulateNoise(t *testing.T) {
+	d, _ := New(Options{SampleRate: Rate2400})
+	frames := d.Demodulate(synthesize(Rate2400, nil, 100000, 0, 0.1, 1))
+	frames = append(frames, d.Flush()...)
+
+	if len(frames) != 0 {
+		t.Fatalf("frame count incorrect, wanted %v got %v: %v", 0, len(frames), frames)
+	}
+}
+
+func TestReader(t *testing.T) {
+	es := "8D4840D6202CC371C32CE0576098"
+	iq := synthesize(Rate2000, []placed{{es, 20}, {es, 200}}, 400, 0.5, 0.01, 1)
+
+	r, err := NewReader(bytes.NewReader(iq), Options{SampleRate: Rate2000})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		f, err := r.ReadFrame()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if f.Message != es {
+			t.Fatalf("Message incorrect, wanted %v got %v", es, f.Message)
+		}
+	}
+
+	if _, err := r.ReadFrame(); err != io.EOF {
+		t.Fatalf("wanted EOF after last frame, got %v", err)
+	}
+}
+
+func TestNewRejectsRate(t *testing.T) {
+	if _, err := New(Options{SampleRate: 1000000}); err == nil {
+		t.Fatalf("wanted error for unsupported sample rate")
+	}
+}
@@ -0,0 +1,111 @@
+package demod
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// Mode A/C reply timing in µs. A reply is framed by the pulses F1 and F2, with 13 code pulse slots in between and an
+// optional SPI pulse after F2.
+const (
+	acPulseUs   = 0.45
+	acSpacingUs = 1.45
+	acF2Us      = 20.3
+	acSPIUs     = 24.65
+	// acGapUs is the part of the gap between two pulse slots that is checked for silence, clear of smeared edges
+	acGapUs = 0.2
+)
+
+// acSlots maps each code pulse slot after F1 to its bit in the reply, see decode.ModeACSPI. The X slot is -1.
+var acSlots = []int{4, 12, 5, 13, 6, 14, -1, 8, 0, 9, 1, 10, 2}
+
+// detectModeAC tries to demodulate a Mode A/C reply whose F1 pulse starts near sample i. It returns the frame and the
+// last sample it occupies.
+//
+// Mode A/C replies have no parity, so they are only accepted once the noise floor is known, with framing pulses of
+// similar strength, silence between all pulse slots and before F1, and an empty X slot.
+func (d *Demodulator) detectModeAC(i int) (models.Frame, int, bool) {
+	if d.noise == 0 || float64(d.mag[i])*float64(d.mag[i]) < d.noise/2 {
+		return models.Frame{}, 0, false
+	}
+
+	for _, phase := range phases {
+		start := float64(i) + phase
+		if start < 0 {
+			continue
+		}
+
+		code, level, ok := d.modeAC(start)
+		if !ok {
+			continue
+		}
+
+		us := acF2Us + acPulseUs
+		if code&decode.ModeACSPI != 0 {
+			us = acSPIUs + acPulseUs
+		}
+
+		frame := d.frame(nil, start, level*level)
+		frame.Message = fmt.Sprintf("%04X", code)
+
+		return frame, int(math.Ceil(start + us*d.spu)), true
+	}
+
+	return models.Frame{}, 0, false
+}
+
+// modeAC reads the pulses of a Mode A/C reply with F1 at start. It returns the reply and the level of the framing
+// pulses.
+func (d *Demodulator) modeAC(start float64) (int, float64, bool) {
+	if start+(acSPIUs+acPulseUs)*d.spu >= float64(len(d.mag)) {
+		return 0, 0, false
+	}
+
+	pulse := func(us float64) float64 {
+		return d.window(start+us*d.spu, acPulseUs*d.spu)
+	}
+
+	f1, f2 := pulse(0), pulse(acF2Us)
+	if f1*f1 < minSNR*d.noise || f2*f2 < minSNR*d.noise || f1 > 2*f2 || f2 > 2*f1 {
+		return 0, 0, false
+	}
+
+	level := (f1 + f2) / 2
+	threshold := level / 2
+
+	// the reply must not be part of a longer transmission
+	if d.window(start-1.0*d.spu, 0.6*d.spu) > threshold {
+		return 0, 0, false
+	}
+
+	code := 0
+	for n := 0; n <= len(acSlots); n++ {
+		gap := float64(n)*acSpacingUs + acPulseUs + (acSpacingUs-acPulseUs-acGapUs)/2
+		if d.window(start+gap*d.spu, acGapUs*d.spu) > threshold {
+			return 0, 0, false
+		}
+
+		if n == len(acSlots) {
+			break
+		}
+
+		p := pulse(float64(n+1) * acSpacingUs)
+		if p <= threshold {
+			continue
+		}
+		// a much stronger pulse belongs to another transmission
+		if acSlots[n] < 0 || p > 2*level {
+			return 0, 0, false
+		}
+		code |= 1 << uint(acSlots[n])
+	}
+
+	if pulse(acSPIUs) > threshold {
+		code |= decode.ModeACSPI
+	}
+
+	return code, level, true
+}
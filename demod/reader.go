@@ -0,0 +1,55 @@
+package demod
+
+import (
+	"io"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// blockSize is the number of bytes read at a time, 128 ms of samples at 2 MHz.
+const blockSize = 512 * 1024
+
+// Reader demodulates an IQ sample stream into frames. It implements formats.FrameReader.
+type Reader struct {
+	r      io.Reader
+	d      *Demodulator
+	buf    []byte
+	frames []models.Frame
+	err    error
+}
+
+// NewReader returns a Reader that demodulates the samples read from r.
+func NewReader(r io.Reader, opts Options) (*Reader, error) {
+	d, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{r: r, d: d, buf: make([]byte, blockSize)}, nil
+}
+
+// ReadFrame returns the next frame. Once the stream ends the remaining frames are returned, then the read error.
+func (r *Reader) ReadFrame() (models.Frame, error) {
+	for len(r.frames) == 0 {
+		if r.err != nil {
+			return models.Frame{}, r.err
+		}
+
+		n, err := r.r.Read(r.buf)
+		r.frames = append(r.frames, r.d.Demodulate(r.buf[:n])...)
+		if err != nil {
+			r.err = err
+			r.frames = append(r.frames, r.d.Flush()...)
+		}
+	}
+
+	frame := r.frames[0]
+	r.frames = r.frames[1:]
+
+	return frame, nil
+}
+
+// NoiseFloor returns the estimated noise level in dBFS.
+func (r *Reader) NoiseFloor() float64 {
+	return r.d.NoiseFloor()
+}
@@ -0,0 +1,226 @@
+package formats
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+const (
+	beastEscape    = 0x1A
+	beastModeAC    = '1'
+	beastModeShort = '2'
+	beastModeLong  = '3'
+	beastStatus    = '4'
+)
+
+// BeastReader reads frames from a stream in the Beast binary format.
+//
+// Every frame starts with 0x1A followed by a type byte, a 6 byte MLAT timestamp, a 1 byte signal level and the
+// message itself. Any 0x1A byte inside the frame body is doubled on the wire.
+type BeastReader struct {
+	r *bufio.Reader
+	// synced is set when a frame start marker has already been consumed
+	synced bool
+}
+
+// NewBeastReader returns a BeastReader that reads from r.
+func NewBeastReader(r io.Reader) *BeastReader {
+	return &BeastReader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame returns the next Mode A/C, Mode S short or Mode S long frame from the stream. Status frames are skipped.
+// If the stream is out of sync, bytes are discarded until the next frame start.
+func (b *BeastReader) ReadFrame() (models.Frame, error) {
+	for {
+		if err := b.sync(); err != nil {
+			return models.Frame{}, err
+		}
+
+		t, err := b.r.ReadByte()
+		if err != nil {
+			return models.Frame{}, err
+		}
+
+		var msgLen int
+		switch t {
+		case beastModeAC:
+			msgLen = 2
+		case beastModeShort:
+			msgLen = 7
+		case beastModeLong:
+			msgLen = 14
+		case beastStatus:
+			msgLen = 14
+		case beastEscape:
+			// the previous marker was stray, this one starts the frame
+			b.synced = true
+			continue
+		default:
+			continue
+		}
+
+		body := make([]byte, 7+msgLen)
+		resync, err := b.readBody(body)
+		if err != nil {
+			return models.Frame{}, err
+		}
+		if resync || t == beastStatus {
+			continue
+		}
+
+		return beastFrame(body), nil
+	}
+}
+
+// sync discards bytes until a frame start marker has been consumed.
+func (b *BeastReader) sync() error {
+	if b.synced {
+		b.synced = false
+		return nil
+	}
+
+	for {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if c == beastEscape {
+			return nil
+		}
+	}
+}
+
+// readBody fills body while undoing the 0x1A escaping. It reports true if a new frame started part way through, in
+// which case the partial frame should be dropped and the new frame is picked up by the next read.
+func (b *BeastReader) readBody(body []byte) (bool, error) {
+	for i := range body {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return false, err
+		}
+
+		if c == beastEscape {
+			next, err := b.r.ReadByte()
+			if err != nil {
+				return false, err
+			}
+			if next != beastEscape {
+				// an unescaped marker means the frame was truncated
+				b.r.UnreadByte()
+				b.synced = true
+				return true, nil
+			}
+		}
+
+		body[i] = c
+	}
+
+	return false, nil
+}
+
+func beastFrame(body []byte) models.Frame {
+	var ts uint64
+	for _, c := range body[:6] {
+		ts = ts<<8 | uint64(c)
+	}
+
+	return models.Frame{
+		Message:   strings.ToUpper(hex.EncodeToString(body[7:])),
+		Timestamp: ts,
+		RSSI:      SignalToRSSI(body[6]),
+		Received:  time.Now(),
+	}
+}
+
+// BeastWriter writes frames in the Beast binary format.
+type BeastWriter struct {
+	w io.Writer
+}
+
+// NewBeastWriter returns a BeastWriter that writes to w.
+func NewBeastWriter(w io.Writer) *BeastWriter {
+	return &BeastWriter{w: w}
+}
+
+// WriteFrame writes the frame as a single Beast frame.
+func (b *BeastWriter) WriteFrame(frame models.Frame) error {
+	buf, err := EncodeBeast(frame)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.w.Write(buf)
+	return err
+}
+
+// EncodeBeast encodes a frame in the Beast binary format, escaping any 0x1A bytes.
+func EncodeBeast(frame models.Frame) ([]byte, error) {
+	msg, err := hex.DecodeString(frame.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	var t byte
+	switch len(msg) {
+	case 2:
+		t = beastModeAC
+	case 7:
+		t = beastModeShort
+	case 14:
+		t = beastModeLong
+	default:
+		return nil, fmt.Errorf("unexpected message length %d", len(msg))
+	}
+
+	body := make([]byte, 0, 7+len(msg))
+	for shift := 40; shift >= 0; shift -= 8 {
+		body = append(body, byte(frame.Timestamp>>uint(shift)))
+	}
+	body = append(body, RSSIToSignal(frame.RSSI))
+	body = append(body, msg...)
+
+	out := make([]byte, 0, 2+2*len(body))
+	out = append(out, beastEscape, t)
+	for _, c := range body {
+		out = append(out, c)
+		if c == beastEscape {
+			out = append(out, c)
+		}
+	}
+
+	return out, nil
+}
+
+// SignalToRSSI converts a one byte signal level, as used by Beast receivers, to dBFS.
+func SignalToRSSI(level byte) float64 {
+	if level == 0 {
+		return -50
+	}
+
+	l := float64(level) / 255
+	return roundFloat(10*math.Log10(l*l), 1)
+}
+
+func roundFloat(val float64, precision uint) float64 {
+	ratio := math.Pow(10, float64(precision))
+	return math.Round(val*ratio) / ratio
+}
+
+// RSSIToSignal is the inverse of SignalToRSSI.
+func RSSIToSignal(rssi float64) byte {
+	if rssi <= -50 {
+		return 0
+	}
+	if rssi >= 0 {
+		return 255
+	}
+
+	return byte(math.Round(255 * math.Pow(10, rssi/20)))
+}
@@ -0,0 +1,118 @@
+package formats
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func beastBytes(t byte, ts []byte, signal byte, msg []byte) []byte {
+	body := append(append(append([]byte{}, ts...), signal), msg...)
+
+	out := []byte{0x1A, t}
+	for _, c := range body {
+		out = append(out, c)
+		if c == 0x1A {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+var beastTests = []struct {
+	name     string
+	input    []byte
+	want     string
+	wantTs   uint64
+	wantRSSI float64
+}{
+	{
+		"long",
+		beastBytes('3', []byte{0, 0, 0, 0, 0x12, 0x34}, 0xFF, []byte{0x8D, 0x48, 0x40, 0xD6, 0x20, 0x2C, 0xC3, 0x71, 0xC3, 0x2C, 0xE0, 0x57, 0x60, 0x98}),
+		"8D4840D6202CC371C32CE0576098", 0x1234, 0,
+	},
+	{
+		"short",
+		beastBytes('2', []byte{0, 0, 0, 0, 0, 1}, 0x80, []byte{0x5D, 0x48, 0x40, 0xD6, 0x00, 0x00, 0x00}),
+		"5D4840D6000000", 1, -6,
+	},
+	{
+		"mode ac",
+		beastBytes('1', []byte{0, 0, 0, 0, 0, 2}, 0x80, []byte{0x12, 0x34}),
+		"1234", 2, -6,
+	},
+	{
+		"escaped",
+		beastBytes('3', []byte{0x1A, 0, 0, 0, 0, 0x1A}, 0x1A, []byte{0x8D, 0x1A, 0x40, 0xD6, 0x20, 0x2C, 0xC3, 0x71, 0xC3, 0x2C, 0xE0, 0x57, 0x60, 0x1A}),
+		"8D1A40D6202CC371C32CE057601A", 0x1A000000001A, -19.8,
+	},
+	{
+		"garbage before frame",
+		append([]byte{0x00, 0x42, 0x1A, 0x1A}, beastBytes('1', []byte{0, 0, 0, 0, 0, 3}, 0xFF, []byte{0x07, 0x00})...),
+		"0700", 3, 0,
+	},
+	{
+		"truncated frame",
+		append(beastBytes('3', []byte{0, 0, 0, 0, 0, 4}, 0xFF, []byte{0x8D, 0x48})[:6], beastBytes('1', []byte{0, 0, 0, 0, 0, 5}, 0xFF, []byte{0x07, 0x00})...),
+		"0700", 5, 0,
+	},
+}
+
+func TestBeastReader(t *testing.T) {
+	for _, test := range beastTests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewBeastReader(bytes.NewReader(test.input))
+
+			frame, err := r.ReadFrame()
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if frame.Message != test.want {
+				t.Errorf("Message incorrect, wanted %v got %v", test.want, frame.Message)
+			}
+			if frame.Timestamp != test.wantTs {
+				t.Errorf("Timestamp incorrect, wanted %v got %v", test.wantTs, frame.Timestamp)
+			}
+			if frame.RSSI != test.wantRSSI {
+				t.Errorf("RSSI incorrect, wanted %v got %v", test.wantRSSI, frame.RSSI)
+			}
+
+			if _, err := r.ReadFrame(); err != io.EOF {
+				t.Errorf("wanted EOF after last frame, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBeastReaderSkipsStatus(t *testing.T) {
+	input := append(beastBytes('4', make([]byte, 6), 0, make([]byte, 14)), beastBytes('1', make([]byte, 6), 0xFF, []byte{0x12, 0x34})...)
+
+	frame, err := NewBeastReader(bytes.NewReader(input)).ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if frame.Message != "1234" {
+		t.Fatalf("Message incorrect, wanted %v got %v", "1234", frame.Message)
+	}
+}
+
+func TestBeastWriterRoundTrip(t *testing.T) {
+	for _, test := range beastTests[:4] {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := NewBeastWriter(&buf).WriteFrame(models.Frame{Message: test.want, Timestamp: test.wantTs, RSSI: test.wantRSSI})
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), test.input) {
+				t.Fatalf("encoding incorrect, wanted %X got %X", test.input, buf.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Package formats provides readers and writers for the wire formats spoken by Mode S receivers.
+package formats
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pragmatic-zac/goModeS/demod"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// FrameReader is implemented by the readers in this package.
+type FrameReader interface {
+	ReadFrame() (models.Frame, error)
+}
+
+// NewReader returns a FrameReader for the named format, "raw", "beast", or "iq2000" and "iq2400" for unsigned 8-bit IQ
+// samples at 2 and 2.4 MHz, which are demodulated.
+func NewReader(mode string, r io.Reader) (FrameReader, error) {
+	switch mode {
+	case "raw":
+		return NewRawReader(r), nil
+	case "beast":
+		return NewBeastReader(r), nil
+	case "iq2000":
+		return demod.NewReader(r, demod.Options{SampleRate: demod.Rate2000})
+	case "iq2400":
+		return demod.NewReader(r, demod.Options{SampleRate: demod.Rate2400})
+	default:
+		return nil, fmt.Errorf("unsupported format %q", mode)
+	}
+}
+
+// FrameWriter is implemented by the writers in this package.
+type FrameWriter interface {
+	WriteFrame(frame models.Frame) error
+}
+
+// NewWriter returns a FrameWriter for the named format, "raw", "beast" or "sbs". Positions in SBS output are decoded
+// relative to the given receiver location.
+func NewWriter(mode string, w io.Writer, latRef float64, lonRef float64) (FrameWriter, error) {
+	switch mode {
+	case "raw":
+		return NewRawWriter(w), nil
+	case "beast":
+		return NewBeastWriter(w), nil
+	case "sbs":
+		return NewSBSWriter(w, latRef, lonRef), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", mode)
+	}
+}
@@ -0,0 +1,128 @@
+package formats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// RawReader reads frames from a stream of newline separated AVR messages. See ParseAVR for the supported variants.
+type RawReader struct {
+	r *bufio.Reader
+}
+
+// NewRawReader returns a RawReader that reads from r.
+func NewRawReader(r io.Reader) *RawReader {
+	return &RawReader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame returns the next valid message from the stream. Lines that cannot be parsed are skipped.
+func (a *RawReader) ReadFrame() (models.Frame, error) {
+	for {
+		line, err := a.r.ReadString('\n')
+		if err != nil {
+			return models.Frame{}, err
+		}
+
+		frame, err := ParseAVR(line)
+		if err != nil {
+			continue
+		}
+
+		return frame, nil
+	}
+}
+
+// ParseAVR parses a single line in one of the AVR formats:
+//   - "*8D4840D6202CC371C32CE0576098;" plain message.
+//   - "@0000001234568D4840D6202CC371C32CE0576098;" prefixed with a 12 digit 12 MHz MLAT timestamp.
+//   - "%000000123456A08D4840D6202CC371C32CE0576098;" and "<000000123456A08D4840D6202CC371C32CE0576098;" prefixed with
+//     a 12 digit timestamp and a 2 digit signal level.
+//
+// The message itself may be a 2 byte Mode A/C, 7 byte (56-bit) or 14 byte (112-bit) Mode S frame.
+func ParseAVR(line string) (models.Frame, error) {
+	line = strings.TrimSpace(line)
+	if len(line) < 2 {
+		return models.Frame{}, errors.New("line too short")
+	}
+
+	frame := models.Frame{Received: time.Now()}
+
+	var body string
+	switch line[0] {
+	case '*':
+		body = line[1:]
+	case '@':
+		if len(line) < 13 {
+			return models.Frame{}, errors.New("missing timestamp")
+		}
+		ts, err := strconv.ParseUint(line[1:13], 16, 64)
+		if err != nil {
+			return models.Frame{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		frame.Timestamp = ts
+		body = line[13:]
+	case '%', '<':
+		if len(line) < 15 {
+			return models.Frame{}, errors.New("missing timestamp or signal level")
+		}
+		ts, err := strconv.ParseUint(line[1:13], 16, 64)
+		if err != nil {
+			return models.Frame{}, fmt.Errorf("invalid timestamp: %w", err)
+		}
+		sig, err := strconv.ParseUint(line[13:15], 16, 8)
+		if err != nil {
+			return models.Frame{}, fmt.Errorf("invalid signal level: %w", err)
+		}
+		frame.Timestamp = ts
+		frame.RSSI = SignalToRSSI(byte(sig))
+		body = line[15:]
+	default:
+		return models.Frame{}, fmt.Errorf("unknown AVR prefix %q", line[0])
+	}
+
+	body = strings.TrimSuffix(body, ";")
+	if len(body) != 4 && len(body) != 14 && len(body) != 28 {
+		return models.Frame{}, fmt.Errorf("unexpected message length %d", len(body))
+	}
+	for _, c := range body {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return models.Frame{}, errors.New("message is not hexadecimal")
+		}
+	}
+
+	frame.Message = strings.ToUpper(body)
+
+	return frame, nil
+}
+
+// RawWriter writes frames as AVR lines.
+type RawWriter struct {
+	w io.Writer
+}
+
+// NewRawWriter returns a RawWriter that writes to w.
+func NewRawWriter(w io.Writer) *RawWriter {
+	return &RawWriter{w: w}
+}
+
+// WriteFrame writes the frame as a single AVR line.
+func (a *RawWriter) WriteFrame(frame models.Frame) error {
+	_, err := io.WriteString(a.w, FormatAVR(frame))
+	return err
+}
+
+// FormatAVR formats a frame as an AVR line including the trailing newline. Frames with an MLAT timestamp use the "@"
+// variant, other frames the plain "*" variant.
+func FormatAVR(frame models.Frame) string {
+	if frame.Timestamp == 0 {
+		return "*" + frame.Message + ";\n"
+	}
+	return fmt.Sprintf("@%012X%s;\n", frame.Timestamp&0xFFFFFFFFFFFF, frame.Message)
+}
@@ -0,0 +1,86 @@
+package formats
+
+import (
+	"strings"
+	"testing"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+var avrTests = []struct {
+	line     string
+	want     string
+	wantTs   uint64
+	wantRSSI float64
+}{
+	{"*8D4840D6202CC371C32CE0576098;\n", "8D4840D6202CC371C32CE0576098", 0, 0},
+	{"*8d4840d6202cc371c32ce0576098;\r\n", "8D4840D6202CC371C32CE0576098", 0, 0},
+	{"*5D4840D6000000;", "5D4840D6000000", 0, 0},
+	{"@0000001234568D4840D6202CC371C32CE0576098;", "8D4840D6202CC371C32CE0576098", 0x123456, 0},
+	{"<000000123456FF8D4840D6202CC371C32CE0576098;", "8D4840D6202CC371C32CE0576098", 0x123456, 0},
+	{"%00000012345680*2A00516D492B80;", "", 0, 0},
+	{"%000000123456802A00516D492B80;", "2A00516D492B80", 0x123456, -6},
+	{"*1234;", "1234", 0, 0},
+}
+
+func TestParseAVR(t *testing.T) {
+	for _, test := range avrTests {
+		t.Run(test.line, func(t *testing.T) {
+			frame, err := ParseAVR(test.line)
+			if test.want == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %v", frame)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if frame.Message != test.want {
+				t.Errorf("Message incorrect, wanted %v got %v", test.want, frame.Message)
+			}
+			if frame.Timestamp != test.wantTs {
+				t.Errorf("Timestamp incorrect, wanted %v got %v", test.wantTs, frame.Timestamp)
+			}
+			if frame.RSSI != test.wantRSSI {
+				t.Errorf("RSSI incorrect, wanted %v got %v", test.wantRSSI, frame.RSSI)
+			}
+		})
+	}
+}
+
+func TestRawReaderSkipsInvalid(t *testing.T) {
+	input := "garbage\n*8D4840D6;\n\n@0000001234568D4840D6202CC371C32CE0576098;\n"
+
+	frame, err := NewRawReader(strings.NewReader(input)).ReadFrame()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if frame.Message != "8D4840D6202CC371C32CE0576098" {
+		t.Fatalf("Message incorrect, wanted %v got %v", "8D4840D6202CC371C32CE0576098", frame.Message)
+	}
+}
+
+func TestFormatAVR(t *testing.T) {
+	tests := []struct {
+		frame models.Frame
+		want  string
+	}{
+		{models.Frame{Message: "8D4840D6202CC371C32CE0576098"}, "*8D4840D6202CC371C32CE0576098;\n"},
+		{models.Frame{Message: "5D4840D6000000", Timestamp: 0x123456}, "@0000001234565D4840D6000000;\n"},
+	}
+
+	for _, test := range tests {
+		got := FormatAVR(test.frame)
+		if got != test.want {
+			t.Fatalf("AVR line incorrect, wanted %q got %q", test.want, got)
+		}
+
+		frame, err := ParseAVR(got)
+		if err != nil || frame.Message != test.frame.Message || frame.Timestamp != test.frame.Timestamp {
+			t.Fatalf("AVR line does not round trip, got %v %v", frame, err)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package formats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// SBS transmission types, the second field of a BaseStation MSG line.
+const (
+	SBSIdentification  = 1
+	SBSSurfacePos      = 2
+	SBSAirbornePos     = 3
+	SBSAirborneVel     = 4
+	SBSSurveillanceAlt = 5
+	SBSSurveillanceID  = 6
+	SBSAirToAir        = 7
+	SBSAllCall         = 8
+)
+
+const (
+	sbsDateLayout = "2006/01/02"
+	sbsTimeLayout = "15:04:05.000"
+	sbsFields     = 22
+)
+
+// SBSMessage is a single MSG line of the BaseStation (SBS-1) CSV format. Fields that are empty on the line are nil.
+type SBSMessage struct {
+	Type      int
+	Icao      string
+	Generated time.Time
+	Callsign  *string
+	Altitude  *int
+	Speed     *float64
+	Track     *float64
+	Latitude  *float64
+	Longitude *float64
+	VertRate  *int32
+	Squawk    *string
+	Alert     *bool
+	Emergency *bool
+	SPI       *bool
+	OnGround  *bool
+}
+
+// ParseSBS parses a single BaseStation MSG line, e.g. "MSG,3,1,1,4840D6,1,2023/01/02,10:00:00.000,...".
+func ParseSBS(line string) (SBSMessage, error) {
+	fields := strings.Split(strings.TrimRight(line, "\r\n"), ",")
+	if len(fields) < sbsFields {
+		return SBSMessage{}, fmt.Errorf("expected %d fields, got %d", sbsFields, len(fields))
+	}
+
+	if fields[0] != "MSG" {
+		return SBSMessage{}, errors.New("not a MSG line")
+	}
+
+	msgType, err := strconv.Atoi(fields[1])
+	if err != nil || msgType < SBSIdentification || msgType > SBSAllCall {
+		return SBSMessage{}, fmt.Errorf("invalid transmission type %q", fields[1])
+	}
+
+	m := SBSMessage{
+		Type: msgType,
+		Icao: strings.ToUpper(strings.TrimSpace(fields[4])),
+	}
+
+	if m.Icao == "" {
+		return SBSMessage{}, errors.New("missing hex ident")
+	}
+
+	if gen, err := time.ParseInLocation(sbsDateLayout+" "+sbsTimeLayout, fields[6]+" "+fields[7], time.Local); err == nil {
+		m.Generated = gen
+	}
+
+	if s := strings.TrimSpace(fields[10]); s != "" {
+		m.Callsign = &s
+	}
+	if v, err := strconv.Atoi(fields[11]); err == nil {
+		m.Altitude = &v
+	}
+	if v, err := strconv.ParseFloat(fields[12], 64); err == nil {
+		m.Speed = &v
+	}
+	if v, err := strconv.ParseFloat(fields[13], 64); err == nil {
+		m.Track = &v
+	}
+	if v, err := strconv.ParseFloat(fields[14], 64); err == nil {
+		m.Latitude = &v
+	}
+	if v, err := strconv.ParseFloat(fields[15], 64); err == nil {
+		m.Longitude = &v
+	}
+	if v, err := strconv.ParseInt(fields[16], 10, 32); err == nil {
+		vr := int32(v)
+		m.VertRate = &vr
+	}
+	if s := strings.TrimSpace(fields[17]); s != "" {
+		m.Squawk = &s
+	}
+	m.Alert = parseSBSFlag(fields[18])
+	m.Emergency = parseSBSFlag(fields[19])
+	m.SPI = parseSBSFlag(fields[20])
+	m.OnGround = parseSBSFlag(fields[21])
+
+	return m, nil
+}
+
+func parseSBSFlag(s string) *bool {
+	switch strings.TrimSpace(s) {
+	case "-1", "1":
+		b := true
+		return &b
+	case "0":
+		b := false
+		return &b
+	default:
+		return nil
+	}
+}
+
+// SBSReader reads MSG lines from a BaseStation stream. Other line types (SEL, ID, AIR, STA, CLK) are skipped.
+type SBSReader struct {
+	r *bufio.Reader
+}
+
+// NewSBSReader returns an SBSReader that reads from r.
+func NewSBSReader(r io.Reader) *SBSReader {
+	return &SBSReader{r: bufio.NewReader(r)}
+}
+
+// ReadMessage returns the next MSG line from the stream.
+func (s *SBSReader) ReadMessage() (SBSMessage, error) {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return SBSMessage{}, err
+		}
+
+		if !strings.HasPrefix(line, "MSG,") {
+			continue
+		}
+
+		m, err := ParseSBS(line)
+		if err != nil {
+			continue
+		}
+
+		return m, nil
+	}
+}
+
+// SBSWriter decodes Mode S frames and writes them as BaseStation MSG lines. Positions are decoded locally against the
+// receiver reference position.
+type SBSWriter struct {
+	w      io.Writer
+	latRef float64
+	lonRef float64
+}
+
+// NewSBSWriter returns an SBSWriter that writes to w, decoding positions relative to the given reference.
+func NewSBSWriter(w io.Writer, latRef float64, lonRef float64) *SBSWriter {
+	return &SBSWriter{w: w, latRef: latRef, lonRef: lonRef}
+}
+
+// WriteFrame writes the frame as a MSG line. Frames that have no BaseStation representation are silently skipped.
+func (s *SBSWriter) WriteFrame(frame models.Frame) error {
+	m, ok := s.Encode(frame)
+	if !ok {
+		return nil
+	}
+
+	_, err := io.WriteString(s.w, FormatSBS(m))
+	return err
+}
+
+// Encode decodes a frame into an SBSMessage. It reports false if the frame has no BaseStation representation.
+func (s *SBSWriter) Encode(frame models.Frame) (SBSMessage, bool) {
+	msg := frame.Message
+	if len(msg) != 14 && len(msg) != 28 {
+		return SBSMessage{}, false
+	}
+
+	df, err := decode.Df(msg)
+	if err != nil {
+		return SBSMessage{}, false
+	}
+
+	icao, err := decode.Icao(msg)
+	if err != nil || icao == "" {
+		return SBSMessage{}, false
+	}
+
+	m := SBSMessage{Icao: icao, Generated: frame.Received}
+	if m.Generated.IsZero() {
+		m.Generated = time.Now()
+	}
+
+	switch df {
+	case 0, 16:
+		m.Type = SBSAirToAir
+		if alt, err := decode.AltitudeCode(msg); err == nil && alt != 0 {
+			m.Altitude = &alt
+		}
+	case 4, 20:
+		m.Type = SBSSurveillanceAlt
+		if alt, err := decode.AltitudeCode(msg); err == nil && alt != 0 {
+			m.Altitude = &alt
+		}
+	case 5, 21:
+		m.Type = SBSSurveillanceID
+		if sq, err := decode.Squawk(msg); err == nil {
+			m.Squawk = &sq
+		}
+	case 11:
+		m.Type = SBSAllCall
+	case 17, 18:
+		if !s.encodeExtendedSquitter(msg, &m) {
+			return SBSMessage{}, false
+		}
+	default:
+		return SBSMessage{}, false
+	}
+
+	return m, true
+}
+
+func (s *SBSWriter) encodeExtendedSquitter(msg string, m *SBSMessage) bool {
+	tc, err := decode.Typecode(msg)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case tc >= 1 && tc <= 4:
+		m.Type = SBSIdentification
+		cs, err := decode.Callsign(msg)
+		if err != nil {
+			return false
+		}
+		cs = strings.TrimRight(cs, " #")
+		m.Callsign = &cs
+	case tc >= 5 && tc <= 8:
+		m.Type = SBSSurfacePos
+		pos, err := decode.SurfacePositionWithRef(msg, s.latRef, s.lonRef)
+		if err != nil {
+			return false
+		}
+		m.Latitude, m.Longitude = &pos.Latitude, &pos.Longitude
+		if vel, err := decode.SurfaceVelocity(msg); err == nil {
+			m.Speed, m.Track = &vel.Speed, &vel.Angle
+		}
+		onGround := true
+		m.OnGround = &onGround
+	case tc >= 9 && tc <= 18 || tc >= 20 && tc <= 22:
+		m.Type = SBSAirbornePos
+		pos, err := decode.AirbornePositionWithRef(msg, s.latRef, s.lonRef)
+		if err != nil {
+			return false
+		}
+		m.Latitude, m.Longitude = &pos.Latitude, &pos.Longitude
+		if alt, err := decode.Altitude(msg); err == nil && alt != 0 {
+			m.Altitude = &alt
+		}
+		onGround := false
+		m.OnGround = &onGround
+	case tc == 19:
+		m.Type = SBSAirborneVel
+		vel, err := decode.AirborneVelocity(msg)
+		if err != nil {
+			return false
+		}
+		m.Speed, m.Track, m.VertRate = &vel.Speed, &vel.Angle, &vel.VertRate
+	default:
+		return false
+	}
+
+	return true
+}
+
+// FormatSBS formats an SBSMessage as a BaseStation MSG line, including the trailing CRLF.
+func FormatSBS(m SBSMessage) string {
+	fields := make([]string, sbsFields)
+	fields[0] = "MSG"
+	fields[1] = strconv.Itoa(m.Type)
+	fields[2] = "1"
+	fields[3] = "1"
+	fields[4] = m.Icao
+	fields[5] = "1"
+	fields[6] = m.Generated.Format(sbsDateLayout)
+	fields[7] = m.Generated.Format(sbsTimeLayout)
+	fields[8] = fields[6]
+	fields[9] = fields[7]
+
+	if m.Callsign != nil {
+		fields[10] = *m.Callsign
+	}
+	if m.Altitude != nil {
+		fields[11] = strconv.Itoa(*m.Altitude)
+	}
+	if m.Speed != nil {
+		fields[12] = strconv.FormatFloat(*m.Speed, 'f', -1, 64)
+	}
+	if m.Track != nil {
+		fields[13] = strconv.FormatFloat(*m.Track, 'f', -1, 64)
+	}
+	if m.Latitude != nil {
+		fields[14] = strconv.FormatFloat(*m.Latitude, 'f', 5, 64)
+	}
+	if m.Longitude != nil {
+		fields[15] = strconv.FormatFloat(*m.Longitude, 'f', 5, 64)
+	}
+	if m.VertRate != nil {
+		fields[16] = strconv.Itoa(int(*m.VertRate))
+	}
+	if m.Squawk != nil {
+		fields[17] = *m.Squawk
+	}
+	fields[18] = formatSBSFlag(m.Alert)
+	fields[19] = formatSBSFlag(m.Emergency)
+	fields[20] = formatSBSFlag(m.SPI)
+	fields[21] = formatSBSFlag(m.OnGround)
+
+	return strings.Join(fields, ",") + "\r\n"
+}
+
+func formatSBSFlag(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	if *b {
+		return "-1"
+	}
+	return "0"
+}
@@ -0,0 +1,84 @@
+package formats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestParseSBS(t *testing.T) {
+	line := "MSG,3,1,1,4840D6,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,,38000,,,52.25720,3.91937,,,0,0,0,0\r\n"
+
+	m, err := ParseSBS(line)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if m.Type != SBSAirbornePos {
+		t.Fatalf("Type incorrect, wanted %v got %v", SBSAirbornePos, m.Type)
+	}
+	if m.Icao != "4840D6" {
+		t.Fatalf("ICAO incorrect, wanted %v got %v", "4840D6", m.Icao)
+	}
+	if m.Altitude == nil || *m.Altitude != 38000 {
+		t.Fatalf("Altitude incorrect, wanted %v got %v", 38000, m.Altitude)
+	}
+	if m.Latitude == nil || *m.Latitude != 52.2572 {
+		t.Fatalf("Latitude incorrect, wanted %v got %v", 52.2572, m.Latitude)
+	}
+	if m.Callsign != nil || m.Squawk != nil || m.Speed != nil {
+		t.Fatalf("empty fields should be nil")
+	}
+	if m.OnGround == nil || *m.OnGround {
+		t.Fatalf("OnGround incorrect, wanted false got %v", m.OnGround)
+	}
+
+	want := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	if !m.Generated.Equal(want) {
+		t.Fatalf("Generated incorrect, wanted %v got %v", want, m.Generated)
+	}
+
+	if actual := FormatSBS(m); actual != line {
+		t.Fatalf("FormatSBS incorrect, wanted %q got %q", line, actual)
+	}
+}
+
+func TestParseSBSInvalid(t *testing.T) {
+	for _, line := range []string{"", "SEL,,496,2286,4CA4E5,27215,2010/02/19,18:06:07.710,2010/02/19,18:06:07.710,RYR1427", "MSG,9,1,1,4840D6,1,,,,,,,,,,,,,,,,"} {
+		if _, err := ParseSBS(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+var sbsWriterTests = []struct {
+	msg  string
+	want string
+}{
+	{"8D4840D6202CC371C32CE0576098", "MSG,1,1,1,4840D6,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,KLM1023,,,,,,,,,,,\r\n"},
+	{"8D485020994409940838175B284F", "MSG,4,1,1,485020,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,,,159,182.88,,,-832,,,,,\r\n"},
+	// the identification above sent as DF18
+	{"904840D6202CC371C32CE02A6C6D", "MSG,1,1,1,4840D6,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,KLM1023,,,,,,,,,,,\r\n"},
+	{"8D40058B58C901375147EFD09357", "MSG,3,1,1,40058B,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,,39000,,,"},
+}
+
+func TestSBSWriter(t *testing.T) {
+	for _, test := range sbsWriterTests {
+		t.Run(test.msg, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewSBSWriter(&buf, 52.0, 4.0)
+
+			frame := models.Frame{Message: test.msg, Received: time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)}
+			if err := w.WriteFrame(frame); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if !strings.HasPrefix(buf.String(), test.want) {
+				t.Errorf("SBS line incorrect, wanted %q got %q", test.want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Package geo holds the geodesy shared by range statistics, multilateration and plausibility checks: great circle
+// distance, bearing and destination on a spherical earth, conversions between geodetic and earth centred coordinates
+// on the WGS84 ellipsoid, and the units aviation uses.
+//
+// Latitudes, longitudes and bearings are in degrees, distances and heights in meters unless a name says otherwise.
+package geo
+
+import "math"
+
+// EarthRadius is the mean earth radius in meters, used for great circle calculations.
+const EarthRadius = 6371008.8
+
+// MaxPlausibleRange is the largest distance in meters a receiver is taken to hear an aircraft at. A position further
+// away can only come from a bad decode.
+const MaxPlausibleRange = 1000e3
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// normalizeBearing maps a bearing to [0, 360).
+func normalizeBearing(b float64) float64 {
+	b = math.Mod(b, 360)
+	if b < 0 {
+		b += 360
+	}
+	return b
+}
+
+// normalizeLon maps a longitude to [-180, 180).
+func normalizeLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// Distance returns the great circle distance between two positions.
+func Distance(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLon := radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(a, 1)))
+}
+
+// Bearing returns the initial true bearing of the great circle from the first position to the second, in [0, 360).
+func Bearing(lat1 float64, lon1 float64, lat2 float64, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dLon := radians(lon2 - lon1)
+
+	y := math.Sin(dLon) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLon)
+	return normalizeBearing(degrees(math.Atan2(y, x)))
+}
+
+// Destination returns the position reached by travelling the distance along the great circle that starts at the given
+// position with the given bearing.
+func Destination(lat float64, lon float64, bearing float64, distance float64) (float64, float64) {
+	phi1, lambda1 := radians(lat), radians(lon)
+	theta := radians(bearing)
+	delta := distance / EarthRadius
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+
+	return degrees(phi2), normalizeLon(degrees(lambda2))
+}
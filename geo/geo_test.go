@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	// Amsterdam Schiphol to London Heathrow
+	d := Distance(52.3086, 4.7639, 51.4706, -0.4619)
+	if math.Abs(d-370500) > 1000 {
+		t.Fatalf("Distance incorrect, wanted about %v got %v", 370500, d)
+	}
+
+	if d := Distance(52, 4, 52, 4); d != 0 {
+		t.Fatalf("Distance incorrect, wanted %v got %v", 0, d)
+	}
+}
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		lat2, lon2 float64
+		want       float64
+	}{
+		{53, 4, 0},
+		{52, 5, 89.6},
+		{51, 4, 180},
+		{52, 3, 270.4},
+	}
+
+	for _, test := range tests {
+		if got := Bearing(52, 4, test.lat2, test.lon2); math.Abs(got-test.want) > 0.1 {
+			t.Fatalf("Bearing to %v, %v incorrect, wanted %v got %v", test.lat2, test.lon2, test.want, got)
+		}
+	}
+}
+
+func TestDestination(t *testing.T) {
+	for _, bearing := range []float64{0, 45, 135, 270, 359} {
+		lat, lon := Destination(52.3086, 4.7639, bearing, 250e3)
+		if d := Distance(52.3086, 4.7639, lat, lon); math.Abs(d-250e3) > 0.01 {
+			t.Fatalf("%v: distance incorrect, wanted %v got %v", bearing, 250e3, d)
+		}
+		if b := Bearing(52.3086, 4.7639, lat, lon); math.Abs(b-bearing) > 1e-6 && math.Abs(b-bearing) < 360-1e-6 {
+			t.Fatalf("%v: bearing incorrect, wanted %v got %v", bearing, bearing, b)
+		}
+	}
+
+	// across the antimeridian
+	if _, lon := Destination(0, 179.5, 90, 111195); math.Abs(lon+179.5) > 1e-3 {
+		t.Fatalf("longitude incorrect, wanted %v got %v", -179.5, lon)
+	}
+}
+
+func TestECEF(t *testing.T) {
+	// on the equator at the prime meridian, and at the north pole
+	if p := ToECEF(0, 0, 0); math.Abs(p.X-WGS84A) > 1e-6 || p.Y != 0 || p.Z != 0 {
+		t.Fatalf("ToECEF incorrect, wanted %v 0 0 got %v", WGS84A, p)
+	}
+	if p := ToECEF(90, 0, 100); math.Abs(p.Z-WGS84B-100) > 1e-6 {
+		t.Fatalf("ToECEF incorrect, wanted Z %v got %v", WGS84B+100, p.Z)
+	}
+
+	tests := [][3]float64{
+		{52.3086, 4.7639, -2},
+		{-33.9461, 151.1772, 6},
+		{51.4706, -0.4619, 11000},
+		{89.99, -120, 3000},
+		{-90, 0, 10},
+	}
+	for _, test := range tests {
+		lat, lon, alt := ToLLA(ToECEF(test[0], test[1], test[2]))
+		if math.Abs(lat-test[0]) > 1e-9 || math.Abs(alt-test[2]) > 1e-3 || (math.Abs(test[0]) < 90 && math.Abs(lon-test[1]) > 1e-9) {
+			t.Fatalf("ToLLA incorrect, wanted %v got %v %v %v", test, lat, lon, alt)
+		}
+	}
+
+	if d := ToECEF(52, 4, 0).Sub(ToECEF(52, 4, 1000)).Norm(); math.Abs(d-1000) > 1e-6 {
+		t.Fatalf("Norm incorrect, wanted %v got %v", 1000, d)
+	}
+}
+
+func TestUnits(t *testing.T) {
+	if m := FeetToMeters(38000); math.Abs(m-11582.4) > 1e-6 {
+		t.Fatalf("FeetToMeters incorrect, wanted %v got %v", 11582.4, m)
+	}
+	if ft := MetersToFeet(FeetToMeters(1234)); math.Abs(ft-1234) > 1e-9 {
+		t.Fatalf("MetersToFeet incorrect, wanted %v got %v", 1234, ft)
+	}
+	if nm := MetersToNauticalMiles(370400); nm != 200 {
+		t.Fatalf("MetersToNauticalMiles incorrect, wanted %v got %v", 200, nm)
+	}
+	if fl := FlightLevel(37975); fl != 380 {
+		t.Fatalf("FlightLevel incorrect, wanted %v got %v", 380, fl)
+	}
+}
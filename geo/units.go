@@ -0,0 +1,31 @@
+package geo
+
+import "math"
+
+// Lengths and speeds in meters and meters per second.
+const (
+	Foot         = 0.3048
+	NauticalMile = 1852.0
+	Knot         = NauticalMile / 3600
+)
+
+// FeetToMeters converts an altitude in feet, as reported by Mode S, to meters.
+func FeetToMeters(ft float64) float64 {
+	return ft * Foot
+}
+
+// MetersToFeet converts meters to feet.
+func MetersToFeet(m float64) float64 {
+	return m / Foot
+}
+
+// MetersToNauticalMiles converts meters to nautical miles.
+func MetersToNauticalMiles(m float64) float64 {
+	return m / NauticalMile
+}
+
+// FlightLevel returns the flight level of a pressure altitude in feet, in hundreds of feet rounded to the nearest
+// level.
+func FlightLevel(ft int) int {
+	return int(math.Round(float64(ft) / 100))
+}
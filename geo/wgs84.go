@@ -0,0 +1,69 @@
+package geo
+
+import "math"
+
+// WGS84 ellipsoid: semi-major axis in meters, flattening, semi-minor axis in meters and first eccentricity squared.
+const (
+	WGS84A  = 6378137.0
+	WGS84F  = 1 / 298.257223563
+	WGS84B  = WGS84A * (1 - WGS84F)
+	WGS84E2 = WGS84F * (2 - WGS84F)
+)
+
+// ECEF is a position in earth centred, earth fixed coordinates, in meters.
+type ECEF struct {
+	X, Y, Z float64
+}
+
+// Sub returns the vector from o to p.
+func (p ECEF) Sub(o ECEF) ECEF {
+	return ECEF{p.X - o.X, p.Y - o.Y, p.Z - o.Z}
+}
+
+// Norm returns the length of the vector p.
+func (p ECEF) Norm() float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+// ToECEF converts a geodetic position with the height above the WGS84 ellipsoid to ECEF coordinates.
+func ToECEF(lat float64, lon float64, alt float64) ECEF {
+	phi, lambda := radians(lat), radians(lon)
+
+	sinPhi := math.Sin(phi)
+	n := WGS84A / math.Sqrt(1-WGS84E2*sinPhi*sinPhi)
+
+	return ECEF{
+		X: (n + alt) * math.Cos(phi) * math.Cos(lambda),
+		Y: (n + alt) * math.Cos(phi) * math.Sin(lambda),
+		Z: (n*(1-WGS84E2) + alt) * sinPhi,
+	}
+}
+
+// ToLLA converts ECEF coordinates to a geodetic position and the height above the WGS84 ellipsoid, using Bowring's
+// method. It is accurate to well below a millimeter for positions near the surface of the earth.
+func ToLLA(p ECEF) (float64, float64, float64) {
+	ep2 := (WGS84A*WGS84A - WGS84B*WGS84B) / (WGS84B * WGS84B)
+	r := math.Hypot(p.X, p.Y)
+	if r == 0 {
+		// on the polar axis
+		lat := 90.0
+		if p.Z < 0 {
+			lat = -90
+		}
+		return lat, 0, math.Abs(p.Z) - WGS84B
+	}
+
+	beta := math.Atan2(WGS84A*p.Z, WGS84B*r)
+	var phi float64
+	for i := 0; i < 3; i++ {
+		sinBeta, cosBeta := math.Sincos(beta)
+		phi = math.Atan2(p.Z+ep2*WGS84B*sinBeta*sinBeta*sinBeta, r-WGS84E2*WGS84A*cosBeta*cosBeta*cosBeta)
+		beta = math.Atan2((1-WGS84F)*math.Sin(phi), math.Cos(phi))
+	}
+
+	sinPhi := math.Sin(phi)
+	n := WGS84A / math.Sqrt(1-WGS84E2*sinPhi*sinPhi)
+	alt := r*math.Cos(phi) + (p.Z+WGS84E2*n*sinPhi)*sinPhi - n
+
+	return degrees(phi), degrees(math.Atan2(p.Y, p.X)), alt
+}
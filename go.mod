@@ -3,8 +3,11 @@ module github.com/pragmatic-zac/goModeS
 go 1.19
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/buger/goterm v1.0.4
+	github.com/gorilla/websocket v1.5.0
 	github.com/spf13/cobra v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,228 @@
+// Package metrics exposes the state of the tracker and the input sources in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pragmatic-zac/goModeS/source"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+// states are the source states reported by gomodes_source_state.
+var states = []source.State{source.Connecting, source.Connected, source.Waiting, source.Finished, source.Failed, source.Stopped}
+
+// Handler serves the metrics. Nothing is kept between scrapes, every value is read from the tracker and the sources.
+type Handler struct {
+	tracker *streaming.Tracker
+	sources []*source.Source
+}
+
+// NewHandler returns a Handler for the tracker and the sources feeding it.
+func NewHandler(tracker *streaming.Tracker, sources []*source.Source) *Handler {
+	return &Handler{tracker: tracker, sources: sources}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := h.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes all metrics to w.
+func (h *Handler) Write(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	totals := h.tracker.Totals()
+	m := h.tracker.Metrics()
+	flights := h.tracker.Flights()
+
+	e.header("gomodes_frames_total", "counter", "Frames offered to the tracker.")
+	e.sample("gomodes_frames_total", nil, float64(totals.Frames))
+
+	e.header("gomodes_frames_accepted_total", "counter", "Frames that updated an aircraft.")
+	e.sample("gomodes_frames_accepted_total", nil, float64(totals.Accepted))
+
+	e.header("gomodes_messages_total", "counter", "Mode S frames received by downlink format.")
+	for _, df := range sortedKeys(m.ByDF) {
+		e.sample("gomodes_messages_total", []string{"df", strconv.Itoa(df)}, float64(m.ByDF[df]))
+	}
+
+	e.header("gomodes_messages_typecode_total", "counter", "Valid extended squitters by typecode.")
+	for _, tc := range sortedKeys(m.ByTypecode) {
+		e.sample("gomodes_messages_typecode_total", []string{"tc", strconv.Itoa(tc)}, float64(m.ByTypecode[tc]))
+	}
+
+	e.header("gomodes_crc_failures_total", "counter", "Extended squitters dropped for a CRC error.")
+	e.sample("gomodes_crc_failures_total", nil, float64(m.CRCFailures))
+
+	e.header("gomodes_crc_corrected_total", "counter", "Extended squitters repaired by flipping a single bit.")
+	e.sample("gomodes_crc_corrected_total", nil, float64(m.CRCCorrected))
+
+	e.header("gomodes_duplicates_total", "counter", "Frames dropped as copies of a frame heard by another receiver.")
+	e.sample("gomodes_duplicates_total", nil, float64(m.Duplicates))
+
+	delays := h.tracker.Delays()
+	receivers := make([]string, 0, len(delays))
+	for receiver := range delays {
+		receivers = append(receivers, receiver)
+	}
+	sort.Strings(receivers)
+	e.header("gomodes_receiver_delay_seconds", "gauge", "Delay of each receiver's frames relative to the fastest receiver.")
+	for _, receiver := range receivers {
+		e.sample("gomodes_receiver_delay_seconds", []string{"receiver", receiver}, delays[receiver].Seconds())
+	}
+
+	e.header("gomodes_positions_total", "counter", "Position messages by decode result.")
+	e.sample("gomodes_positions_total", []string{"result", "decoded"}, float64(m.PositionsDecoded))
+	e.sample("gomodes_positions_total", []string{"result", "failed"}, float64(m.PositionsFailed))
+
+	e.header("gomodes_modeac_replies_total", "counter", "Mode A/C replies by whether they matched a Mode S aircraft.")
+	e.sample("gomodes_modeac_replies_total", []string{"matched", "true"}, float64(m.ModeACMatched))
+	e.sample("gomodes_modeac_replies_total", []string{"matched", "false"}, float64(m.ModeACReplies-m.ModeACMatched))
+
+	e.header("gomodes_modeac_targets", "gauge", "Mode A/C codes heard recently that did not match a Mode S aircraft.")
+	e.sample("gomodes_modeac_targets", nil, float64(len(h.tracker.ModeAC())))
+
+	e.header("gomodes_mlat_positions_total", "counter", "Multilaterated positions by whether they were applied.")
+	e.sample("gomodes_mlat_positions_total", []string{"result", "applied"}, float64(m.MLATPositions))
+	e.sample("gomodes_mlat_positions_total", []string{"result", "rejected"}, float64(m.MLATRejected))
+
+	e.header("gomodes_tracks_total", "counter", "Aircraft that started being tracked.")
+	e.sample("gomodes_tracks_total", nil, float64(totals.NewFlights))
+
+	withPosition := 0
+	for _, f := range flights {
+		if !f.LastPositionTime.IsZero() {
+			withPosition++
+		}
+	}
+	e.header("gomodes_aircraft", "gauge", "Aircraft currently tracked.")
+	e.sample("gomodes_aircraft", nil, float64(len(flights)))
+
+	e.header("gomodes_aircraft_with_position", "gauge", "Aircraft currently tracked with a known position.")
+	e.sample("gomodes_aircraft_with_position", nil, float64(withPosition))
+
+	e.header("gomodes_max_range_meters", "gauge", "Largest distance between the receiver and a decoded position.")
+	e.sample("gomodes_max_range_meters", nil, m.MaxRange)
+
+	e.histogram("gomodes_decode_latency_seconds", "Time taken to decode and apply a frame.", m.Latency)
+
+	if len(h.sources) > 0 {
+		h.writeSources(e)
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (h *Handler) writeSources(e *encoder) {
+	statuses := make([]source.Status, 0, len(h.sources))
+	for _, s := range h.sources {
+		statuses = append(statuses, s.Status())
+	}
+
+	e.header("gomodes_source_up", "gauge", "Whether the source is connected.")
+	for _, s := range statuses {
+		up := 0.0
+		if s.State == source.Connected {
+			up = 1
+		}
+		e.sample("gomodes_source_up", []string{"source", s.Name}, up)
+	}
+
+	e.header("gomodes_source_state", "gauge", "The state of the source, 1 for the current one.")
+	for _, s := range statuses {
+		for _, state := range states {
+			v := 0.0
+			if s.State == state {
+				v = 1
+			}
+			e.sample("gomodes_source_state", []string{"source", s.Name, "state", state.String()}, v)
+		}
+	}
+
+	e.header("gomodes_source_frames_total", "counter", "Frames or messages read from the source.")
+	for _, s := range statuses {
+		e.sample("gomodes_source_frames_total", []string{"source", s.Name}, float64(s.Frames))
+	}
+
+	e.header("gomodes_source_reconnects_total", "counter", "Times the source was reopened after failing.")
+	for _, s := range statuses {
+		e.sample("gomodes_source_reconnects_total", []string{"source", s.Name}, float64(s.Reconnects))
+	}
+
+	e.header("gomodes_source_last_frame_timestamp_seconds", "gauge", "Unix time of the last frame read from the source.")
+	for _, s := range statuses {
+		v := 0.0
+		if !s.LastFrame.IsZero() {
+			v = float64(s.LastFrame.UnixNano()) / 1e9
+		}
+		e.sample("gomodes_source_last_frame_timestamp_seconds", []string{"source", s.Name}, v)
+	}
+}
+
+// encoder writes the text exposition format, keeping the first error.
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) printf(format string, args ...interface{}) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
+
+func (e *encoder) header(name string, typ string, help string) {
+	e.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a single value, labels are given as name, value pairs.
+func (e *encoder) sample(name string, labels []string, v float64) {
+	e.printf("%s%s %s\n", name, formatLabels(labels), strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func (e *encoder) histogram(name string, help string, h streaming.Histogram) {
+	e.header(name, "histogram", help)
+	for i, b := range h.Buckets {
+		e.sample(name+"_bucket", []string{"le", strconv.FormatFloat(b, 'g', -1, 64)}, float64(h.Counts[i]))
+	}
+	e.sample(name+"_bucket", []string{"le", "+Inf"}, float64(h.Count))
+	e.sample(name+"_sum", nil, h.Sum)
+	e.sample(name+"_count", nil, float64(h.Count))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func sortedKeys(m map[int]uint64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/source"
+	"github.com/pragmatic-zac/goModeS/streaming"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Unix(1457996400, 0)
+	tracker := streaming.NewTracker(52.0, 4.0, streaming.WithClock(func() time.Time { return now }))
+
+	for _, msg := range []string{"8D4840D6202CC371C32CE0576098", "8D4840D6A02CC371C32CE0576099", "8D40621D58C382D690C8AC2863A7"} {
+		tracker.Update(models.Frame{Message: msg, Received: now})
+	}
+
+	src, err := source.Parse(`tcp://"receiver":30005`, source.Options{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHandler(tracker, []*source.Source{src}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status incorrect, wanted %v got %v", http.StatusOK, rec.Code)
+	}
+	body := rec.Body.String()
+
+	wanted := []string{
+		"# TYPE gomodes_messages_total counter",
+		`gomodes_messages_total{df="17"} 3`,
+		`gomodes_messages_typecode_total{tc="4"} 1`,
+		`gomodes_messages_typecode_total{tc="11"} 1`,
+		"gomodes_crc_failures_total 1",
+		"gomodes_crc_corrected_total 0",
+		"gomodes_duplicates_total 0",
+		`gomodes_positions_total{result="decoded"} 1`,
+		"gomodes_aircraft 2",
+		"gomodes_aircraft_with_position 1",
+		"gomodes_max_range_meters 29",
+		`gomodes_decode_latency_seconds_bucket{le="+Inf"} 3`,
+		"gomodes_decode_latency_seconds_count 3",
+		`gomodes_source_up{source="tcp://\"receiver\":30005"} 0`,
+		`gomodes_source_state{source="tcp://\"receiver\":30005",state="connecting"} 1`,
+	}
+	for _, w := range wanted {
+		if !strings.Contains(body, w) {
+			t.Errorf("missing %q in\n%v", w, body)
+		}
+	}
+}
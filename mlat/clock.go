@@ -0,0 +1,90 @@
+package mlat
+
+import "math"
+
+// Clock synchronisation. Every receiver counts 12 MHz ticks from an arbitrary start, at a rate that is slightly off.
+// When two receivers hear the same ADS-B position message, the difference between their timestamps, corrected for
+// the propagation delay from the known aircraft position to each of them, is the offset between their clocks. The
+// offset is tracked per pair of receivers as a line fitted through the recent sync points, so drift is followed too.
+const (
+	// syncWindow is how long sync points are kept, in seconds.
+	syncWindow = 30
+	// maxSyncError is how far a sync point may be from the fitted line before it is taken to be a bad reference, in
+	// seconds.
+	maxSyncError = 5e-6
+	// maxSyncRejects is the number of bad sync points in a row after which the pair is reset, as one of the clocks
+	// has probably jumped.
+	maxSyncRejects = 5
+)
+
+// pairKey identifies a pair of receivers by index, a < b.
+type pairKey struct {
+	a, b int
+}
+
+// syncPoint is the offset of b's clock from a's at time t on a's clock, both in seconds.
+type syncPoint struct {
+	t      float64
+	offset float64
+}
+
+type clockPair struct {
+	points  []syncPoint
+	rejects int
+}
+
+// add records a sync point, dropping points older than the window and points that do not fit the recent ones.
+func (c *clockPair) add(t float64, offset float64) {
+	if len(c.points) >= 3 {
+		if predicted, _ := c.offset(t); math.Abs(offset-predicted) > maxSyncError {
+			c.rejects++
+			if c.rejects >= maxSyncRejects {
+				c.points = c.points[:0]
+				c.rejects = 0
+			}
+			return
+		}
+	}
+	c.rejects = 0
+
+	c.points = append(c.points, syncPoint{t, offset})
+
+	keep := 0
+	for keep < len(c.points) && c.points[keep].t < t-syncWindow {
+		keep++
+	}
+	c.points = c.points[keep:]
+}
+
+// offset returns the offset of b's clock from a's at time t on a's clock, from a least squares line through the sync
+// points.
+func (c *clockPair) offset(t float64) (float64, bool) {
+	switch len(c.points) {
+	case 0:
+		return 0, false
+	case 1:
+		return c.points[0].offset, true
+	}
+
+	// centre on the means, the clock values are large
+	var meanT, meanOffset float64
+	for _, p := range c.points {
+		meanT += p.t
+		meanOffset += p.offset
+	}
+	meanT /= float64(len(c.points))
+	meanOffset /= float64(len(c.points))
+
+	var num, den float64
+	for _, p := range c.points {
+		num += (p.t - meanT) * (p.offset - meanOffset)
+		den += (p.t - meanT) * (p.t - meanT)
+	}
+
+	slope := 0.0
+	if den > 0 {
+		slope = num / den
+	}
+
+	return meanOffset + slope*(t-meanT), true
+}
@@ -0,0 +1,391 @@
+// Package mlat locates aircraft that do not report their position, from the times their replies arrive at several
+// receivers (multilateration).
+//
+// Receivers must deliver 12 MHz timestamps, as Beast receivers do. Their clocks are synchronised using ADS-B position
+// messages heard by more than one receiver, then every other reply heard by enough synchronised receivers is solved
+// for the position whose range differences match the time differences of arrival. The altitude, from the reply itself
+// or the last one known, constrains the solution so three receivers suffice; without it four are needed.
+package mlat
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/geo"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+const (
+	// tickRate is the frequency of the receiver clocks.
+	tickRate = 12e6
+	// groupWindow is how long after the first copy of a message other receivers' copies are waited for.
+	groupWindow = 500 * time.Millisecond
+	// maxResidual is the largest RMS residual of an accepted solution, in meters.
+	maxResidual = 500.0
+	// maxRange is the largest accepted distance between a solution and the base receiver, in meters.
+	maxRange = 500e3
+	// speedOfLight in air is close enough to the vacuum value at the accuracy of a 12 MHz clock.
+	speedOfLight = 299792458.0
+)
+
+// Receiver is a receiver taking part in multilateration. Alt is its height above the WGS84 ellipsoid in meters.
+type Receiver struct {
+	ID  string
+	Lat float64
+	Lon float64
+	Alt float64
+}
+
+// Options configures a Solver.
+type Options struct {
+	// Altitude returns the last known altitude of an aircraft in feet, for replies that do not carry one. Optional.
+	Altitude func(icao string) (int, bool)
+}
+
+// Result is a multilaterated position.
+type Result struct {
+	Icao string
+	// Time is when the base receiver received the message.
+	Time     time.Time
+	Position decode.Position
+	// Altitude is the altitude used to solve, in feet, 0 if the position was solved without one.
+	Altitude int
+	// Receivers is the number of synchronised receivers that heard the message.
+	Receivers int
+	// Error is the RMS of the range difference residuals, in meters.
+	Error float64
+}
+
+// arrivalAt is a copy of a message received by a receiver.
+type arrivalAt struct {
+	rx       int
+	t        float64
+	received time.Time
+}
+
+// group collects the copies of a single transmission.
+type group struct {
+	msg      string
+	first    time.Time
+	arrivals []arrivalAt
+}
+
+func (g *group) has(rx int) bool {
+	for _, a := range g.arrivals {
+		if a.rx == rx {
+			return true
+		}
+	}
+	return false
+}
+
+// Solver synchronises receiver clocks and multilaterates replies. It is not safe for concurrent use.
+type Solver struct {
+	receivers []Receiver
+	ecef      []geo.ECEF
+	index     map[string]int
+	opts      Options
+
+	pairs map[pairKey]*clockPair
+	// queue holds the groups waiting for copies, oldest first
+	queue   []*group
+	pending map[string]*group
+}
+
+// New returns a Solver for the receivers.
+func New(receivers []Receiver, opts Options) (*Solver, error) {
+	s := &Solver{
+		receivers: receivers,
+		index:     make(map[string]int),
+		opts:      opts,
+		pairs:     make(map[pairKey]*clockPair),
+		pending:   make(map[string]*group),
+	}
+
+	for i, r := range receivers {
+		if _, ok := s.index[r.ID]; ok {
+			return nil, fmt.Errorf("duplicate receiver %q", r.ID)
+		}
+		if r.Lat < -90 || r.Lat > 90 || r.Lon < -180 || r.Lon > 180 {
+			return nil, fmt.Errorf("receiver %q: location %v, %v is out of range", r.ID, r.Lat, r.Lon)
+		}
+		s.index[r.ID] = i
+		s.ecef = append(s.ecef, geo.ToECEF(r.Lat, r.Lon, r.Alt))
+	}
+	if len(receivers) < 3 {
+		return nil, errors.New("multilateration needs at least three receivers")
+	}
+
+	return s, nil
+}
+
+// Add records a frame received by the named receiver and returns the positions solved from messages that are no
+// longer waiting for copies. Frames without a timestamp, from unknown receivers or of formats that are neither
+// references nor multilaterated are ignored.
+func (s *Solver) Add(receiver string, frame models.Frame) []Result {
+	rx, ok := s.index[receiver]
+	if !ok || frame.Timestamp == 0 || !useful(frame.Message) {
+		return nil
+	}
+
+	received := frame.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
+
+	results := s.flush(received.Add(-groupWindow))
+
+	a := arrivalAt{rx: rx, t: float64(frame.Timestamp) / tickRate, received: received}
+	if g, ok := s.pending[frame.Message]; ok && !g.has(rx) {
+		g.arrivals = append(g.arrivals, a)
+		return results
+	}
+
+	g := &group{msg: frame.Message, first: received, arrivals: []arrivalAt{a}}
+	s.pending[frame.Message] = g
+	s.queue = append(s.queue, g)
+
+	return results
+}
+
+// Flush processes all messages still waiting for copies.
+func (s *Solver) Flush() []Result {
+	return s.flush(time.Time{})
+}
+
+// flush processes the groups started before the cut off, all of them when it is zero.
+func (s *Solver) flush(cutoff time.Time) []Result {
+	var results []Result
+
+	n := 0
+	for ; n < len(s.queue); n++ {
+		g := s.queue[n]
+		if !cutoff.IsZero() && !g.first.Before(cutoff) {
+			break
+		}
+
+		if s.pending[g.msg] == g {
+			delete(s.pending, g.msg)
+		}
+		if res, ok := s.process(g); ok {
+			results = append(results, res)
+		}
+	}
+	s.queue = append(s.queue[:0], s.queue[n:]...)
+
+	return results
+}
+
+// useful reports whether a message can be used as a reference or multilaterated.
+func useful(msg string) bool {
+	if len(msg) != 14 && len(msg) != 28 {
+		return false
+	}
+
+	df, err := decode.Df(msg)
+	if err != nil {
+		return false
+	}
+
+	switch df {
+	case 0, 4, 5, 11, 16, 20, 21:
+		return true
+	case 17, 18:
+		tc, err := decode.Typecode(msg)
+		return err == nil && tc >= 9 && tc <= 18
+	}
+
+	return false
+}
+
+// process uses an ADS-B position message to synchronise clocks, and multilaterates any other message.
+func (s *Solver) process(g *group) (Result, bool) {
+	if len(g.arrivals) < 2 {
+		return Result{}, false
+	}
+
+	df, _ := decode.Df(g.msg)
+	if df == 17 || df == 18 {
+		s.synchronise(g)
+		return Result{}, false
+	}
+
+	return s.multilaterate(g, df)
+}
+
+// synchronise adds a sync point for every pair of receivers that heard the position message.
+func (s *Solver) synchronise(g *group) {
+	// a valid CRC is needed to trust the position
+	if rem, err := decode.Crc(g.msg); err != nil || rem != 0 {
+		return
+	}
+
+	base := s.receivers[g.arrivals[0].rx]
+	pos, err := decode.AirbornePositionWithRef(g.msg, base.Lat, base.Lon)
+	if err != nil {
+		return
+	}
+	alt, err := decode.Altitude(g.msg)
+	if err != nil || alt == 0 {
+		return
+	}
+
+	aircraft := geo.ToECEF(pos.Latitude, pos.Longitude, geo.FeetToMeters(float64(alt)))
+
+	// the time each receiver's clock showed when the message was transmitted
+	sent := make([]float64, len(g.arrivals))
+	for i, a := range g.arrivals {
+		sent[i] = a.t - aircraft.Sub(s.ecef[a.rx]).Norm()/speedOfLight
+	}
+
+	for i := range g.arrivals {
+		for j := range g.arrivals {
+			a, b := g.arrivals[i].rx, g.arrivals[j].rx
+			if a >= b {
+				continue
+			}
+			s.pair(a, b).add(sent[i], sent[j]-sent[i])
+		}
+	}
+}
+
+func (s *Solver) pair(a int, b int) *clockPair {
+	key := pairKey{a, b}
+	p, ok := s.pairs[key]
+	if !ok {
+		p = &clockPair{}
+		s.pairs[key] = p
+	}
+	return p
+}
+
+// toBase converts a time on rx's clock to the base receiver's clock.
+func (s *Solver) toBase(base int, rx int, t float64) (float64, bool) {
+	if rx == base {
+		return t, true
+	}
+
+	if base < rx {
+		// the pair tracks rx's offset from base, as a function of base's time
+		p, ok := s.pairs[pairKey{base, rx}]
+		if !ok {
+			return 0, false
+		}
+		offset, ok := p.offset(t)
+		if !ok {
+			return 0, false
+		}
+		// evaluate once more at the estimated base time, for drift
+		offset, _ = p.offset(t - offset)
+		return t - offset, true
+	}
+
+	p, ok := s.pairs[pairKey{rx, base}]
+	if !ok {
+		return 0, false
+	}
+	offset, ok := p.offset(t)
+	if !ok {
+		return 0, false
+	}
+	return t + offset, true
+}
+
+// Synced reports whether the clocks of two receivers are synchronised.
+func (s *Solver) Synced(a string, b string) bool {
+	i, ok1 := s.index[a]
+	j, ok2 := s.index[b]
+	if !ok1 || !ok2 {
+		return false
+	}
+	if i > j {
+		i, j = j, i
+	}
+
+	p, ok := s.pairs[pairKey{i, j}]
+	return ok && len(p.points) > 0
+}
+
+// multilaterate solves the position of a reply with the receivers synchronised to the one that has the most
+// synchronised peers among those that heard it.
+func (s *Solver) multilaterate(g *group, df int) (Result, bool) {
+	if df == 11 {
+		// the parity of all-call replies is checked, it may be overlaid with the interrogator code only
+		if rem, err := decode.Crc(g.msg); err != nil || rem&^0x7F != 0 {
+			return Result{}, false
+		}
+	}
+
+	icao, err := decode.Icao(g.msg)
+	if err != nil || icao == "" {
+		return Result{}, false
+	}
+
+	var arrivals []arrival
+	var baseArrival arrivalAt
+	for _, candidate := range g.arrivals {
+		var synced []arrival
+		for _, a := range g.arrivals {
+			t, ok := s.toBase(candidate.rx, a.rx, a.t)
+			if !ok {
+				continue
+			}
+			// copies further apart than the receivers can only be another transmission of the same message
+			if math.Abs(t-candidate.t)*speedOfLight > s.ecef[a.rx].Sub(s.ecef[candidate.rx]).Norm()+1000 {
+				continue
+			}
+
+			synced = append(synced, arrival{pos: s.ecef[a.rx], t: t})
+			if a.rx == candidate.rx {
+				// the base goes first
+				synced[0], synced[len(synced)-1] = synced[len(synced)-1], synced[0]
+			}
+		}
+		if len(synced) > len(arrivals) {
+			arrivals, baseArrival = synced, candidate
+		}
+	}
+
+	pr := problem{arrivals: arrivals}
+
+	alt := 0
+	switch df {
+	case 0, 4, 16, 20:
+		alt, _ = decode.AltitudeCode(g.msg)
+	}
+	if alt == 0 && s.opts.Altitude != nil {
+		alt, _ = s.opts.Altitude(icao)
+	}
+	if alt != 0 {
+		pr.alt, pr.hasAlt = geo.FeetToMeters(float64(alt)), true
+	}
+
+	need := 4
+	if pr.hasAlt {
+		need = 3
+	}
+	if len(arrivals) < need {
+		return Result{}, false
+	}
+
+	base := s.receivers[baseArrival.rx]
+	lat, lon, _, residual, ok := pr.solve(base.Lat, base.Lon)
+	if !ok || residual > maxResidual {
+		return Result{}, false
+	}
+	if geo.ToECEF(lat, lon, 0).Sub(geo.ToECEF(base.Lat, base.Lon, 0)).Norm() > maxRange {
+		return Result{}, false
+	}
+
+	return Result{
+		Icao:      icao,
+		Time:      baseArrival.received,
+		Position:  decode.Position{Latitude: lat, Longitude: lon},
+		Altitude:  alt,
+		Receivers: len(arrivals),
+		Error:     residual,
+	}, true
+}
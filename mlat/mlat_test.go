@@ -0,0 +1,222 @@
+package mlat
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/geo"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// testReceiver is a receiver with a clock that is off by offset seconds and runs drift too fast.
+type testReceiver struct {
+	Receiver
+	offset float64
+	drift  float64
+}
+
+var testReceivers = []testReceiver{
+	{Receiver{"a", 52.00, 4.40, 10}, 12.5, 1e-6},
+	{Receiver{"b", 52.25, 4.75, 0}, 1031.25, -2e-6},
+	{Receiver{"c", 51.85, 4.90, 50}, 0.001, 0},
+	{Receiver{"d", 52.15, 4.20, 5}, 77.7, 3e-7},
+}
+
+var start = time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+
+// receive returns the frame each receiver produces for a message sent at time t seconds from the position.
+func receive(rx testReceiver, msg string, t float64, lat float64, lon float64, altFt int) models.Frame {
+	arrival := t + geo.ToECEF(lat, lon, geo.FeetToMeters(float64(altFt))).Sub(geo.ToECEF(rx.Lat, rx.Lon, rx.Alt)).Norm()/speedOfLight
+	ticks := (arrival + rx.offset) * (1 + rx.drift) * tickRate
+
+	return models.Frame{
+		Message:   msg,
+		Timestamp: uint64(math.Round(ticks)),
+		Received:  start.Add(time.Duration(arrival * float64(time.Second))),
+	}
+}
+
+func withParity(prefix string, overlay int) string {
+	data, _ := hex.DecodeString(prefix + "000000")
+	return fmt.Sprintf("%s%06X", prefix, decode.CrcBytes(data)^overlay)
+}
+
+// cprNL is the number of longitude zones at a latitude.
+func cprNL(lat float64) float64 {
+	if math.Abs(lat) >= 87 {
+		return 1
+	}
+	a := 1 - math.Cos(math.Pi/30)
+	b := math.Pow(math.Cos(math.Pi/180*lat), 2)
+	return math.Floor(2 * math.Pi / math.Acos(1-a/b))
+}
+
+// airbornePosition encodes a DF17 airborne position message with a barometric altitude.
+func airbornePosition(icao string, lat float64, lon float64, altFt int, odd bool) string {
+	mod := func(x, y float64) float64 { return x - y*math.Floor(x/y) }
+
+	i := 0.0
+	if odd {
+		i = 1
+	}
+	dLat := 360 / (60 - i)
+	yz := math.Floor(131072*mod(lat, dLat)/dLat + 0.5)
+	rlat := dLat * (yz/131072 + math.Floor(lat/dLat))
+	dLon := 360 / math.Max(cprNL(rlat)-i, 1)
+	xz := math.Floor(131072*mod(lon, dLon)/dLon + 0.5)
+
+	n := (altFt + 1000) / 25
+	alt := uint64((n&0x7F0)<<1 | 0x10 | n&0xF)
+
+	me := uint64(11)<<51 | alt<<36 | uint64(i)<<34 | (uint64(yz)&0x1FFFF)<<17 | uint64(xz)&0x1FFFF
+	return withParity(fmt.Sprintf("8D%s%014X", icao, me), 0)
+}
+
+// surveillance encodes a DF4 altitude reply.
+func surveillance(icao string, altFt int) string {
+	n := (altFt + 1000) / 25
+	// the 13 bit AC field with M (bit 6) clear and Q (bit 4 from the end) set
+	ac := (n&0x7E0)<<2 | (n&0x10)<<1 | 0x10 | n&0xF
+	var addr int
+	fmt.Sscanf(icao, "%X", &addr)
+	return withParity(fmt.Sprintf("%08X", 4<<27|ac), addr)
+}
+
+// simulate feeds the solver a reference aircraft flying over the receivers and a target sending DF4 replies every
+// second, and returns the positions solved for the target.
+func simulate(s *Solver, receivers []testReceiver, targetLat float64, targetLon float64) []Result {
+	var results []Result
+	for step := 0; step < 40; step++ {
+		// reference position messages, alternating odd and even, twice a second
+		for k := 0; k < 2; k++ {
+			ts := float64(step) + float64(k)/2
+			lat, lon := 51.8+0.005*ts, 4.3+0.01*ts
+			msg := airbornePosition("4840D6", lat, lon, 37000, k == 1)
+			for _, rx := range receivers {
+				results = append(results, s.Add(rx.ID, receive(rx, msg, ts, lat, lon, 37000))...)
+			}
+		}
+
+		ts := float64(step) + 0.25
+		msg := surveillance("ABCDEF", 12000)
+		for _, rx := range receivers {
+			results = append(results, s.Add(rx.ID, receive(rx, msg, ts, targetLat, targetLon, 12000))...)
+		}
+	}
+
+	return append(results, s.Flush()...)
+}
+
+func TestSurveillanceEncoding(t *testing.T) {
+	msg := surveillance("ABCDEF", 12000)
+	if icao, _ := decode.Icao(msg); icao != "ABCDEF" {
+		t.Fatalf("Icao incorrect, wanted %v got %v", "ABCDEF", icao)
+	}
+	if alt, _ := decode.AltitudeCode(msg); alt != 12000 {
+		t.Fatalf("AltitudeCode incorrect, wanted %v got %v", 12000, alt)
+	}
+
+	ref := airbornePosition("4840D6", 52.1, 4.5, 37000, false)
+	if alt, _ := decode.Altitude(ref); alt != 37000 {
+		t.Fatalf("Altitude incorrect, wanted %v got %v", 37000, alt)
+	}
+	pos, err := decode.AirbornePositionWithRef(ref, 52, 4.4)
+	if err != nil || math.Abs(pos.Latitude-52.1) > 0.001 || math.Abs(pos.Longitude-4.5) > 0.001 {
+		t.Fatalf("AirbornePositionWithRef incorrect, wanted %v got %v (%v)", "52.1, 4.5", pos, err)
+	}
+}
+
+func TestSolver(t *testing.T) {
+	var receivers []Receiver
+	for _, rx := range testReceivers {
+		receivers = append(receivers, rx.Receiver)
+	}
+
+	tests := []struct {
+		name      string
+		receivers []testReceiver
+	}{
+		{"four receivers", testReceivers},
+		{"three receivers with altitude", testReceivers[:3]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(receivers, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results := simulate(s, tt.receivers, 52.05, 4.62)
+			if len(results) < 30 {
+				t.Fatalf("results incorrect, wanted at least %v got %v", 30, len(results))
+			}
+
+			for _, res := range results {
+				if res.Icao != "ABCDEF" {
+					t.Fatalf("Icao incorrect, wanted %v got %v", "ABCDEF", res.Icao)
+				}
+				if res.Altitude != 12000 {
+					t.Fatalf("Altitude incorrect, wanted %v got %v", 12000, res.Altitude)
+				}
+				if res.Receivers != len(tt.receivers) {
+					t.Fatalf("Receivers incorrect, wanted %v got %v", len(tt.receivers), res.Receivers)
+				}
+
+				miss := geo.ToECEF(res.Position.Latitude, res.Position.Longitude, 0).Sub(geo.ToECEF(52.05, 4.62, 0)).Norm()
+				if miss > 150 {
+					t.Fatalf("Position incorrect, wanted %v got %v (%.0f m off)", "52.05, 4.62", res.Position, miss)
+				}
+			}
+
+			if !s.Synced("a", "c") || !s.Synced("c", "a") {
+				t.Fatalf("Synced incorrect, wanted %v got %v", true, false)
+			}
+			if s.Synced("a", "x") {
+				t.Fatalf("Synced incorrect, wanted %v got %v", false, true)
+			}
+		})
+	}
+}
+
+func TestSolverNeedsSync(t *testing.T) {
+	var receivers []Receiver
+	for _, rx := range testReceivers {
+		receivers = append(receivers, rx.Receiver)
+	}
+
+	s, err := New(receivers, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without reference messages no clocks are synchronised
+	var results []Result
+	for step := 0; step < 5; step++ {
+		msg := surveillance("ABCDEF", 12000)
+		for _, rx := range testReceivers {
+			results = append(results, s.Add(rx.ID, receive(rx, msg, float64(step), 52.05, 4.62, 12000))...)
+		}
+	}
+	results = append(results, s.Flush()...)
+
+	if len(results) != 0 {
+		t.Fatalf("results incorrect, wanted %v got %v", 0, len(results))
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New([]Receiver{{ID: "a"}, {ID: "b"}}, Options{}); err == nil {
+		t.Fatalf("error incorrect, wanted an error for two receivers")
+	}
+	if _, err := New([]Receiver{{ID: "a"}, {ID: "b"}, {ID: "a"}}, Options{}); err == nil {
+		t.Fatalf("error incorrect, wanted an error for a duplicate receiver")
+	}
+	if _, err := New([]Receiver{{ID: "a"}, {ID: "b"}, {ID: "c", Lat: 91}}, Options{}); err == nil {
+		t.Fatalf("error incorrect, wanted an error for an invalid location")
+	}
+}
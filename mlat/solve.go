@@ -0,0 +1,169 @@
+package mlat
+
+import (
+	"math"
+
+	"github.com/pragmatic-zac/goModeS/geo"
+)
+
+const (
+	// maxIterations bounds the Levenberg-Marquardt iterations.
+	maxIterations = 100
+	// altitudeWeight scales the altitude constraint against the range differences, both in meters.
+	altitudeWeight = 1.0
+)
+
+// arrival is a receiver position and the time a message arrived there, on the base receiver's clock in seconds.
+type arrival struct {
+	pos geo.ECEF
+	t   float64
+}
+
+// problem is a set of arrivals of one message, the first being the base receiver, and optionally the height of the
+// aircraft above the ellipsoid in meters.
+type problem struct {
+	arrivals []arrival
+	alt      float64
+	hasAlt   bool
+}
+
+// residuals returns, for every receiver but the base, the difference between the range difference at p and the one
+// measured from the arrival times, followed by the altitude error. p is latitude and longitude in radians and the
+// height in meters.
+func (pr problem) residuals(p [3]float64) []float64 {
+	pos := geo.ToECEF(p[0]*180/math.Pi, p[1]*180/math.Pi, p[2])
+	base := pr.arrivals[0]
+	baseRange := pos.Sub(base.pos).Norm()
+
+	r := make([]float64, 0, len(pr.arrivals))
+	for _, a := range pr.arrivals[1:] {
+		r = append(r, pos.Sub(a.pos).Norm()-baseRange-speedOfLight*(a.t-base.t))
+	}
+	if pr.hasAlt {
+		r = append(r, (p[2]-pr.alt)*altitudeWeight)
+	}
+
+	return r
+}
+
+func sumSquares(r []float64) float64 {
+	s := 0.0
+	for _, v := range r {
+		s += v * v
+	}
+	return s
+}
+
+// solve finds the position that best explains the arrivals with Levenberg-Marquardt, starting from the given latitude
+// and longitude in degrees. It returns latitude and longitude in degrees, the height in meters and the RMS of the
+// residuals in meters.
+func (pr problem) solve(lat float64, lon float64) (float64, float64, float64, float64, bool) {
+	p := [3]float64{lat * math.Pi / 180, lon * math.Pi / 180, 10000}
+	if pr.hasAlt {
+		p[2] = pr.alt
+	}
+	steps := [3]float64{1e-7, 1e-7, 1}
+
+	r := pr.residuals(p)
+	if len(r) < 3 {
+		return 0, 0, 0, 0, false
+	}
+	cost := sumSquares(r)
+	mu := 1e-3
+
+	for i := 0; i < maxIterations; i++ {
+		// numeric Jacobian
+		var jac [3][]float64
+		for k := 0; k < 3; k++ {
+			q := p
+			q[k] += steps[k]
+			rq := pr.residuals(q)
+			jac[k] = make([]float64, len(r))
+			for j := range r {
+				jac[k][j] = (rq[j] - r[j]) / steps[k]
+			}
+		}
+
+		var a [3][3]float64
+		var g [3]float64
+		for k := 0; k < 3; k++ {
+			for l := 0; l < 3; l++ {
+				for j := range r {
+					a[k][l] += jac[k][j] * jac[l][j]
+				}
+			}
+			for j := range r {
+				g[k] -= jac[k][j] * r[j]
+			}
+		}
+
+		improved := false
+		for !improved && mu < 1e10 {
+			damped := a
+			for k := 0; k < 3; k++ {
+				damped[k][k] += mu * a[k][k]
+			}
+
+			delta, ok := solve3(damped, g)
+			if !ok {
+				return 0, 0, 0, 0, false
+			}
+
+			q := [3]float64{p[0] + delta[0], p[1] + delta[1], p[2] + delta[2]}
+			rq := pr.residuals(q)
+			if c := sumSquares(rq); c < cost {
+				converged := math.Abs(delta[0]) < 1e-10 && math.Abs(delta[1]) < 1e-10 && math.Abs(delta[2]) < 1e-3
+				p, r, cost = q, rq, c
+				mu /= 10
+				improved = true
+				if converged {
+					return pr.result(p, cost, len(r))
+				}
+			} else {
+				mu *= 10
+			}
+		}
+
+		if !improved {
+			// no step reduces the cost any more, this is the minimum
+			return pr.result(p, cost, len(r))
+		}
+	}
+
+	return 0, 0, 0, 0, false
+}
+
+func (pr problem) result(p [3]float64, cost float64, n int) (float64, float64, float64, float64, bool) {
+	lat := p[0] * 180 / math.Pi
+	lon := math.Mod(p[1]*180/math.Pi+540, 360) - 180
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return 0, 0, 0, 0, false
+	}
+
+	return lat, lon, p[2], math.Sqrt(cost / float64(n)), true
+}
+
+// solve3 solves the 3x3 linear system a x = b with Cramer's rule.
+func solve3(a [3][3]float64, b [3]float64) ([3]float64, bool) {
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+
+	d := det(a)
+	if d == 0 || math.IsNaN(d) {
+		return [3]float64{}, false
+	}
+
+	var x [3]float64
+	for k := 0; k < 3; k++ {
+		m := a
+		for i := 0; i < 3; i++ {
+			m[i][k] = b[i]
+		}
+		x[k] = det(m) / d
+	}
+
+	return x, true
+}
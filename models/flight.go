@@ -6,14 +6,55 @@ import (
 )
 
 type Flight struct {
-	Icao            string
-	Callsign        string
-	Altitude        int
-	Position        decode.Position
-	Velocity        decode.Velocity
-	LastSeen        time.Time
+	Icao             string
+	Callsign         string
+	Squawk           string
+	Altitude         int
+	OnGround         bool
+	Position         decode.Position
+	Velocity         decode.Velocity
+	FirstSeen        time.Time
+	LastSeen         time.Time
+	LastPositionTime time.Time
+	Messages         int
+	RSSI             float64
+	// Distance, in meters, and Bearing, in degrees from true north, locate the position relative to the receiver.
+	Distance float64
+	Bearing  float64
+	// Receivers are the names of the sources that heard the flight, sorted.
+	Receivers []string
+	// MLAT is set when the position was multilaterated rather than reported by the aircraft.
+	MLAT bool
+	// ModeACReplies is the number of Mode A/C replies matched to the flight by squawk or altitude.
+	ModeACReplies   int
+	Track           []TrackPoint
 	OddMessage      string
 	OddMessageTime  time.Time
 	EvenMessage     string
 	EvenMessageTime time.Time
+	// Registration, TypeCode, Manufacturer, Model, Operator and Military are looked up in an aircraft database when
+	// the flight is first heard, empty when unknown. Country, and the registration and military flag of some
+	// addresses, are known from the address itself.
+	Country      string
+	Registration string
+	TypeCode     string
+	Manufacturer string
+	Model        string
+	Operator     string
+	Military     bool
+	// Airline and Telephony are the operator named by the designator the callsign starts with, Origin and
+	// Destination the ICAO airports of the route flown under the callsign. Empty when unknown.
+	Airline     string
+	Telephony   string
+	Origin      string
+	Destination string
+}
+
+// TrackPoint is a single position in the recent history of a flight.
+type TrackPoint struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+	Altitude  int
+	OnGround  bool
 }
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Frame is a single Mode S or Mode A/C reply as delivered by a receiver, together with its reception metadata.
+type Frame struct {
+	// Message is the reply as an upper case hexadecimal string (4, 14 or 28 characters).
+	Message string
+	// Timestamp is the receiver's 12 MHz MLAT counter value, zero when the source does not provide one.
+	Timestamp uint64
+	// RSSI is the received signal strength in dBFS, zero when the source does not provide one.
+	RSSI float64
+	// Received is the wall clock time the frame was read from the source.
+	Received time.Time
+	// Receiver is the name of the source the frame was read from, empty when unknown.
+	Receiver string
+}
@@ -0,0 +1,295 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// stableAfter is how long a connection must stay up for the backoff to start over. Successful writes prove nothing, the
+// kernel accepts them even when the aggregator already closed the connection.
+const stableAfter = 10 * time.Second
+
+// ConnState is the connection state of a Feeder.
+type ConnState int
+
+const (
+	// Disconnected means the feeder is waiting to retry.
+	Disconnected ConnState = iota
+	// Connecting means a connection attempt is in progress.
+	Connecting
+	// Connected means frames are being sent.
+	Connected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	default:
+		return "unknown"
+	}
+}
+
+// FeederOptions configures a Feeder.
+type FeederOptions struct {
+	// Addr is the host:port of the aggregator.
+	Addr string
+	// Mode is the format to send, "beast" or "raw".
+	Mode string
+	// Buffer is the number of frames kept while disconnected. When it is full the oldest frames are dropped.
+	// Defaults to 10000.
+	Buffer int
+	// MinBackoff and MaxBackoff bound the delay between connection attempts, which doubles after every failure.
+	// Default to 1 second and 1 minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// FeederStatus is a snapshot of a Feeder's connection.
+type FeederStatus struct {
+	Addr  string
+	State ConnState
+	// Since is when the feeder entered the state.
+	Since time.Time
+	// LastError is the reason of the last disconnect or failed attempt, if any.
+	LastError error
+	// Sent is the number of frames written to the aggregator, Dropped the number lost to a full buffer.
+	Sent    uint64
+	Dropped uint64
+	// Queued is the number of frames waiting to be sent.
+	Queued int
+}
+
+func (s FeederStatus) String() string {
+	str := fmt.Sprintf("%s %s since %s, %d sent, %d dropped, %d queued", s.Addr, s.State, s.Since.Format("15:04:05"), s.Sent, s.Dropped, s.Queued)
+	if s.State != Connected && s.LastError != nil {
+		str += ": " + s.LastError.Error()
+	}
+	return str
+}
+
+// Feeder sends frames to a remote aggregator over TCP, reconnecting with exponential backoff whenever the connection
+// fails. Frames written while disconnected are buffered up to a limit.
+type Feeder struct {
+	opts       FeederOptions
+	newEncoder NewEncoder
+
+	mu     sync.Mutex
+	queue  []models.Frame
+	ready  chan struct{}
+	status FeederStatus
+}
+
+// NewFeeder returns a Feeder for the options. Call Run to start connecting.
+func NewFeeder(opts FeederOptions) (*Feeder, error) {
+	if opts.Mode != "beast" && opts.Mode != "raw" {
+		return nil, fmt.Errorf("unsupported feed format %q", opts.Mode)
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 10000
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = time.Minute
+		if opts.MaxBackoff < opts.MinBackoff {
+			opts.MaxBackoff = opts.MinBackoff
+		}
+	}
+
+	enc, err := Encoder(opts.Mode, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Feeder{
+		opts:       opts,
+		newEncoder: enc,
+		ready:      make(chan struct{}, 1),
+		status:     FeederStatus{Addr: opts.Addr, State: Disconnected, Since: time.Now()},
+	}, nil
+}
+
+// WriteFrame queues a frame for sending. It never blocks; when the buffer is full the oldest frame is dropped.
+func (f *Feeder) WriteFrame(frame models.Frame) error {
+	f.mu.Lock()
+	if len(f.queue) >= f.opts.Buffer {
+		f.queue = f.queue[1:]
+		f.status.Dropped++
+	}
+	f.queue = append(f.queue, frame)
+	f.mu.Unlock()
+
+	select {
+	case f.ready <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Status returns the current connection status.
+func (f *Feeder) Status() FeederStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	s := f.status
+	s.Queued = len(f.queue)
+	return s
+}
+
+func (f *Feeder) setState(state ConnState, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.status.State = state
+	f.status.Since = time.Now()
+	if err != nil {
+		f.status.LastError = err
+	}
+}
+
+// Run connects and sends frames until ctx is cancelled.
+func (f *Feeder) Run(ctx context.Context) {
+	var dialer net.Dialer
+	backoff := f.opts.MinBackoff
+
+	for {
+		f.setState(Connecting, nil)
+
+		conn, err := dialer.DialContext(ctx, "tcp", f.opts.Addr)
+		if err == nil {
+			f.setState(Connected, nil)
+			opened := time.Now()
+
+			err = f.send(ctx, conn)
+			conn.Close()
+			// an aggregator that accepts and drops the connection straight away is backed off like one that refuses it
+			if time.Since(opened) >= stableAfter {
+				backoff = f.opts.MinBackoff
+			}
+		}
+
+		if ctx.Err() != nil {
+			f.setState(Disconnected, nil)
+			return
+		}
+		f.setState(Disconnected, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > f.opts.MaxBackoff {
+			backoff = f.opts.MaxBackoff
+		}
+	}
+}
+
+// take removes and returns all queued frames.
+func (f *Feeder) take() []models.Frame {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	frames := f.queue
+	f.queue = nil
+	return frames
+}
+
+// requeue puts frames that could not be sent back in front of the queue, as far as the buffer allows.
+func (f *Feeder) requeue(frames []models.Frame) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queue := append(frames, f.queue...)
+	if len(queue) > f.opts.Buffer {
+		f.status.Dropped += uint64(len(queue) - f.opts.Buffer)
+		queue = queue[len(queue)-f.opts.Buffer:]
+	}
+	f.queue = queue
+}
+
+// send writes queued frames to the connection until it fails or ctx is cancelled.
+func (f *Feeder) send(ctx context.Context, conn net.Conn) error {
+	// aggregators rarely send anything, but reading notices a closed connection without waiting for the next write
+	closed := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, conn)
+		if err == nil {
+			err = io.EOF
+		}
+		closed <- err
+	}()
+
+	var buf bytes.Buffer
+	enc := f.newEncoder(&buf)
+	// ends holds the end offset of every frame of a batch in buf
+	var ends []int
+
+	for {
+		frames := f.take()
+		if len(frames) == 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case err := <-closed:
+				return fmt.Errorf("connection closed: %w", err)
+			case <-f.ready:
+				continue
+			}
+		}
+
+		buf.Reset()
+		ends = ends[:0]
+		for _, frame := range frames {
+			n := buf.Len()
+			if err := enc.WriteFrame(frame); err != nil {
+				// not representable in the format, skip it
+				buf.Truncate(n)
+			}
+			ends = append(ends, buf.Len())
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+		n, err := conn.Write(buf.Bytes())
+
+		// only frames written completely count as sent, the rest is sent again after reconnecting
+		written, sent, prev := 0, 0, 0
+		for _, end := range ends {
+			if end > n {
+				break
+			}
+			if end > prev {
+				sent++
+			}
+			written++
+			prev = end
+		}
+		if err != nil {
+			f.requeue(frames[written:])
+		}
+
+		f.mu.Lock()
+		f.status.Sent += uint64(sent)
+		f.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+}
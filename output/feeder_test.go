@@ -0,0 +1,230 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func feederOptions(addr string) FeederOptions {
+	return FeederOptions{Addr: addr, Mode: "raw", Buffer: 3, MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+}
+
+func frame(i int) models.Frame {
+	return models.Frame{Message: "8D4840D6202CC371C32CE0576098", Timestamp: uint64(i)}
+}
+
+func readLines(t *testing.T, r *bufio.Reader, want ...string) {
+	t.Helper()
+
+	for _, w := range want {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if line != w {
+			t.Fatalf("line incorrect, wanted %q got %q", w, line)
+		}
+	}
+}
+
+func TestFeederReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	f, err := NewFeeder(feederOptions(ln.Addr().String()))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	waitFor(t, "connected", func() bool { return f.Status().State == Connected })
+
+	f.WriteFrame(frame(1))
+	readLines(t, bufio.NewReader(conn), "@0000000000018D4840D6202CC371C32CE0576098;\n")
+
+	// the aggregator goes away, the feeder must notice and come back
+	conn.Close()
+	waitFor(t, "sent count", func() bool { return f.Status().Sent == 1 })
+
+	conn, err = ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+	waitFor(t, "reconnected", func() bool { return f.Status().State == Connected })
+
+	f.WriteFrame(frame(2))
+	readLines(t, bufio.NewReader(conn), "@0000000000028D4840D6202CC371C32CE0576098;\n")
+}
+
+func TestFeederBackoffAfterDroppedConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	f, err := NewFeeder(feederOptions(ln.Addr().String()))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	// the aggregator accepts and closes at once, so the delays must grow to 10, 20 and 40 ms
+	var accepted []time.Time
+	for i := 0; i < 4; i++ {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("accept: %v", err)
+		}
+		conn.Close()
+		accepted = append(accepted, time.Now())
+	}
+
+	if gap := accepted[3].Sub(accepted[2]); gap < 35*time.Millisecond {
+		t.Fatalf("delay incorrect, wanted about %v got %v", 40*time.Millisecond, gap)
+	}
+}
+
+func TestFeederBuffersWhileDown(t *testing.T) {
+	// find a free port, nothing listens on it until later
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	f, err := NewFeeder(feederOptions(addr))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	for i := 1; i <= 5; i++ {
+		f.WriteFrame(frame(i))
+	}
+
+	waitFor(t, "failed attempt", func() bool {
+		s := f.Status()
+		return s.State == Disconnected && s.LastError != nil
+	})
+
+	s := f.Status()
+	if s.Queued != 3 || s.Dropped != 2 {
+		t.Fatalf("buffer incorrect, wanted 3 queued and 2 dropped got %v and %v", s.Queued, s.Dropped)
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("port was taken in the meantime: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+
+	// the oldest frames were dropped
+	readLines(t, bufio.NewReader(conn),
+		"@0000000000038D4840D6202CC371C32CE0576098;\n",
+		"@0000000000048D4840D6202CC371C32CE0576098;\n",
+		"@0000000000058D4840D6202CC371C32CE0576098;\n",
+	)
+}
+
+func TestFeederRejectsFormat(t *testing.T) {
+	if _, err := NewFeeder(FeederOptions{Addr: "localhost:1", Mode: "sbs"}); err == nil {
+		t.Fatalf("expected error for sbs feed")
+	}
+}
+
+func TestFeederRequeuesUnsentFrames(t *testing.T) {
+	f, err := NewFeeder(feederOptions("unused"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		f.WriteFrame(frame(i))
+	}
+
+	// the aggregator reads the first frame and part of the second, then goes away
+	client, conn := net.Pipe()
+	go func() {
+		first := "@0000000000018D4840D6202CC371C32CE0576098;\n"
+		io.ReadFull(client, make([]byte, len(first)+10))
+		client.Close()
+	}()
+
+	if err := f.send(context.Background(), conn); err == nil {
+		t.Fatalf("expected error for closed connection")
+	}
+
+	s := f.Status()
+	if s.Sent != 1 || s.Queued != 2 {
+		t.Fatalf("status incorrect, wanted 1 sent and 2 queued got %v and %v", s.Sent, s.Queued)
+	}
+	if queued := f.take(); queued[0].Timestamp != 2 || queued[1].Timestamp != 3 {
+		t.Fatalf("queued frames incorrect, wanted 2 and 3 got %v and %v", queued[0].Timestamp, queued[1].Timestamp)
+	}
+}
+
+func TestFeederCountsSentFrames(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	opts := feederOptions(ln.Addr().String())
+	opts.Mode = "beast"
+	f, err := NewFeeder(opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the middle frame has no beast encoding and is skipped
+	f.WriteFrame(frame(1))
+	f.WriteFrame(models.Frame{Message: "not hex"})
+	f.WriteFrame(frame(3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+
+	// all three frames go out in one batch
+	waitFor(t, "batch to be sent", func() bool { return f.Status().Sent > 0 })
+	if s := f.Status(); s.Sent != 2 {
+		t.Fatalf("sent incorrect, wanted %v got %v", 2, s.Sent)
+	}
+}
@@ -0,0 +1,209 @@
+// Package output re-broadcasts received frames to other programs, either by accepting client connections the way
+// dump1090's network output ports do, or by feeding a remote aggregator.
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// clientQueue is the number of encoded frames queued per client. A client that falls this far behind is disconnected
+// rather than slowing everybody else down.
+const clientQueue = 4096
+
+// clientWriteTimeout bounds a single write to a client, so a client that stops reading cannot hold its connection open
+// forever.
+const clientWriteTimeout = 10 * time.Second
+
+// NewEncoder returns a frame writer for the output format, writing to w.
+type NewEncoder func(w io.Writer) formats.FrameWriter
+
+// Encoder returns a NewEncoder for the named format, "raw", "beast" or "sbs".
+func Encoder(mode string, latRef float64, lonRef float64) (NewEncoder, error) {
+	if _, err := formats.NewWriter(mode, io.Discard, latRef, lonRef); err != nil {
+		return nil, err
+	}
+
+	return func(w io.Writer) formats.FrameWriter {
+		fw, _ := formats.NewWriter(mode, w, latRef, lonRef)
+		return fw
+	}, nil
+}
+
+// Server accepts TCP clients and sends every frame written to it to all of them.
+//
+// Frames are encoded once and queued per client, so writing a frame never blocks on the network.
+type Server struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	encoder formats.FrameWriter
+	clients map[*client]struct{}
+
+	dropped uint64
+}
+
+type client struct {
+	conn  net.Conn
+	queue chan []byte
+	once  sync.Once
+}
+
+// Listen returns a Server listening on the TCP address. Call Serve to start accepting clients.
+func Listen(addr string, newEncoder NewEncoder) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{ln: ln, clients: make(map[*client]struct{})}
+	s.encoder = newEncoder(&s.buf)
+
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.ln.Addr()
+}
+
+// Serve accepts clients until ctx is cancelled, then disconnects all of them.
+func (s *Server) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.ln.Close()
+	}()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			s.closeAll()
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		c := &client{conn: conn, queue: make(chan []byte, clientQueue)}
+
+		s.mu.Lock()
+		s.clients[c] = struct{}{}
+		s.mu.Unlock()
+
+		go s.writeLoop(c)
+		go s.readLoop(c)
+	}
+}
+
+// WriteFrame queues the frame for every connected client. Frames without a representation in the output format are
+// skipped.
+func (s *Server) WriteFrame(frame models.Frame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.clients) == 0 {
+		return nil
+	}
+
+	s.buf.Reset()
+	if err := s.encoder.WriteFrame(frame); err != nil {
+		return err
+	}
+	if s.buf.Len() == 0 {
+		return nil
+	}
+
+	// every client gets the same slice, it is never modified after this
+	data := append([]byte(nil), s.buf.Bytes()...)
+	for c := range s.clients {
+		select {
+		case c.queue <- data:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+			s.remove(c)
+			// writeLoop may be blocked writing to the client, closing the connection unblocks it
+			c.conn.Close()
+		}
+	}
+
+	return nil
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.clients)
+}
+
+// Dropped returns the number of clients that were disconnected for being too slow.
+func (s *Server) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// remove must be called with the lock held.
+func (s *Server) remove(c *client) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+
+	delete(s.clients, c)
+	c.once.Do(func() { close(c.queue) })
+}
+
+func (s *Server) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.clients {
+		s.remove(c)
+	}
+}
+
+// writeLoop sends the client's queue, flushing whenever it runs empty.
+func (s *Server) writeLoop(c *client) {
+	defer c.conn.Close()
+
+	w := bufio.NewWriter(c.conn)
+	for data := range c.queue {
+		c.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if _, err := w.Write(data); err != nil {
+			break
+		}
+		if len(c.queue) == 0 {
+			if err := w.Flush(); err != nil {
+				break
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.remove(c)
+	s.mu.Unlock()
+
+	// let WriteFrame's pending sends drain, the queue is closed by now
+	for range c.queue {
+	}
+}
+
+// readLoop discards anything the client sends, so that a disconnect is noticed even while no frames are written.
+func (s *Server) readLoop(c *client) {
+	io.Copy(io.Discard, c.conn)
+	c.conn.Close()
+
+	s.mu.Lock()
+	s.remove(c)
+	s.mu.Unlock()
+}
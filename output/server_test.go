@@ -0,0 +1,171 @@
+package output
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func startServer(t *testing.T, mode string) (*Server, context.CancelFunc) {
+	t.Helper()
+
+	enc, err := Encoder(mode, 52.0, 4.0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	s, err := Listen("127.0.0.1:0", enc)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Serve(ctx)
+
+	return s, cancel
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServerFanOut(t *testing.T) {
+	s, cancel := startServer(t, "raw")
+	defer cancel()
+
+	var readers []*bufio.Reader
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		readers = append(readers, bufio.NewReader(conn))
+	}
+	waitFor(t, "clients", func() bool { return s.Clients() == 3 })
+
+	s.WriteFrame(models.Frame{Message: "8D4840D6202CC371C32CE0576098"})
+	s.WriteFrame(models.Frame{Message: "5D4840D6000000", Timestamp: 0x123456})
+
+	for i, r := range readers {
+		for _, want := range []string{"*8D4840D6202CC371C32CE0576098;\n", "@0000001234565D4840D6000000;\n"} {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("client %v: %v", i, err)
+			}
+			if line != want {
+				t.Fatalf("client %v line incorrect, wanted %q got %q", i, want, line)
+			}
+		}
+	}
+}
+
+func TestServerSkipsUnsupported(t *testing.T) {
+	s, cancel := startServer(t, "sbs")
+	defer cancel()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	waitFor(t, "client", func() bool { return s.Clients() == 1 })
+
+	// Mode A/C has no BaseStation representation
+	s.WriteFrame(models.Frame{Message: "1234"})
+	s.WriteFrame(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)})
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := "MSG,1,1,1,4840D6,1,2023/01/02,10:00:00.000,2023/01/02,10:00:00.000,KLM1023,,,,,,,,,,,\r\n"
+	if line != want {
+		t.Fatalf("line incorrect, wanted %q got %q", want, line)
+	}
+}
+
+func TestServerDropsSlowClient(t *testing.T) {
+	s, cancel := startServer(t, "beast")
+	defer cancel()
+
+	fast, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer fast.Close()
+
+	var received int64
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := fast.Read(buf)
+			if err != nil {
+				return
+			}
+			atomic.AddInt64(&received, int64(n))
+		}
+	}()
+
+	goroutines := runtime.NumGoroutine()
+	slow, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer slow.Close()
+	waitFor(t, "clients", func() bool { return s.Clients() == 2 })
+
+	// the slow client never reads, so once the socket buffers and its queue are full it must be dropped. Frames are
+	// written in batches the fast client keeps up with, it must stay connected.
+	frame := models.Frame{Message: "8D4840D6202CC371C32CE0576098", Timestamp: 1}
+	const frameLen, batch = 23, 1000
+
+	var sent int64
+	for i := 0; i < 10000 && s.Dropped() == 0; i++ {
+		for j := 0; j < batch; j++ {
+			s.WriteFrame(frame)
+		}
+		sent += batch * frameLen
+		waitFor(t, "fast client", func() bool { return atomic.LoadInt64(&received) == sent })
+	}
+
+	if s.Dropped() != 1 {
+		t.Fatalf("dropped clients incorrect, wanted %v got %v", 1, s.Dropped())
+	}
+	waitFor(t, "slow client to be removed", func() bool { return s.Clients() == 1 })
+
+	// the slow client still isn't reading, its connection must be closed for the server's goroutines to finish
+	waitFor(t, "slow client's goroutines", func() bool { return runtime.NumGoroutine() <= goroutines })
+}
+
+func TestServerShutdown(t *testing.T) {
+	s, cancel := startServer(t, "raw")
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	waitFor(t, "client", func() bool { return s.Clients() == 1 })
+
+	cancel()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("expected the connection to be closed")
+	}
+}
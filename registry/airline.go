@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"strings"
+)
+
+// Airline is an aircraft operator with an ICAO three letter designator.
+type Airline struct {
+	Designator string
+	Name       string
+	// Telephony is the callsign used on the radio, e.g. SPEEDBIRD for BAW.
+	Telephony string
+}
+
+// airlines are the designators of common operators, from ICAO Doc 8585.
+var airlines = map[string]Airline{}
+
+func init() {
+	for _, a := range []Airline{
+		{"AAL", "American Airlines", "AMERICAN"},
+		{"AAR", "Asiana Airlines", "ASIANA"},
+		{"ACA", "Air Canada", "AIR CANADA"},
+		{"AEE", "Aegean Airlines", "AEGEAN"},
+		{"AFL", "Aeroflot", "AEROFLOT"},
+		{"AFR", "Air France", "AIRFRANS"},
+		{"AIC", "Air India", "AIRINDIA"},
+		{"AMX", "Aeromexico", "AEROMEXICO"},
+		{"ANA", "All Nippon Airways", "ALL NIPPON"},
+		{"ANZ", "Air New Zealand", "NEW ZEALAND"},
+		{"ASA", "Alaska Airlines", "ALASKA"},
+		{"AUA", "Austrian Airlines", "AUSTRIAN"},
+		{"AVA", "Avianca", "AVIANCA"},
+		{"AZU", "Azul Linhas Aereas", "AZUL"},
+		{"BAW", "British Airways", "SPEEDBIRD"},
+		{"BCS", "European Air Transport", "EUROTRANS"},
+		{"BEL", "Brussels Airlines", "BEE-LINE"},
+		{"BOX", "AeroLogic", "GERMAN CARGO"},
+		{"BTI", "airBaltic", "AIR BALTIC"},
+		{"CAL", "China Airlines", "DYNASTY"},
+		{"CCA", "Air China", "AIR CHINA"},
+		{"CES", "China Eastern Airlines", "CHINA EASTERN"},
+		{"CFG", "Condor", "CONDOR"},
+		{"CLX", "Cargolux", "CARGOLUX"},
+		{"CMP", "Copa Airlines", "COPA"},
+		{"CPA", "Cathay Pacific", "CATHAY"},
+		{"CSA", "Czech Airlines", "CSA"},
+		{"CSN", "China Southern Airlines", "CHINA SOUTHERN"},
+		{"DAL", "Delta Air Lines", "DELTA"},
+		{"DLH", "Lufthansa", "LUFTHANSA"},
+		{"EIN", "Aer Lingus", "SHAMROCK"},
+		{"EJA", "NetJets", "EXECJET"},
+		{"EJU", "easyJet Europe", "ALPINE"},
+		{"ELY", "El Al", "ELAL"},
+		{"ENY", "Envoy Air", "ENVOY"},
+		{"ETD", "Etihad Airways", "ETIHAD"},
+		{"ETH", "Ethiopian Airlines", "ETHIOPIAN"},
+		{"EVA", "EVA Air", "EVA"},
+		{"EWG", "Eurowings", "EUROWINGS"},
+		{"EXS", "Jet2", "CHANNEX"},
+		{"EZS", "easyJet Switzerland", "TOPSWISS"},
+		{"EZY", "easyJet", "EASY"},
+		{"FDX", "FedEx", "FEDEX"},
+		{"FFT", "Frontier Airlines", "FRONTIER FLIGHT"},
+		{"FIN", "Finnair", "FINNAIR"},
+		{"GAF", "German Air Force", "GERMAN AIR FORCE"},
+		{"GIA", "Garuda Indonesia", "INDONESIA"},
+		{"GLO", "Gol Linhas Aereas", "GOL TRANSPORTE"},
+		{"GTI", "Atlas Air", "GIANT"},
+		{"HVN", "Vietnam Airlines", "VIET NAM AIRLINES"},
+		{"IBE", "Iberia", "IBERIA"},
+		{"ICE", "Icelandair", "ICEAIR"},
+		{"IGO", "IndiGo", "IFLY"},
+		{"JAL", "Japan Airlines", "JAPANAIR"},
+		{"JBU", "JetBlue Airways", "JETBLUE"},
+		{"JZA", "Jazz Aviation", "JAZZ"},
+		{"KAL", "Korean Air", "KOREANAIR"},
+		{"KLM", "KLM Royal Dutch Airlines", "KLM"},
+		{"KZR", "Air Astana", "ASTANALINE"},
+		{"LGL", "Luxair", "LUXAIR"},
+		{"LOT", "LOT Polish Airlines", "POLLOT"},
+		{"MAS", "Malaysia Airlines", "MALAYSIAN"},
+		{"MSR", "EgyptAir", "EGYPTAIR"},
+		{"NAX", "Norwegian Air Shuttle", "NOR SHUTTLE"},
+		{"NJE", "NetJets Europe", "FRACTION"},
+		{"NKS", "Spirit Airlines", "SPIRIT WINGS"},
+		{"PGT", "Pegasus Airlines", "SUNTURK"},
+		{"PIA", "Pakistan International Airlines", "PAKISTAN"},
+		{"QFA", "Qantas", "QANTAS"},
+		{"QTR", "Qatar Airways", "QATARI"},
+		{"RAM", "Royal Air Maroc", "ROYALAIR MAROC"},
+		{"RCH", "US Air Mobility Command", "REACH"},
+		{"ROT", "TAROM", "TAROM"},
+		{"RPA", "Republic Airways", "BRICKYARD"},
+		{"RYR", "Ryanair", "RYANAIR"},
+		{"SAA", "South African Airways", "SPRINGBOK"},
+		{"SAS", "Scandinavian Airlines", "SCANDINAVIAN"},
+		{"SIA", "Singapore Airlines", "SINGAPORE"},
+		{"SKW", "SkyWest Airlines", "SKYWEST"},
+		{"SVA", "Saudia", "SAUDIA"},
+		{"SWA", "Southwest Airlines", "SOUTHWEST"},
+		{"SWR", "Swiss International Air Lines", "SWISS"},
+		{"SXS", "SunExpress", "SUNEXPRESS"},
+		{"TAP", "TAP Air Portugal", "AIR PORTUGAL"},
+		{"THA", "Thai Airways", "THAI"},
+		{"THY", "Turkish Airlines", "TURKISH"},
+		{"TOM", "TUI Airways", "TOM JET"},
+		{"TRA", "Transavia", "TRANSAVIA"},
+		{"TVF", "Transavia France", "FRANCE SOLEIL"},
+		{"UAE", "Emirates", "EMIRATES"},
+		{"UAL", "United Airlines", "UNITED"},
+		{"UPS", "UPS Airlines", "UPS"},
+		{"VIR", "Virgin Atlantic", "VIRGIN"},
+		{"VLG", "Vueling", "VUELING"},
+		{"VOI", "Volaris", "VOLARIS"},
+		{"WJA", "WestJet", "WESTJET"},
+		{"WZZ", "Wizz Air", "WIZZ AIR"},
+	} {
+		airlines[a.Designator] = a
+	}
+}
+
+// designator returns the airline designator of a callsign made of one followed by a flight number, such as KLM1023.
+// Callsigns that are a registration have none.
+func designator(callsign string) (string, bool) {
+	callsign = strings.ToUpper(strings.TrimRight(callsign, " #_"))
+	if len(callsign) < 4 {
+		return "", false
+	}
+
+	for i := 0; i < 3; i++ {
+		if callsign[i] < 'A' || callsign[i] > 'Z' {
+			return "", false
+		}
+	}
+	if callsign[3] < '0' || callsign[3] > '9' {
+		return "", false
+	}
+
+	return callsign[:3], true
+}
+
+// LookupAirline returns the operator of a flight from the designator its callsign starts with, e.g. KLM for KLM1023.
+func LookupAirline(callsign string) (Airline, bool) {
+	d, ok := designator(callsign)
+	if !ok {
+		return Airline{}, false
+	}
+
+	a, ok := airlines[d]
+	return a, ok
+}
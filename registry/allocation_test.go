@@ -0,0 +1,77 @@
+package registry
+
+import "testing"
+
+func TestCountry(t *testing.T) {
+	tests := []struct {
+		icao string
+		want string
+	}{
+		{"4840D6", "Netherlands"},
+		{"40621D", "United Kingdom"},
+		{"a061d9", "United States"},
+		{"789123", "Hong Kong"},
+		{"780123", "China"},
+		{"C044A9", "Canada"},
+		{"7C6B2D", "Australia"},
+	}
+
+	for _, test := range tests {
+		if got, ok := Country(test.icao); !ok || got != test.want {
+			t.Fatalf("%v: Country incorrect, wanted %v got %v", test.icao, test.want, got)
+		}
+	}
+
+	for _, icao := range []string{"000001", "F0FFFF", "XYZ123", "12345"} {
+		if got, ok := Country(icao); ok {
+			t.Fatalf("%v: Country incorrect, wanted none got %v", icao, got)
+		}
+	}
+}
+
+func TestMilitary(t *testing.T) {
+	tests := []struct {
+		icao string
+		want bool
+	}{
+		{"AE1234", true},
+		{"43C6E1", true},
+		{"4840D6", false},
+		{"A061D9", false},
+	}
+
+	for _, test := range tests {
+		if got := Military(test.icao); got != test.want {
+			t.Fatalf("%v: Military incorrect, wanted %v got %v", test.icao, test.want, got)
+		}
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	tests := []struct {
+		icao string
+		want string
+	}{
+		{"A00001", "N1"},
+		{"A00002", "N1A"},
+		{"A00003", "N1AA"},
+		{"A0025A", "N10"},
+		{"A061D9", "N12345"},
+		{"ADF7C7", "N99999"},
+		{"C00001", "C-FAAA"},
+		{"C044A8", "C-FZZZ"},
+		{"C044A9", "C-GAAA"},
+	}
+
+	for _, test := range tests {
+		if got, ok := Registration(test.icao); !ok || got != test.want {
+			t.Fatalf("%v: Registration incorrect, wanted %v got %v", test.icao, test.want, got)
+		}
+	}
+
+	for _, icao := range []string{"A00000", "ADF7C8", "C08951", "4840D6"} {
+		if got, ok := Registration(icao); ok {
+			t.Fatalf("%v: Registration incorrect, wanted none got %v", icao, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Import merges a downloaded aircraft database into db and returns the number of aircraft read. The format is detected
+// from the first line:
+//   - OpenSky Network's aircraftDatabase.csv, with a header naming icao24, registration, typecode and so on.
+//   - tar1090-db's aircraft.csv, semicolon separated icao;registration;type;flags;description;year;owner without a
+//     header. The first flag marks military aircraft.
+//   - A database written by Write.
+//
+// Any of them may be gzip compressed. Later imports take precedence over earlier ones for the fields they know.
+func (db *DB) Import(r io.Reader) (int, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return 0, err
+	}
+
+	br := bufio.NewReader(r)
+	first, err := br.Peek(256)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return 0, err
+	}
+	line := string(first)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(line, strings.Join(header, ",")):
+		other, err := Read(br)
+		if err != nil {
+			return 0, err
+		}
+		for _, a := range other.aircraft {
+			db.Add(a)
+		}
+		return other.Len(), nil
+	case strings.Contains(line, "icao24"):
+		return db.importOpenSky(br)
+	case strings.Contains(line, ";"):
+		return db.importTar1090(br)
+	}
+
+	return 0, errors.New("unknown aircraft database format")
+}
+
+func (db *DB) importOpenSky(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	names, err := cr.Read()
+	if err != nil {
+		return 0, err
+	}
+	columns := make(map[string]int)
+	for i, name := range names {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["icao24"]; !ok {
+		return 0, errors.New("OpenSky database without an icao24 column")
+	}
+
+	n := 0
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+
+		operator := field("operator")
+		if operator == "" {
+			operator = field("owner")
+		}
+
+		a := Aircraft{
+			Icao:         field("icao24"),
+			Registration: field("registration"),
+			TypeCode:     field("typecode"),
+			Manufacturer: field("manufacturername"),
+			Model:        field("model"),
+			Operator:     operator,
+		}
+		// OpenSky does not know which aircraft are military, keep what an earlier import said
+		if cur, ok := db.Lookup(a.Icao); ok {
+			a.Military = cur.Military
+		}
+		if validIcao(strings.ToUpper(a.Icao)) {
+			db.Add(a)
+			n++
+		}
+	}
+}
+
+func (db *DB) importTar1090(r io.Reader) (int, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	n := 0
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+
+		field := func(i int) string {
+			if i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+
+		a := Aircraft{
+			Icao:         field(0),
+			Registration: field(1),
+			TypeCode:     field(2),
+			Model:        field(4),
+			Operator:     field(6),
+			Military:     strings.HasPrefix(field(3), "1"),
+		}
+		if validIcao(strings.ToUpper(a.Icao)) {
+			db.Add(a)
+			n++
+		}
+	}
+}
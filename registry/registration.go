@@ -0,0 +1,104 @@
+package registry
+
+// Some states derive the 24 bit address from the registration, so the registration can be computed back from the
+// address without a database.
+
+const (
+	// nLetters are the letters used in US registrations, I and O are left out as they look like digits.
+	nLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	nDigits  = "0123456789"
+
+	// nSuffixSize is the number of registrations ending in zero to two letters after a given digit: none, one
+	// letter, or a letter followed by a second letter.
+	nSuffixSize = 1 + len(nLetters)*(1+len(nLetters))
+	// nBucket4 to nBucket1 are the number of registrations that share the first four to one digits.
+	nBucket4 = 1 + len(nLetters) + len(nDigits)
+	nBucket3 = len(nDigits)*nBucket4 + nSuffixSize
+	nBucket2 = len(nDigits)*nBucket3 + nSuffixSize
+	nBucket1 = len(nDigits)*nBucket2 + nSuffixSize
+
+	nFirst = 0xA00001
+	nLast  = 0xADF7C7
+)
+
+// stride is a block of addresses assigned to registrations of a prefix followed by three letters in alphabetical
+// order.
+type stride struct {
+	start  uint32
+	prefix string
+}
+
+var strides = []stride{
+	{0xC00001, "C-F"},
+	{0xC044A9, "C-G"},
+}
+
+// Registration derives the registration from an address for the states that assign addresses algorithmically: US
+// N-numbers and Canadian C-F and C-G marks.
+func Registration(icao string) (string, bool) {
+	addr, ok := parseIcao(icao)
+	if !ok {
+		return "", false
+	}
+
+	if addr >= nFirst && addr <= nLast {
+		return nNumber(int(addr - nFirst)), true
+	}
+
+	for _, s := range strides {
+		if addr < s.start {
+			continue
+		}
+		i := int(addr - s.start)
+		if i >= 26*26*26 {
+			continue
+		}
+		return s.prefix + string(rune('A'+i/(26*26))) + string(rune('A'+i/26%26)) + string(rune('A'+i%26)), true
+	}
+
+	return "", false
+}
+
+// nNumber returns the US registration with the given offset from the first address, N1.
+func nNumber(i int) string {
+	reg := "N"
+
+	// the first digit is 1 to 9, the others 0 to 9, each followed by either a letter suffix or more digits
+	reg += string(nDigits[i/nBucket1+1])
+	i %= nBucket1
+	for _, bucket := range []int{nBucket2, nBucket3} {
+		if i < nSuffixSize {
+			return reg + nSuffix(i)
+		}
+		i -= nSuffixSize
+		reg += string(nDigits[i/bucket])
+		i %= bucket
+	}
+
+	if i < nSuffixSize {
+		return reg + nSuffix(i)
+	}
+	i -= nSuffixSize
+	reg += string(nDigits[i/nBucket4])
+	i %= nBucket4
+
+	// the fifth character is a letter or a digit
+	if i == 0 {
+		return reg
+	}
+	return reg + string((nLetters + nDigits)[i-1])
+}
+
+// nSuffix returns the letters for an offset within a block of nSuffixSize.
+func nSuffix(i int) string {
+	if i == 0 {
+		return ""
+	}
+
+	first := string(nLetters[(i-1)/(len(nLetters)+1)])
+	second := (i - 1) % (len(nLetters) + 1)
+	if second == 0 {
+		return first
+	}
+	return first + string(nLetters[second-1])
+}
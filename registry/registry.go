@@ -0,0 +1,195 @@
+// Package registry looks up what is known about an aircraft from its ICAO address: registration, type, operator and
+// whether it is military.
+//
+// The database is a CSV file with the header
+//
+//	icao,registration,typecode,manufacturer,model,operator,military
+//
+// optionally gzip compressed. Import builds one from the aircraft databases that can be downloaded from OpenSky
+// Network and tar1090-db.
+//
+// Callsigns are resolved too: LookupAirline finds the operator from the airline designator a callsign starts with, and
+// Routes the origin and destination of a flight number in a local routes file.
+package registry
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// header is the first line of a database file.
+var header = []string{"icao", "registration", "typecode", "manufacturer", "model", "operator", "military"}
+
+// Aircraft is what the database knows about an aircraft. Empty fields are unknown.
+type Aircraft struct {
+	// Icao is the 24 bit address as six upper case hexadecimal digits.
+	Icao         string
+	Registration string
+	// TypeCode is the ICAO type designator, e.g. B738.
+	TypeCode     string
+	Manufacturer string
+	Model        string
+	Operator     string
+	Military     bool
+}
+
+// DB is an aircraft database. It is safe for concurrent lookups once loaded, but not for lookups concurrent with Add
+// or Import.
+type DB struct {
+	aircraft map[string]Aircraft
+}
+
+// New returns an empty database.
+func New() *DB {
+	return &DB{aircraft: make(map[string]Aircraft)}
+}
+
+// Load reads a database file, gzip compressed or not.
+func Load(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	db, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// Read reads a database, gzip compressed or not.
+func Read(r io.Reader) (*DB, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(header)
+
+	first, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(first, ",") != strings.Join(header, ",") {
+		return nil, errors.New("not an aircraft database, the header is missing")
+	}
+
+	db := New()
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return db, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		db.Add(Aircraft{
+			Icao:         rec[0],
+			Registration: rec[1],
+			TypeCode:     rec[2],
+			Manufacturer: rec[3],
+			Model:        rec[4],
+			Operator:     rec[5],
+			Military:     rec[6] == "1",
+		})
+	}
+}
+
+// decompress returns a reader of the uncompressed data if r is gzip compressed.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// Write writes the database in its CSV format, ordered by address.
+func (db *DB) Write(w io.Writer) error {
+	icaos := make([]string, 0, len(db.aircraft))
+	for icao := range db.aircraft {
+		icaos = append(icaos, icao)
+	}
+	sort.Strings(icaos)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, icao := range icaos {
+		a := db.aircraft[icao]
+		military := "0"
+		if a.Military {
+			military = "1"
+		}
+		if err := cw.Write([]string{a.Icao, a.Registration, a.TypeCode, a.Manufacturer, a.Model, a.Operator, military}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// Add merges an aircraft into the database. Fields it leaves empty keep their current value, except Military, which
+// always comes from a. Entries without a valid address are ignored.
+func (db *DB) Add(a Aircraft) {
+	a.Icao = strings.ToUpper(strings.TrimSpace(a.Icao))
+	if !validIcao(a.Icao) {
+		return
+	}
+
+	cur, ok := db.aircraft[a.Icao]
+	if !ok {
+		db.aircraft[a.Icao] = a
+		return
+	}
+
+	merge := func(cur *string, s string) {
+		if s != "" {
+			*cur = s
+		}
+	}
+	merge(&cur.Registration, a.Registration)
+	merge(&cur.TypeCode, a.TypeCode)
+	merge(&cur.Manufacturer, a.Manufacturer)
+	merge(&cur.Model, a.Model)
+	merge(&cur.Operator, a.Operator)
+	cur.Military = a.Military
+	db.aircraft[a.Icao] = cur
+}
+
+func validIcao(icao string) bool {
+	if len(icao) != 6 {
+		return false
+	}
+	for _, c := range icao {
+		if (c < '0' || c > '9') && (c < 'A' || c > 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// Lookup returns what is known about an aircraft, by its address in either case.
+func (db *DB) Lookup(icao string) (Aircraft, bool) {
+	a, ok := db.aircraft[strings.ToUpper(icao)]
+	return a, ok
+}
+
+// Len returns the number of aircraft in the database.
+func (db *DB) Len() int {
+	return len(db.aircraft)
+}
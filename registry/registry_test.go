@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const openSky = `"icao24","registration","manufacturericao","manufacturername","model","typecode","serialnumber","operator","owner"
+"4840d6","PH-BXA","BOEING","The Boeing Company","737-8K2","B738","29131","KLM","KLM Royal Dutch Airlines"
+"40621d","G-EZAB","AIRBUS","Airbus","A319-111","A319","2184","","easyJet Airline"
+"zzzzzz","BAD","","","","","","",""
+`
+
+const tar1090 = `4840D6;PH-BXA;B738;00;BOEING 737-800;1999;KLM;
+AE1234;;C17;10;BOEING C-17 Globemaster 3;;United States Air Force;
+`
+
+func TestImport(t *testing.T) {
+	db := New()
+
+	n, err := db.Import(strings.NewReader(openSky))
+	if err != nil || n != 2 {
+		t.Fatalf("Import incorrect, wanted %v got %v (%v)", 2, n, err)
+	}
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(tar1090))
+	w.Close()
+	if n, err := db.Import(&gz); err != nil || n != 2 {
+		t.Fatalf("Import incorrect, wanted %v got %v (%v)", 2, n, err)
+	}
+
+	tests := []Aircraft{
+		// the tar1090 description replaces the model, the manufacturer is kept
+		{Icao: "4840D6", Registration: "PH-BXA", TypeCode: "B738", Manufacturer: "The Boeing Company", Model: "BOEING 737-800", Operator: "KLM"},
+		{Icao: "40621D", Registration: "G-EZAB", TypeCode: "A319", Manufacturer: "Airbus", Model: "A319-111", Operator: "easyJet Airline"},
+		{Icao: "AE1234", TypeCode: "C17", Model: "BOEING C-17 Globemaster 3", Operator: "United States Air Force", Military: true},
+	}
+	for _, want := range tests {
+		got, ok := db.Lookup(strings.ToLower(want.Icao))
+		if !ok || got != want {
+			t.Fatalf("Lookup incorrect, wanted %+v got %+v", want, got)
+		}
+	}
+
+	if _, err := db.Import(strings.NewReader("something else\n")); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
+
+func TestImportMilitary(t *testing.T) {
+	db := New()
+	if _, err := db.Import(strings.NewReader(tar1090)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// OpenSky has no military flag, the one from tar1090-db is kept
+	if _, err := db.Import(strings.NewReader(openSky + "\"ae1234\",\"05-5140\",\"\",\"\",\"\",\"C17\",\"\",\"\",\"\"\n")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if a, _ := db.Lookup("AE1234"); !a.Military || a.Registration != "05-5140" {
+		t.Fatalf("Lookup incorrect, wanted a military aircraft got %+v", a)
+	}
+
+	// a newer tar1090-db that no longer marks the aircraft clears the flag
+	if _, err := db.Import(strings.NewReader("AE1234;;C17;00;;;;\n")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if a, _ := db.Lookup("AE1234"); a.Military {
+		t.Fatalf("Military incorrect, wanted %v got %v", false, a.Military)
+	}
+}
+
+func TestWriteAndLoad(t *testing.T) {
+	db := New()
+	db.Add(Aircraft{Icao: "4840d6", Registration: "PH-BXA", TypeCode: "B738", Operator: "KLM, Royal Dutch"})
+	db.Add(Aircraft{Icao: "AE1234", TypeCode: "C17", Military: true})
+
+	path := filepath.Join(t.TempDir(), "aircraft.csv.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	w := gzip.NewWriter(f)
+	if err := db.Write(w); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	w.Close()
+	f.Close()
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if loaded.Len() != 2 {
+		t.Fatalf("Len incorrect, wanted %v got %v", 2, loaded.Len())
+	}
+	for _, icao := range []string{"4840D6", "AE1234"} {
+		want, _ := db.Lookup(icao)
+		if got, _ := loaded.Lookup(icao); got != want {
+			t.Fatalf("Lookup incorrect, wanted %+v got %+v", want, got)
+		}
+	}
+
+	if _, err := Read(strings.NewReader("a,b,c,d,e,f,g\n")); err == nil {
+		t.Fatalf("expected an error without the header")
+	}
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Route is where a scheduled flight departs from and arrives at, as ICAO airport codes.
+type Route struct {
+	Callsign    string
+	Origin      string
+	Destination string
+}
+
+// Routes maps callsigns to routes. It is safe for concurrent lookups.
+type Routes struct {
+	routes map[string]Route
+}
+
+// LoadRoutes reads a routes file, gzip compressed or not, see ReadRoutes.
+func LoadRoutes(path string) (*Routes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	routes, err := ReadRoutes(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return routes, nil
+}
+
+// ReadRoutes reads a CSV file of routes. The header names the columns, which can be in any order:
+//   - callsign,origin,destination
+//   - Callsign,AirportCodes as in Virtual Radar Server's routes.csv, where AirportCodes is a dash separated list
+//     such as EHAM-KJFK. Intermediate stops are skipped.
+//
+// Other columns are ignored.
+func ReadRoutes(r io.Reader) (*Routes, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	names, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range names {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	callsign, ok := columns["callsign"]
+	if !ok {
+		return nil, errors.New("routes file without a callsign column")
+	}
+	origin, hasOrigin := columns["origin"]
+	destination, hasDestination := columns["destination"]
+	airports, hasAirports := columns["airportcodes"]
+	if !(hasOrigin && hasDestination) && !hasAirports {
+		return nil, errors.New("routes file without origin and destination or airportcodes columns")
+	}
+
+	routes := &Routes{routes: make(map[string]Route)}
+	field := func(rec []string, i int) string {
+		if i < len(rec) {
+			return strings.ToUpper(strings.TrimSpace(rec[i]))
+		}
+		return ""
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return routes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		route := Route{Callsign: field(rec, callsign)}
+		if hasOrigin && hasDestination {
+			route.Origin = field(rec, origin)
+			route.Destination = field(rec, destination)
+		} else {
+			codes := strings.Split(field(rec, airports), "-")
+			route.Origin = codes[0]
+			route.Destination = codes[len(codes)-1]
+		}
+		if route.Callsign == "" || route.Origin == "" || route.Destination == "" {
+			continue
+		}
+
+		routes.routes[route.Callsign] = route
+	}
+}
+
+// Lookup returns the route flown under a callsign.
+func (r *Routes) Lookup(callsign string) (Route, bool) {
+	route, ok := r.routes[strings.ToUpper(strings.TrimRight(callsign, " #_"))]
+	return route, ok
+}
+
+// Len returns the number of routes.
+func (r *Routes) Len() int {
+	return len(r.routes)
+}
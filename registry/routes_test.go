@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupAirline(t *testing.T) {
+	tests := []struct {
+		callsign  string
+		name      string
+		telephony string
+	}{
+		{"KLM1023", "KLM Royal Dutch Airlines", "KLM"},
+		{"BAW12K  ", "British Airways", "SPEEDBIRD"},
+		{"ezy89ab", "easyJet", "EASY"},
+	}
+
+	for _, test := range tests {
+		got, ok := LookupAirline(test.callsign)
+		if !ok || got.Name != test.name || got.Telephony != test.telephony {
+			t.Fatalf("%v: LookupAirline incorrect, wanted %v (%v) got %+v", test.callsign, test.name, test.telephony, got)
+		}
+	}
+
+	// registrations, unknown designators and callsigns without a flight number
+	for _, callsign := range []string{"PHBXA", "N12345", "XXX123", "KLM", "KLMA12", ""} {
+		if got, ok := LookupAirline(callsign); ok {
+			t.Fatalf("%v: LookupAirline incorrect, wanted none got %+v", callsign, got)
+		}
+	}
+}
+
+func TestReadRoutes(t *testing.T) {
+	files := map[string]string{
+		"own": "callsign,origin,destination\nKLM1023,EHAM,EGLL\nBAW12K,,EGLL\n",
+		"vrs": "Callsign,Code,Number,AirlineCode,AirportCodes\nKLM1023,KL,1023,KLM,EHAM-EGLL\nBAW12K,BA,12,BAW,\n",
+		// stops are skipped
+		"stops": "Callsign,AirportCodes\nKLM1023,EHAM-EBBR-EGLL\n",
+	}
+
+	for name, file := range files {
+		routes, err := ReadRoutes(strings.NewReader(file))
+		if err != nil {
+			t.Fatalf("%v: ReadRoutes failed: %v", name, err)
+		}
+		if routes.Len() != 1 {
+			t.Fatalf("%v: Len incorrect, wanted %v got %v", name, 1, routes.Len())
+		}
+
+		want := Route{Callsign: "KLM1023", Origin: "EHAM", Destination: "EGLL"}
+		if got, ok := routes.Lookup("KLM1023 "); !ok || got != want {
+			t.Fatalf("%v: Lookup incorrect, wanted %+v got %+v", name, want, got)
+		}
+	}
+
+	if _, err := ReadRoutes(strings.NewReader("callsign,from\nKLM1023,EHAM\n")); err == nil {
+		t.Fatalf("expected an error for a file without destinations")
+	}
+}
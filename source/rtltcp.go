@@ -0,0 +1,175 @@
+package source
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/pragmatic-zac/goModeS/demod"
+)
+
+// rtl_tcp commands, each sent as a command byte followed by a big endian 32 bit argument.
+const (
+	rtlSetFrequency  = 0x01
+	rtlSetSampleRate = 0x02
+	rtlSetGainMode   = 0x03
+	rtlSetGain       = 0x04
+	rtlSetFreqCorr   = 0x05
+)
+
+// DefaultFrequency is the Mode S downlink frequency in Hz.
+const DefaultFrequency = 1090000000
+
+// iqRates maps the IQ modes to their sample rates.
+var iqRates = map[string]int{
+	"iq2000": demod.Rate2000,
+	"iq2400": demod.Rate2400,
+}
+
+// tunerNames are the tuner types reported in the rtl_tcp header.
+var tunerNames = []string{"unknown", "E4000", "FC0012", "FC0013", "FC2580", "R820T", "R828D"}
+
+// DongleInfo is the header rtl_tcp sends when a client connects.
+type DongleInfo struct {
+	TunerType  uint32
+	GainLevels uint32
+}
+
+// Tuner returns the name of the tuner.
+func (d DongleInfo) Tuner() string {
+	if int(d.TunerType) < len(tunerNames) {
+		return tunerNames[d.TunerType]
+	}
+	return "unknown"
+}
+
+// RTLTCPOptions configures the dongle behind an rtl_tcp server.
+type RTLTCPOptions struct {
+	// Frequency is the centre frequency in Hz. Defaults to DefaultFrequency.
+	Frequency uint32
+	// SampleRate is the sample rate in Hz, demod.Rate2000 or demod.Rate2400.
+	SampleRate uint32
+	// Gain is the tuner gain in dB. Zero or less selects automatic gain.
+	Gain float64
+	// PPM is the frequency correction in parts per million.
+	PPM int
+}
+
+// RTLTCPConn is a connection to an rtl_tcp server. Reading it returns the IQ samples.
+type RTLTCPConn struct {
+	net.Conn
+	Info DongleInfo
+}
+
+// DialRTLTCP connects to an rtl_tcp server at addr, reads the dongle info and tunes the dongle.
+func DialRTLTCP(ctx context.Context, addr string, opts RTLTCPOptions) (*RTLTCPConn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &RTLTCPConn{Conn: conn}
+	if err := c.handshake(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *RTLTCPConn) handshake(opts RTLTCPOptions) error {
+	var header [12]byte
+	if _, err := io.ReadFull(c.Conn, header[:]); err != nil {
+		return fmt.Errorf("reading rtl_tcp header: %w", err)
+	}
+	if string(header[:4]) != "RTL0" {
+		return errors.New("not an rtl_tcp server")
+	}
+
+	c.Info = DongleInfo{
+		TunerType:  binary.BigEndian.Uint32(header[4:8]),
+		GainLevels: binary.BigEndian.Uint32(header[8:12]),
+	}
+
+	if opts.Frequency == 0 {
+		opts.Frequency = DefaultFrequency
+	}
+
+	if err := c.command(rtlSetSampleRate, opts.SampleRate); err != nil {
+		return err
+	}
+	if err := c.command(rtlSetFrequency, opts.Frequency); err != nil {
+		return err
+	}
+	if opts.PPM != 0 {
+		if err := c.command(rtlSetFreqCorr, uint32(int32(opts.PPM))); err != nil {
+			return err
+		}
+	}
+
+	if opts.Gain <= 0 {
+		return c.command(rtlSetGainMode, 0)
+	}
+	if err := c.command(rtlSetGainMode, 1); err != nil {
+		return err
+	}
+	// the gain is given in tenths of a dB
+	return c.command(rtlSetGain, uint32(math.Round(opts.Gain*10)))
+}
+
+func (c *RTLTCPConn) command(cmd byte, arg uint32) error {
+	var b [5]byte
+	b[0] = cmd
+	binary.BigEndian.PutUint32(b[1:], arg)
+
+	_, err := c.Conn.Write(b[:])
+	return err
+}
+
+// parseRTLTCP parses the query of an rtltcp:// source, e.g. "gain=40&freq=1090000000&ppm=-2". Gain may be "auto".
+func parseRTLTCP(query string) (RTLTCPOptions, error) {
+	var opts RTLTCPOptions
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return opts, err
+	}
+
+	for key := range values {
+		v := values.Get(key)
+		switch key {
+		case "freq":
+			f, err := strconv.ParseUint(v, 10, 32)
+			if err != nil {
+				return opts, fmt.Errorf("invalid frequency %q", v)
+			}
+			opts.Frequency = uint32(f)
+		case "gain":
+			if v == "auto" {
+				continue
+			}
+			g, err := strconv.ParseFloat(v, 64)
+			if err != nil || g < 0 {
+				return opts, fmt.Errorf("invalid gain %q", v)
+			}
+			opts.Gain = g
+		case "ppm":
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return opts, fmt.Errorf("invalid ppm %q", v)
+			}
+			opts.PPM = p
+		default:
+			return opts, fmt.Errorf("unknown rtl_tcp option %q", key)
+		}
+	}
+
+	return opts, nil
+}
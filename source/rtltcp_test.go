@@ -0,0 +1,162 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// iqSamples returns 2 MHz IQ samples of a reply whose pulses line up with the samples, surrounded by silence.
+func iqSamples(msg string) []byte {
+	data, _ := hex.DecodeString(msg)
+
+	// one sample per half microsecond, the preamble first
+	pulses := []bool{true, false, true, false, false, false, false, true, false, true, false, false, false, false, false, false}
+	for n := 0; n < len(data)*8; n++ {
+		one := data[n/8]&(0x80>>uint(n%8)) != 0
+		pulses = append(pulses, one, !one)
+	}
+
+	quiet := []byte{127, 128}
+	var out []byte
+	for i := 0; i < 200; i++ {
+		out = append(out, quiet...)
+	}
+	for _, p := range pulses {
+		if p {
+			out = append(out, 191, 128)
+		} else {
+			out = append(out, quiet...)
+		}
+	}
+	for i := 0; i < 400; i++ {
+		out = append(out, quiet...)
+	}
+
+	return out
+}
+
+// fakeRTLTCP accepts one connection, sends the dongle info, replays samples once the client has sent want bytes of
+// commands and returns the commands on the channel.
+func fakeRTLTCP(t *testing.T, magic string, want int, samples []byte) (string, <-chan []byte) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	commands := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		header := make([]byte, 12)
+		copy(header, magic)
+		binary.BigEndian.PutUint32(header[4:], 5)
+		binary.BigEndian.PutUint32(header[8:], 29)
+		conn.Write(header)
+
+		buf := make([]byte, want)
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			commands <- nil
+			return
+		}
+		commands <- buf
+
+		conn.Write(samples)
+		// keep the connection open like a real server
+		time.Sleep(time.Second)
+	}()
+
+	return ln.Addr().String(), commands
+}
+
+func command(cmd byte, arg uint32) []byte {
+	b := []byte{cmd, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[1:], arg)
+	return b
+}
+
+func TestDialRTLTCP(t *testing.T) {
+	addr, commands := fakeRTLTCP(t, "RTL0", 25, nil)
+
+	conn, err := DialRTLTCP(context.Background(), addr, RTLTCPOptions{SampleRate: 2400000, Gain: 40.2, PPM: -3})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer conn.Close()
+
+	if conn.Info.Tuner() != "R820T" || conn.Info.GainLevels != 29 {
+		t.Fatalf("Info incorrect, wanted R820T with 29 gains got %v with %v", conn.Info.Tuner(), conn.Info.GainLevels)
+	}
+
+	var want []byte
+	want = append(want, command(rtlSetSampleRate, 2400000)...)
+	want = append(want, command(rtlSetFrequency, DefaultFrequency)...)
+	want = append(want, command(rtlSetFreqCorr, 0xFFFFFFFD)...)
+	want = append(want, command(rtlSetGainMode, 1)...)
+	want = append(want, command(rtlSetGain, 402)...)
+
+	got := <-commands
+	if !bytes.Equal(got, want) {
+		t.Fatalf("commands incorrect, wanted %X got %X", want, got)
+	}
+}
+
+func TestDialRTLTCPRejectsOtherServers(t *testing.T) {
+	addr, _ := fakeRTLTCP(t, "HTTP", 0, nil)
+
+	if _, err := DialRTLTCP(context.Background(), addr, RTLTCPOptions{}); err == nil {
+		t.Fatalf("wanted error for a server that is not rtl_tcp")
+	}
+}
+
+func TestRTLTCPSource(t *testing.T) {
+	es := "8D4840D6202CC371C32CE0576098"
+	samples := append(iqSamples(es), iqSamples(es)...)
+
+	// sample rate, frequency and automatic gain
+	addr, commands := fakeRTLTCP(t, "RTL0", 15, samples)
+
+	src, err := Parse("rtltcp://"+addr, testOptions)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	frames := make(chan models.Frame)
+	go src.ReadFrames(ctx, "iq2000", frames)
+
+	got := <-commands
+	want := append(append(command(rtlSetSampleRate, 2000000), command(rtlSetFrequency, DefaultFrequency)...), command(rtlSetGainMode, 0)...)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("commands incorrect, wanted %X got %X", want, got)
+	}
+
+	receive(t, frames, es)
+	receive(t, frames, es)
+}
+
+func TestRTLTCPSourceNeedsIQMode(t *testing.T) {
+	src, err := Parse("rtltcp://localhost:1234", testOptions)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := src.ReadFrames(context.Background(), "beast", nil); err == nil {
+		t.Fatalf("wanted error for mode beast")
+	}
+}
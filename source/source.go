@@ -0,0 +1,369 @@
+// Package source reads receiver data from the places it can come from: a TCP or Unix socket, UDP datagrams, an rtl_tcp
+// server, stdin or a file. Network sources reconnect with exponential backoff when they fail, and every source reports its health.
+package source
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// State is the health of a source.
+type State int
+
+const (
+	// Connecting means the source is being opened.
+	Connecting State = iota
+	// Connected means the source is open and being read.
+	Connected
+	// Waiting means the source failed and will be retried after a backoff.
+	Waiting
+	// Finished means a file or stdin was read to the end.
+	Finished
+	// Failed means the source failed and will not be retried.
+	Failed
+	// Stopped means reading was cancelled.
+	Stopped
+)
+
+func (s State) String() string {
+	switch s {
+	case Connecting:
+		return "connecting"
+	case Connected:
+		return "connected"
+	case Waiting:
+		return "waiting to reconnect"
+	case Finished:
+		return "finished"
+	case Failed:
+		return "failed"
+	case Stopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// Status is a snapshot of a source's health.
+type Status struct {
+	Name  string
+	State State
+	// Since is when the source entered the state.
+	Since time.Time
+	// LastError is the reason the source last failed, if it did.
+	LastError error
+	// Frames is the number of frames or messages read, LastFrame when the last one arrived.
+	Frames    uint64
+	LastFrame time.Time
+	// Reconnects is the number of times the source was reopened after failing.
+	Reconnects int
+}
+
+func (s Status) String() string {
+	str := fmt.Sprintf("%s %s since %s, %d frames", s.Name, s.State, s.Since.Format("15:04:05"), s.Frames)
+	if !s.LastFrame.IsZero() {
+		str += fmt.Sprintf(", last %s ago", time.Since(s.LastFrame).Round(time.Second))
+	}
+	if s.Reconnects > 0 {
+		str += fmt.Sprintf(", %d reconnects", s.Reconnects)
+	}
+	if s.State != Connected && s.LastError != nil {
+		str += ": " + s.LastError.Error()
+	}
+	return str
+}
+
+// stableAfter is how long a connection must stay up for the backoff to start over, when it delivered no data.
+const stableAfter = 10 * time.Second
+
+// Options configures a Source.
+type Options struct {
+	// MinBackoff and MaxBackoff bound the delay between attempts to reopen a failed network source, which doubles
+	// after every failure. Default to 1 second and 30 seconds.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Name identifies the source in its status and in the frames it reads, see models.Frame.Receiver. Defaults to
+	// the spec.
+	Name string
+}
+
+// Source is a place receiver data is read from. Create one with Parse.
+type Source struct {
+	name string
+	// finite sources (files, stdin) end at EOF instead of being reopened
+	finite bool
+	open   func(ctx context.Context) (io.ReadCloser, error)
+	opts   Options
+	// rtl is set for rtl_tcp sources, whose sample rate follows the mode they are read in
+	rtl *RTLTCPOptions
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Parse returns the source described by spec:
+//   - "host:port" or "tcp://host:port" connects to a TCP server.
+//   - "udp://host:port" listens for UDP datagrams on the address.
+//   - "unix:///path/to/socket" connects to a Unix socket.
+//   - "rtltcp://host:port?gain=40&freq=1090000000&ppm=0" connects to an rtl_tcp server and tunes its dongle. All
+//     options are optional, the gain defaults to automatic. Read it in mode iq2000 or iq2400.
+//   - "-" or "stdin" reads standard input.
+//   - "file:///path/to/file" or "file://relative/path" reads a file.
+func Parse(spec string, opts Options) (*Source, error) {
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = 30 * time.Second
+		if opts.MaxBackoff < opts.MinBackoff {
+			opts.MaxBackoff = opts.MinBackoff
+		}
+	}
+
+	if opts.Name == "" {
+		opts.Name = spec
+	}
+
+	s := &Source{name: opts.Name, opts: opts}
+
+	scheme, rest := "tcp", spec
+	if i := strings.Index(spec, "://"); i >= 0 {
+		scheme, rest = spec[:i], spec[i+3:]
+	}
+
+	switch {
+	case spec == "-" || spec == "stdin" || scheme == "stdin":
+		s.finite = true
+		s.open = func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(os.Stdin), nil
+		}
+	case scheme == "file":
+		if rest == "" {
+			return nil, errors.New("missing file path")
+		}
+		s.finite = true
+		s.open = func(context.Context) (io.ReadCloser, error) {
+			return os.Open(rest)
+		}
+	case scheme == "tcp" || scheme == "unix":
+		if rest == "" {
+			return nil, fmt.Errorf("missing %s address", scheme)
+		}
+		s.open = func(ctx context.Context) (io.ReadCloser, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, scheme, rest)
+		}
+	case scheme == "rtltcp":
+		addr, query := rest, ""
+		if i := strings.Index(rest, "?"); i >= 0 {
+			addr, query = rest[:i], rest[i+1:]
+		}
+		if addr == "" {
+			return nil, errors.New("missing rtl_tcp address")
+		}
+		rtl, err := parseRTLTCP(query)
+		if err != nil {
+			return nil, err
+		}
+		s.rtl = &rtl
+		s.open = func(ctx context.Context) (io.ReadCloser, error) {
+			return DialRTLTCP(ctx, addr, *s.rtl)
+		}
+	case scheme == "udp":
+		if rest == "" {
+			return nil, errors.New("missing udp address")
+		}
+		s.open = func(ctx context.Context) (io.ReadCloser, error) {
+			var lc net.ListenConfig
+			conn, err := lc.ListenPacket(ctx, "udp", rest)
+			if err != nil {
+				return nil, err
+			}
+			return packetReader{conn}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported source %q", spec)
+	}
+
+	s.status = Status{Name: s.name, State: Connecting, Since: time.Now()}
+
+	return s, nil
+}
+
+// packetReader reads the payload of successive datagrams.
+type packetReader struct {
+	net.PacketConn
+}
+
+func (p packetReader) Read(b []byte) (int, error) {
+	n, _, err := p.ReadFrom(b)
+	return n, err
+}
+
+// Status returns the source's current health.
+func (s *Source) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+func (s *Source) setState(state State, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.State = state
+	s.status.Since = time.Now()
+	if err != nil {
+		s.status.LastError = err
+	}
+	if state == Waiting {
+		s.status.Reconnects++
+	}
+}
+
+func (s *Source) received() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.Frames++
+	s.status.LastFrame = time.Now()
+}
+
+// ReadFrames reads frames in the given format (see formats.NewReader) and sends them to out until ctx is cancelled or a
+// file or stdin ends. Network sources are reopened whenever they fail.
+func (s *Source) ReadFrames(ctx context.Context, mode string, out chan<- models.Frame) error {
+	if _, err := formats.NewReader(mode, nil); err != nil {
+		return err
+	}
+
+	if s.rtl != nil {
+		rate, ok := iqRates[mode]
+		if !ok {
+			return errors.New("rtl_tcp delivers IQ samples, use mode iq2000 or iq2400")
+		}
+		s.rtl.SampleRate = uint32(rate)
+	}
+
+	return s.run(ctx, func(r io.Reader) error {
+		reader, _ := formats.NewReader(mode, r)
+		for {
+			frame, err := reader.ReadFrame()
+			if err != nil {
+				return err
+			}
+			s.received()
+			frame.Receiver = s.name
+
+			select {
+			case out <- frame:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// ReadSBS reads BaseStation messages and sends them to out, like ReadFrames.
+func (s *Source) ReadSBS(ctx context.Context, out chan<- formats.SBSMessage) error {
+	if s.rtl != nil {
+		return errors.New("rtl_tcp delivers IQ samples, use mode iq2000 or iq2400")
+	}
+
+	return s.run(ctx, func(r io.Reader) error {
+		reader := formats.NewSBSReader(r)
+		for {
+			msg, err := reader.ReadMessage()
+			if err != nil {
+				return err
+			}
+			s.received()
+
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// run opens the source and calls read with it, reopening it with backoff until ctx is cancelled.
+func (s *Source) run(ctx context.Context, read func(r io.Reader) error) error {
+	backoff := s.opts.MinBackoff
+
+	for {
+		s.setState(Connecting, nil)
+
+		rc, err := s.open(ctx)
+		if err == nil {
+			s.setState(Connected, nil)
+			opened, frames := time.Now(), s.Status().Frames
+
+			err = readUntilDone(ctx, rc, read)
+			// a peer that accepts and drops the connection straight away is backed off like one that refuses it
+			if s.Status().Frames > frames || time.Since(opened) >= stableAfter {
+				backoff = s.opts.MinBackoff
+			}
+			if err == nil || errors.Is(err, io.EOF) {
+				if s.finite {
+					s.setState(Finished, nil)
+					return nil
+				}
+				err = errors.New("connection closed")
+			}
+		}
+
+		if ctx.Err() != nil {
+			s.setState(Stopped, nil)
+			return nil
+		}
+		if s.finite {
+			s.setState(Failed, err)
+			return err
+		}
+
+		s.setState(Waiting, err)
+		select {
+		case <-ctx.Done():
+			s.setState(Stopped, nil)
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.opts.MaxBackoff {
+			backoff = s.opts.MaxBackoff
+		}
+	}
+}
+
+// readUntilDone calls read with a buffered rc, closing rc when read returns or ctx is cancelled. It returns as soon as
+// ctx is cancelled, even if read is stuck on a reader that cannot be interrupted, like stdin.
+func readUntilDone(ctx context.Context, rc io.ReadCloser, read func(r io.Reader) error) error {
+	errc := make(chan error, 1)
+	go func() {
+		// large enough for any datagram, the format readers reuse a bufio.Reader of sufficient size
+		errc <- read(bufio.NewReaderSize(rc, 64*1024))
+	}()
+
+	select {
+	case err := <-errc:
+		rc.Close()
+		return err
+	case <-ctx.Done():
+		rc.Close()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,261 @@
+package source
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+const (
+	line1 = "*8D4840D6202CC371C32CE0576098;\n"
+	line2 = "*5D4840D6000000;\n"
+)
+
+var testOptions = Options{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+func receive(t *testing.T, frames <-chan models.Frame, want string) {
+	t.Helper()
+
+	select {
+	case f := <-frames:
+		if f.Message != want {
+			t.Fatalf("Message incorrect, wanted %v got %v", want, f.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %v", want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec   string
+		finite bool
+		valid  bool
+	}{
+		{"localhost:30005", false, true},
+		{"tcp://localhost:30005", false, true},
+		{"udp://:30005", false, true},
+		{"unix:///run/readsb.sock", false, true},
+		{"-", true, true},
+		{"stdin", true, true},
+		{"file:///tmp/capture.bin", true, true},
+		{"rtltcp://pi:1234", false, true},
+		{"rtltcp://pi:1234?gain=40.2&ppm=-3&freq=1090000000", false, true},
+		{"rtltcp://pi:1234?gain=auto", false, true},
+		{"rtltcp://pi:1234?gain=loud", false, false},
+		{"rtltcp://pi:1234?volume=11", false, false},
+		{"rtltcp://", false, false},
+		{"file://", false, false},
+		{"tcp://", false, false},
+		{"http://localhost", false, false},
+	}
+
+	for _, test := range tests {
+		s, err := Parse(test.spec, Options{})
+		if (err == nil) != test.valid {
+			t.Fatalf("%v: error incorrect, wanted valid %v got %v", test.spec, test.valid, err)
+		}
+		if err == nil && s.finite != test.finite {
+			t.Fatalf("%v: finite incorrect, wanted %v got %v", test.spec, test.finite, s.finite)
+		}
+	}
+}
+
+func TestTCPReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	s, err := Parse(ln.Addr().String(), testOptions)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(chan models.Frame)
+	done := make(chan error)
+	go func() { done <- s.ReadFrames(ctx, "raw", frames) }()
+
+	// a frame split over two writes must survive, followed by a dropped connection
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	conn.Write([]byte(line1[:10]))
+	time.Sleep(10 * time.Millisecond)
+	conn.Write([]byte(line1[10:] + line2))
+	receive(t, frames, "8D4840D6202CC371C32CE0576098")
+	receive(t, frames, "5D4840D6000000")
+	conn.Close()
+
+	conn, err = ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(line2))
+	receive(t, frames, "5D4840D6000000")
+
+	st := s.Status()
+	if st.State != Connected || st.Reconnects != 1 || st.Frames != 3 {
+		t.Fatalf("status incorrect, got %v", st)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if st := s.Status(); st.State != Stopped {
+		t.Fatalf("state incorrect, wanted %v got %v", Stopped, st.State)
+	}
+}
+
+func TestTCPBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s, _ := Parse(addr, testOptions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s.ReadFrames(ctx, "raw", make(chan models.Frame))
+
+	// backoffs of 10, 20, 40 and 50 ms fit in 200 ms, without backoff there would be thousands of attempts
+	st := s.Status()
+	if st.Reconnects < 3 || st.Reconnects > 8 {
+		t.Fatalf("reconnects incorrect, wanted 3 to 8 got %v", st.Reconnects)
+	}
+	if st.LastError == nil {
+		t.Fatalf("expected the dial error to be kept")
+	}
+}
+
+func TestTCPBackoffAfterDroppedConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	s, _ := Parse(ln.Addr().String(), testOptions)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ReadFrames(ctx, "raw", make(chan models.Frame))
+
+	// the peer accepts and closes at once, like a full feeder port, so the delays must grow to 10, 20 and 40 ms
+	var accepted []time.Time
+	for i := 0; i < 4; i++ {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatalf("accept: %v", err)
+		}
+		conn.Close()
+		accepted = append(accepted, time.Now())
+	}
+
+	if gap := accepted[3].Sub(accepted[2]); gap < 35*time.Millisecond {
+		t.Fatalf("delay incorrect, wanted about %v got %v", 40*time.Millisecond, gap)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frames.txt")
+	if err := os.WriteFile(path, []byte(line1+"garbage\n"+line2), 0o644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	opts := testOptions
+	opts.Name = "local"
+	s, err := Parse("file://"+path, opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	frames := make(chan models.Frame, 10)
+	if err := s.ReadFrames(context.Background(), "raw", frames); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if f := <-frames; f.Receiver != "local" {
+		t.Fatalf("Receiver incorrect, wanted %v got %v", "local", f.Receiver)
+	}
+	receive(t, frames, "5D4840D6000000")
+	if st := s.Status(); st.State != Finished {
+		t.Fatalf("state incorrect, wanted %v got %v", Finished, st.State)
+	}
+
+	missing, _ := Parse("file://"+path+".missing", testOptions)
+	if err := missing.ReadFrames(context.Background(), "raw", frames); err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if st := missing.Status(); st.State != Failed {
+		t.Fatalf("state incorrect, wanted %v got %v", Failed, st.State)
+	}
+}
+
+func TestUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "beast.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	s, _ := Parse("unix://"+path, testOptions)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := make(chan models.Frame)
+	go s.ReadFrames(ctx, "beast", frames)
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte{0x1A, '1', 0, 0, 0, 0, 0, 1, 0xFF, 0x12, 0x34})
+
+	receive(t, frames, "1234")
+}
+
+func TestUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := pc.LocalAddr().String()
+	pc.Close()
+
+	s, _ := Parse("udp://"+addr, testOptions)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	frames := make(chan models.Frame)
+	go s.ReadFrames(ctx, "raw", frames)
+
+	for s.Status().State != Connected {
+		time.Sleep(time.Millisecond)
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(line1 + line2))
+
+	receive(t, frames, "8D4840D6202CC371C32CE0576098")
+	receive(t, frames, "5D4840D6000000")
+}
@@ -0,0 +1,46 @@
+package streaming
+
+import (
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/coverage"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// WithCoverage records the range of every position decoded from a receiver's frames in c, to measure how far each
+// receiver hears. Copies of a position message from other receivers count for them as well.
+func WithCoverage(c *coverage.Coverage) TrackerOption {
+	return func(t *Tracker) {
+		t.coverage = c
+	}
+}
+
+// Coverage returns the coverage positions are recorded in, nil when there is none.
+func (t *Tracker) Coverage() *coverage.Coverage {
+	return t.coverage
+}
+
+// point is a position as recorded in the coverage, with the altitude in feet.
+type point struct {
+	lat float64
+	lon float64
+	alt int
+}
+
+// pointOf returns the current position of a flight, at altitude 0 when it is on the ground.
+func pointOf(f models.Flight) point {
+	p := point{lat: f.Position.Latitude, lon: f.Position.Longitude, alt: f.Altitude}
+	if f.OnGround {
+		p.alt = 0
+	}
+	return p
+}
+
+// cover records a position in the coverage of the receiver that heard it.
+func (t *Tracker) cover(receiver string, p point, timestamp time.Time) {
+	if t.coverage == nil {
+		return
+	}
+
+	t.coverage.Add(receiver, p.lat, p.lon, p.alt, timestamp)
+}
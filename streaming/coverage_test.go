@@ -0,0 +1,59 @@
+package streaming
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/coverage"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestTrackerCoverage(t *testing.T) {
+	cov := coverage.New(52.0, 4.0)
+	tracker := NewTracker(52.0, 4.0, WithCoverage(cov))
+
+	// the second frame of the pair is heard by the garden receiver as well
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: time.Unix(1457996400, 0), Receiver: "roof"})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: time.Unix(1457996402, 0), Receiver: "roof"})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: time.Unix(1457996402, 0).Add(time.Millisecond), Receiver: "garden"})
+
+	plots := cov.Plots()
+	if len(plots) != 2 || plots[0].Receiver != "garden" || plots[1].Receiver != "roof" {
+		t.Fatalf("Plots incorrect, wanted garden and roof got %v", plots)
+	}
+
+	// about 29 km at 349 degrees, at 38000 ft. The roof receiver also heard the first position, decoded locally,
+	// which is a little further away
+	want := []float64{29124, 29846}
+	for i, p := range plots {
+		if got := p.Cells[4][70]; math.Abs(got.Range-want[i]) > 1 || got.Altitude != 38000 {
+			t.Fatalf("%v: cell incorrect, wanted about %v m at 38000 ft got %+v", p.Receiver, want[i], got)
+		}
+	}
+}
+
+func TestTrackerCoverageDuplicatePosition(t *testing.T) {
+	cov := coverage.New(52.0, 4.0)
+	tracker := NewTracker(52.0, 4.0, WithCoverage(cov))
+
+	// the roof receiver hears a newer position before the garden receiver's copy of the pair's second frame arrives,
+	// the copy counts for the position it carried
+	start := time.Unix(1457996400, 0)
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: start, Receiver: "roof"})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: start.Add(2 * time.Second), Receiver: "roof"})
+	tracker.Update(models.Frame{Message: positionSquitter(0x40621D, 0x0400), Received: start.Add(2500 * time.Millisecond), Receiver: "roof"})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: start.Add(2600 * time.Millisecond), Receiver: "garden"})
+
+	if f, _ := tracker.Flight("40621D"); math.Abs(f.Distance-28600) > 1 {
+		t.Fatalf("Distance incorrect, wanted about %v got %v", 28600, f.Distance)
+	}
+
+	plots := cov.Plots()
+	if len(plots) != 2 || plots[0].Receiver != "garden" {
+		t.Fatalf("Plots incorrect, wanted garden and roof got %v", plots)
+	}
+	if got := plots[0].Cells[4][70]; math.Abs(got.Range-29124) > 1 {
+		t.Fatalf("garden cell incorrect, wanted about %v m got %+v", 29124, got)
+	}
+}
@@ -0,0 +1,120 @@
+package streaming
+
+import (
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// EventType is the kind of change a tracker event describes.
+type EventType int
+
+const (
+	// EventNew is sent when a flight starts being tracked.
+	EventNew EventType = iota
+	// EventUpdated is sent when a tracked flight changes.
+	EventUpdated
+	// EventExpired is sent when a flight was not heard for longer than the expiry and is dropped.
+	EventExpired
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventNew:
+		return "new"
+	case EventUpdated:
+		return "updated"
+	case EventExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a single change to the tracked flights.
+type Event struct {
+	Type EventType
+	// Time is the time of the frame that caused the change, or the tracker's clock for expiries.
+	Time   time.Time
+	Flight models.Flight
+}
+
+// Subscription receives the tracker's events.
+type Subscription struct {
+	// C delivers the events. It is closed when the subscription is cancelled, or when the subscriber fell so far behind
+	// that its buffer filled up; a subscriber that needs a complete picture should then resubscribe and take a new
+	// snapshot with Flights.
+	C <-chan Event
+
+	c       chan Event
+	tracker *Tracker
+}
+
+// Subscribe returns a subscription to the tracker's events with room for buffer pending events. Events are never
+// allowed to block the tracker, so a subscriber that does not keep up is dropped.
+func (t *Tracker) Subscribe(buffer int) *Subscription {
+	c := make(chan Event, buffer)
+	s := &Subscription{C: c, c: c, tracker: t}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.subscribers == nil {
+		t.subscribers = make(map[*Subscription]struct{})
+	}
+	t.subscribers[s] = struct{}{}
+
+	return s
+}
+
+// Cancel stops the subscription and closes C. It is safe to call more than once.
+func (s *Subscription) Cancel() {
+	s.tracker.mu.Lock()
+	defer s.tracker.mu.Unlock()
+
+	s.tracker.unsubscribe(s)
+}
+
+// unsubscribe must be called with the tracker's lock held.
+func (t *Tracker) unsubscribe(s *Subscription) {
+	if _, ok := t.subscribers[s]; ok {
+		delete(t.subscribers, s)
+		close(s.c)
+	}
+}
+
+// publish must be called with the tracker's lock held.
+func (t *Tracker) publish(e Event) {
+	for s := range t.subscribers {
+		select {
+		case s.c <- e:
+		default:
+			t.unsubscribe(s)
+		}
+	}
+}
+
+// publishUpdate sends the event for an applied frame, followed by any expiries it caused.
+func (t *Tracker) publishUpdate(res updateResult, timestamp time.Time, expired []models.Flight) {
+	if len(t.subscribers) == 0 {
+		return
+	}
+
+	if res.accepted {
+		if f, ok := t.flights[res.icao]; ok {
+			e := Event{Type: EventUpdated, Time: timestamp, Flight: f}
+			if res.created {
+				e.Type = EventNew
+			}
+			t.publish(e)
+		}
+	}
+
+	t.publishExpired(timestamp, expired)
+}
+
+func (t *Tracker) publishExpired(timestamp time.Time, expired []models.Flight) {
+	for _, f := range expired {
+		t.publish(Event{Type: EventExpired, Time: timestamp, Flight: f})
+	}
+}
@@ -0,0 +1,62 @@
+package streaming
+
+import (
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestTrackerEvents(t *testing.T) {
+	now := time.Unix(1457996400, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	sub := tracker.Subscribe(10)
+	defer sub.Cancel()
+
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: now})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: now.Add(2 * time.Second)})
+	// unknown surveillance replies do not change anything
+	tracker.Update(models.Frame{Message: "2A00516D492B80", Received: now.Add(3 * time.Second)})
+
+	now = now.Add(2 * time.Minute)
+	tracker.Expire()
+
+	wanted := []EventType{EventNew, EventUpdated, EventExpired}
+	for _, w := range wanted {
+		select {
+		case e := <-sub.C:
+			if e.Type != w {
+				t.Fatalf("event type incorrect, wanted %v got %v", w, e.Type)
+			}
+			if e.Flight.Icao != "40621D" {
+				t.Fatalf("event ICAO incorrect, wanted %v got %v", "40621D", e.Flight.Icao)
+			}
+		default:
+			t.Fatalf("missing %v event", w)
+		}
+	}
+
+	select {
+	case e := <-sub.C:
+		t.Fatalf("unexpected %v event", e.Type)
+	default:
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	tracker := NewTracker(52.0, 4.0)
+
+	sub := tracker.Subscribe(1)
+
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098"})
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098"})
+
+	<-sub.C
+	if _, ok := <-sub.C; ok {
+		t.Fatalf("subscription should be closed after overflowing")
+	}
+
+	// cancelling a dropped subscription is harmless
+	sub.Cancel()
+}
@@ -0,0 +1,202 @@
+package streaming
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// mergeWindow is how long after the first copy of a frame identical frames from other receivers are taken to be
+// copies of the same transmission. It must cover the difference in latency between the receivers.
+const mergeWindow = 2 * time.Second
+
+// delayWeight is the weight of a new observation in the moving average of a receiver's delay.
+const delayWeight = 0.05
+
+// maxDelay is the largest delay a receiver is taken to have. A copy arriving later is not down to network latency.
+const maxDelay = time.Second
+
+// heard is a recently received frame and the receivers that heard it.
+type heard struct {
+	message   string
+	first     time.Time
+	receivers []string
+	// position is the position the first copy produced, if any
+	position *point
+	// unique is set for messages that differ between transmissions, only those give the receivers' delays
+	unique bool
+}
+
+// merger de-duplicates the frames of several receivers. Identical frames from different receivers within the window
+// are one transmission; identical frames from the same receiver are repeats, and are kept. The time between the
+// first copy and the others gives each receiver's delay relative to the fastest one, which is taken off its frame
+// times so the tracker sees a single timeline.
+type merger struct {
+	window time.Duration
+	recent map[string]*heard
+	// queue holds the recent frames, oldest first
+	queue  []*heard
+	delays map[string]time.Duration
+}
+
+func newMerger(window time.Duration) *merger {
+	return &merger{
+		window: window,
+		recent: make(map[string]*heard),
+		delays: make(map[string]time.Duration),
+	}
+}
+
+// add records a frame and reports whether it is the first copy of a transmission. It returns the receive time
+// corrected for the receiver's delay. With a negative window every frame is a transmission of its own.
+func (m *merger) add(frame models.Frame) (time.Time, bool) {
+	if m.window < 0 {
+		return frame.Received, true
+	}
+	m.expire(frame.Received)
+
+	h, ok := m.recent[frame.Message]
+	if ok && !contains(h.receivers, frame.Receiver) {
+		if delay := frame.Received.Sub(h.first); h.unique && delay <= maxDelay {
+			if len(h.receivers) == 1 {
+				// the first receiver was the fastest this time
+				m.observe(h.receivers[0], 0)
+			}
+			m.observe(frame.Receiver, delay)
+		}
+		h.receivers = append(h.receivers, frame.Receiver)
+		return frame.Received.Add(-m.delays[frame.Receiver]), false
+	}
+
+	h = &heard{message: frame.Message, first: frame.Received, receivers: []string{frame.Receiver}, unique: unique(frame.Message)}
+	m.recent[frame.Message] = h
+	m.queue = append(m.queue, h)
+
+	return frame.Received.Add(-m.delays[frame.Receiver]), true
+}
+
+// positioned records the position the first copy of a recent frame produced.
+func (m *merger) positioned(message string, p point) {
+	if h, ok := m.recent[message]; ok {
+		h.position = &p
+	}
+}
+
+// unique reports whether a message differs between transmissions. Airborne position squitters carry the aircraft's
+// movement and alternate between CPR formats, while replies such as all-calls, or an identification that did not
+// change, are repeated word for word and may be matched with another transmission a receiver missed.
+func unique(message string) bool {
+	if len(message) != 28 {
+		return false
+	}
+
+	df, _ := decode.Df(message)
+	if df != 17 && df != 18 {
+		return false
+	}
+	tc, _ := decode.Typecode(message)
+	return tc >= 9 && tc <= 18 || tc >= 20 && tc <= 22
+}
+
+func (m *merger) observe(receiver string, delay time.Duration) {
+	d := m.delays[receiver]
+	m.delays[receiver] = d + time.Duration(float64(delay-d)*delayWeight)
+}
+
+// expire forgets the frames first received more than the window before t.
+func (m *merger) expire(t time.Time) {
+	n := 0
+	for ; n < len(m.queue) && t.Sub(m.queue[n].first) > m.window; n++ {
+		h := m.queue[n]
+		if m.recent[h.message] == h {
+			delete(m.recent, h.message)
+		}
+	}
+	m.queue = append(m.queue[:0], m.queue[n:]...)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// addReceiver records that a receiver heard the flight, keeping the list sorted. The list is copied, as snapshots
+// handed out earlier share it.
+func addReceiver(f *models.Flight, receiver string) {
+	if receiver == "" {
+		return
+	}
+
+	i := sort.SearchStrings(f.Receivers, receiver)
+	if i < len(f.Receivers) && f.Receivers[i] == receiver {
+		return
+	}
+
+	receivers := make([]string, 0, len(f.Receivers)+1)
+	receivers = append(receivers, f.Receivers[:i]...)
+	receivers = append(receivers, receiver)
+	f.Receivers = append(receivers, f.Receivers[i:]...)
+}
+
+// merge de-duplicates a frame and corrects its receive time for the receiver's delay. It reports false for copies of a
+// frame that was already applied, recording only that the receiver heard the flight, and the position the frame
+// carried in its coverage.
+func (t *Tracker) merge(frame *models.Frame) bool {
+	received, first := t.merger.add(*frame)
+	if first {
+		frame.Received = received
+		return true
+	}
+
+	t.metrics.Duplicates++
+	if len(frame.Message) == 14 || len(frame.Message) == 28 {
+		icao, _ := decode.Icao(frame.Message)
+		if f, ok := t.flights[icao]; ok {
+			addReceiver(&f, frame.Receiver)
+			t.flights[icao] = f
+			if p := t.merger.recent[frame.Message].position; p != nil {
+				t.cover(frame.Receiver, *p, frame.Received)
+			}
+		}
+	}
+
+	return false
+}
+
+// WithMergeWindow sets how long identical frames from different receivers are taken to be copies of one
+// transmission. A negative window turns de-duplication off. Defaults to 2 seconds.
+func WithMergeWindow(d time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.merger.window = d
+	}
+}
+
+// HeardBy returns the receivers that heard a recent frame, in the order its copies arrived.
+func (t *Tracker) HeardBy(message string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	h, ok := t.merger.recent[message]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), h.receivers...)
+}
+
+// Delays returns the estimated delay of each receiver's frames relative to the fastest receiver.
+func (t *Tracker) Delays() map[string]time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	delays := make(map[string]time.Duration, len(t.merger.delays))
+	for receiver, d := range t.merger.delays {
+		delays[receiver] = d
+	}
+	return delays
+}
@@ -0,0 +1,145 @@
+package streaming
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// allCall returns a DF11 reply with a valid parity.
+func allCall(icao int) string {
+	prefix := fmt.Sprintf("5D%06X", icao)
+	data, _ := hex.DecodeString(prefix + "000000")
+	return fmt.Sprintf("%s%06X", prefix, decode.CrcBytes(data))
+}
+
+// positionSquitter returns an airborne position squitter with a valid parity, n changes the encoded longitude.
+func positionSquitter(icao int, n int) string {
+	prefix := fmt.Sprintf("8D%06X58C382D690%04X", icao, 0xC8AC^n)
+	data, _ := hex.DecodeString(prefix + "000000")
+	return fmt.Sprintf("%s%06X", prefix, decode.CrcBytes(data))
+}
+
+func TestTrackerMerge(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return start }))
+
+	// receiver b delivers every frame half a second after receiver a
+	delay := 500 * time.Millisecond
+	for i := 0; i < 200; i++ {
+		msg := positionSquitter(0x400000+i, i)
+		at := start.Add(time.Duration(i) * 100 * time.Millisecond)
+
+		if !tracker.Update(models.Frame{Message: msg, Received: at, Receiver: "a"}) {
+			t.Fatalf("%v: first copy should be accepted", msg)
+		}
+		if tracker.Update(models.Frame{Message: msg, Received: at.Add(delay), Receiver: "b"}) {
+			t.Fatalf("%v: second copy should be dropped", msg)
+		}
+	}
+
+	f, _ := tracker.Flight("400000")
+	if !reflect.DeepEqual(f.Receivers, []string{"a", "b"}) {
+		t.Fatalf("Receivers incorrect, wanted %v got %v", []string{"a", "b"}, f.Receivers)
+	}
+	if f.Messages != 1 {
+		t.Fatalf("Messages incorrect, wanted %v got %v", 1, f.Messages)
+	}
+
+	msg := positionSquitter(0x400000+199, 199)
+	if heard := tracker.HeardBy(msg); !reflect.DeepEqual(heard, []string{"a", "b"}) {
+		t.Fatalf("HeardBy incorrect, wanted %v got %v", []string{"a", "b"}, heard)
+	}
+
+	delays := tracker.Delays()
+	if delays["a"] != 0 || delays["b"] < 490*time.Millisecond || delays["b"] > delay {
+		t.Fatalf("Delays incorrect, wanted about %v for b got %v", delay, delays)
+	}
+
+	// a frame only b heard is placed on a's timeline
+	end := start.Add(30 * time.Second)
+	tracker.Update(models.Frame{Message: allCall(0xABCDEF), Received: end.Add(delay), Receiver: "b"})
+	f, _ = tracker.Flight("ABCDEF")
+	if diff := f.FirstSeen.Sub(end); diff < 0 || diff > 10*time.Millisecond {
+		t.Fatalf("FirstSeen incorrect, wanted about %v got %v", end, f.FirstSeen)
+	}
+
+	// repeats from the same receiver are separate transmissions
+	repeat := allCall(0xABCDEF)
+	if !tracker.Update(models.Frame{Message: repeat, Received: end.Add(delay + 100*time.Millisecond), Receiver: "b"}) {
+		t.Fatalf("repeat from the same receiver should be accepted")
+	}
+
+	if m := tracker.Metrics(); m.Duplicates != 200 {
+		t.Fatalf("Duplicates incorrect, wanted %v got %v", 200, m.Duplicates)
+	}
+}
+
+func TestTrackerMergeDisabled(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return start }), WithMergeWindow(-1))
+
+	msg := positionSquitter(0x400000, 0)
+	for _, receiver := range []string{"a", "b"} {
+		if !tracker.Update(models.Frame{Message: msg, Received: start, Receiver: receiver}) {
+			t.Fatalf("%v: copy from %v should be accepted", msg, receiver)
+		}
+	}
+
+	if m := tracker.Metrics(); m.Duplicates != 0 {
+		t.Fatalf("Duplicates incorrect, wanted %v got %v", 0, m.Duplicates)
+	}
+}
+
+func TestTrackerMergeIgnoresLatePositions(t *testing.T) {
+	f := models.Flight{}
+	now := time.Unix(1700000000, 0)
+
+	setPosition(&f, decode.Position{Latitude: 52, Longitude: 4}, now)
+	setPosition(&f, decode.Position{Latitude: 51, Longitude: 3}, now.Add(-time.Second))
+
+	if f.Position.Latitude != 52 || len(f.Track) != 1 {
+		t.Fatalf("Position incorrect, wanted %v got %v", 52.0, f.Position.Latitude)
+	}
+}
+
+func TestTrackerMergeDelaySamples(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return start }))
+
+	// receiver b delivers position squitters 200ms after receiver a
+	at := start
+	for i := 0; i < 100; i++ {
+		msg := positionSquitter(0x400000, i)
+		tracker.Update(models.Frame{Message: msg, Received: at, Receiver: "a"})
+		tracker.Update(models.Frame{Message: msg, Received: at.Add(200 * time.Millisecond), Receiver: "b"})
+		at = at.Add(100 * time.Millisecond)
+	}
+
+	want := tracker.Delays()["b"]
+	if want < 190*time.Millisecond || want > 200*time.Millisecond {
+		t.Fatalf("delay incorrect, wanted about %v got %v", 200*time.Millisecond, want)
+	}
+
+	// an all-call b matches with one a heard a second earlier is no delay sample, nor is a squitter copy too late to be
+	// network latency
+	for i := 0; i < 50; i++ {
+		msg := allCall(0x400000)
+		tracker.Update(models.Frame{Message: msg, Received: at, Receiver: "a"})
+		tracker.Update(models.Frame{Message: msg, Received: at.Add(time.Second / 2), Receiver: "b"})
+
+		msg = positionSquitter(0x400000, 100+i)
+		tracker.Update(models.Frame{Message: msg, Received: at, Receiver: "a"})
+		tracker.Update(models.Frame{Message: msg, Received: at.Add(1500 * time.Millisecond), Receiver: "b"})
+		at = at.Add(2 * time.Second)
+	}
+
+	if got := tracker.Delays()["b"]; got != want {
+		t.Fatalf("delay incorrect, wanted %v got %v", want, got)
+	}
+}
@@ -0,0 +1,145 @@
+package streaming
+
+import (
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/geo"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the decode latency histogram.
+var LatencyBuckets = []float64{1e-6, 2.5e-6, 5e-6, 1e-5, 2.5e-5, 5e-5, 1e-4, 2.5e-4, 5e-4, 1e-3, 1e-2}
+
+// Histogram is a cumulative histogram in the Prometheus sense: Counts[i] is the number of observations less than or
+// equal to Buckets[i].
+type Histogram struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+func (h *Histogram) observe(v float64) {
+	if h.Counts == nil {
+		h.Buckets = LatencyBuckets
+		h.Counts = make([]uint64, len(LatencyBuckets))
+	}
+
+	for i, b := range h.Buckets {
+		if v <= b {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+func (h Histogram) clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// Metrics are the tracker's cumulative counters for monitoring.
+type Metrics struct {
+	// ByDF is the number of Mode S frames received per downlink format, including those that were rejected.
+	ByDF map[int]uint64
+	// ByTypecode is the number of valid extended squitters per typecode.
+	ByTypecode map[int]uint64
+	// CRCFailures is the extended squitters dropped for a CRC error that could not be corrected, CRCCorrected the ones
+	// repaired by flipping a single bit.
+	CRCFailures  uint64
+	CRCCorrected uint64
+	// Duplicates is the frames dropped as copies of a frame already heard by another receiver.
+	Duplicates uint64
+	// PositionsDecoded and PositionsFailed count the position messages that did and did not produce a position.
+	PositionsDecoded uint64
+	PositionsFailed  uint64
+	// ModeACReplies is the valid Mode A/C replies received, ModeACMatched the ones attributed to a Mode S aircraft.
+	ModeACReplies uint64
+	ModeACMatched uint64
+	// MLATPositions is the multilaterated positions applied, MLATRejected the ones dropped because the aircraft
+	// reports its own position.
+	MLATPositions uint64
+	MLATRejected  uint64
+	// MaxRange is the largest distance between the receiver and a decoded position, in meters.
+	MaxRange float64
+	// Latency is the time taken to decode and apply frames, in seconds.
+	Latency Histogram
+}
+
+func (m Metrics) clone() Metrics {
+	c := m
+	c.ByDF = make(map[int]uint64, len(m.ByDF))
+	for k, v := range m.ByDF {
+		c.ByDF[k] = v
+	}
+	c.ByTypecode = make(map[int]uint64, len(m.ByTypecode))
+	for k, v := range m.ByTypecode {
+		c.ByTypecode[k] = v
+	}
+	c.Latency = m.Latency.clone()
+	return c
+}
+
+func (m *Metrics) countFrame(df int, tc int) {
+	if m.ByDF == nil {
+		m.ByDF = make(map[int]uint64)
+		m.ByTypecode = make(map[int]uint64)
+	}
+
+	m.ByDF[df]++
+	if (df == 17 || df == 18) && tc > 0 {
+		m.ByTypecode[tc]++
+	}
+}
+
+// countModeAC records a Mode A/C reply.
+func (m *Metrics) countModeAC(valid bool, matched bool) {
+	if valid {
+		m.ModeACReplies++
+	}
+	if matched {
+		m.ModeACMatched++
+	}
+}
+
+// countPosition records the outcome of a position message.
+func (m *Metrics) countPosition(res updateResult, f models.Flight) {
+	if res.positionFailed {
+		m.PositionsFailed++
+	}
+	if !res.position {
+		return
+	}
+
+	m.PositionsDecoded++
+	// an implausible range would spoil the maximum for good
+	if f.Distance > m.MaxRange && f.Distance <= geo.MaxPlausibleRange {
+		m.MaxRange = f.Distance
+	}
+}
+
+// checkCRC repairs or rejects damaged extended squitters. It reports false for frames that must be dropped.
+func (m *Metrics) checkCRC(frame *models.Frame, df int) bool {
+	if (df != 17 && df != 18) || len(frame.Message) != 28 {
+		return true
+	}
+
+	fixed, bit, err := decode.Correct(frame.Message)
+	if err != nil {
+		m.CRCFailures++
+		return false
+	}
+	if bit >= 0 {
+		m.CRCCorrected++
+		frame.Message = fixed
+	}
+
+	return true
+}
+
+// observeLatency records how long a frame took to apply.
+func (m *Metrics) observeLatency(d time.Duration) {
+	m.Latency.observe(d.Seconds())
+}
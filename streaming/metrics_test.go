@@ -0,0 +1,54 @@
+package streaming
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestTrackerMetrics(t *testing.T) {
+	now := time.Unix(1457996400, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	frames := []string{
+		"8D4840D6202CC371C32CE0576098",
+		// the same with bit 40 flipped, repaired
+		"8D4840D6A02CC371C32CE0576098",
+		// two bits flipped, dropped
+		"8D4840D6A02CC371C32CE0576099",
+		"8D40621D58C386435CC412692AD6",
+		"8D40621D58C382D690C8AC2863A7",
+		"5D4840D6000000",
+		// the identification sent as DF18
+		"904840D6202CC371C32CE02A6C6D",
+	}
+	for _, msg := range frames {
+		tracker.Update(models.Frame{Message: msg, Received: now})
+	}
+
+	m := tracker.Metrics()
+
+	if m.ByDF[17] != 5 || m.ByDF[11] != 1 || m.ByDF[18] != 1 {
+		t.Fatalf("ByDF incorrect, got %v", m.ByDF)
+	}
+	if m.ByTypecode[4] != 3 || m.ByTypecode[11] != 2 {
+		t.Fatalf("ByTypecode incorrect, got %v", m.ByTypecode)
+	}
+	if m.CRCCorrected != 1 || m.CRCFailures != 1 {
+		t.Fatalf("CRC counts incorrect, wanted 1 and 1 got %v and %v", m.CRCCorrected, m.CRCFailures)
+	}
+	if m.PositionsDecoded != 2 || m.PositionsFailed != 0 {
+		t.Fatalf("position counts incorrect, wanted 2 and 0 got %v and %v", m.PositionsDecoded, m.PositionsFailed)
+	}
+	if m.Latency.Count != uint64(len(frames)) {
+		t.Fatalf("latency count incorrect, wanted %v got %v", len(frames), m.Latency.Count)
+	}
+
+	// 52.0,4.0 to 52.26578,3.93891, the odd position decoded locally
+	wantRange := 29846.0
+	if math.Abs(m.MaxRange-wantRange) > 10 {
+		t.Fatalf("MaxRange incorrect, wanted about %v got %v", wantRange, m.MaxRange)
+	}
+}
@@ -0,0 +1,55 @@
+package streaming
+
+import (
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+)
+
+// adsbPrecedence is how long after an ADS-B position multilaterated positions for the same aircraft are ignored. The
+// aircraft's own position is more accurate.
+const adsbPrecedence = 30 * time.Second
+
+// UpdateMLAT applies a position multilaterated from the arrival times of a reply at several receivers, see the mlat
+// package, and reports whether it was accepted. Aircraft that are not tracked yet are created. The position is ignored
+// when the aircraft reported its own position recently.
+func (t *Tracker) UpdateMLAT(icao string, pos decode.Position, timestamp time.Time) bool {
+	if timestamp.IsZero() {
+		timestamp = t.clock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f, tracked := t.flights[icao]
+	if tracked && !f.MLAT && !f.LastPositionTime.IsZero() && timestamp.Sub(f.LastPositionTime) < adsbPrecedence {
+		t.metrics.MLATRejected++
+		return false
+	}
+
+	res := updateResult{icao: icao, accepted: true, created: !tracked, position: true}
+
+	f.Icao = icao
+	if f.FirstSeen.IsZero() {
+		f.FirstSeen = timestamp
+	}
+	if timestamp.After(f.LastSeen) {
+		f.LastSeen = timestamp
+	}
+	setPosition(&f, pos, timestamp)
+	f.MLAT = true
+	t.flights[icao] = f
+	t.enrich(res)
+	t.locate(res)
+
+	c := Counters{Positions: 1}
+	if res.created {
+		c.NewFlights = 1
+	}
+	t.stats.record(timestamp, c)
+	t.metrics.MLATPositions++
+
+	t.publishUpdate(res, timestamp, nil)
+
+	return true
+}
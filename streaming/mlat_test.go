@@ -0,0 +1,45 @@
+package streaming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/formats"
+)
+
+func TestTrackerUpdateMLAT(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	pos := decode.Position{Latitude: 52.1, Longitude: 4.2}
+	if !tracker.UpdateMLAT("ABC123", pos, now) {
+		t.Fatalf("position of an untracked aircraft should be accepted")
+	}
+
+	f, ok := tracker.Flight("ABC123")
+	if !ok || !f.MLAT || f.Position != pos || len(f.Track) != 1 {
+		t.Fatalf("flight incorrect, wanted an MLAT position at %v got %+v", pos, f)
+	}
+
+	// the aircraft reports its own position, which takes precedence for a while
+	lat, lon := 52.2, 4.3
+	tracker.ApplySBS(formats.SBSMessage{Type: formats.SBSAirbornePos, Icao: "ABC123", Latitude: &lat, Longitude: &lon, Generated: now})
+	if f, _ := tracker.Flight("ABC123"); f.MLAT {
+		t.Fatalf("MLAT incorrect, wanted %v got %v", false, f.MLAT)
+	}
+	if tracker.UpdateMLAT("ABC123", pos, now.Add(10*time.Second)) {
+		t.Fatalf("position of an aircraft reporting its own should be rejected")
+	}
+	if !tracker.UpdateMLAT("ABC123", pos, now.Add(time.Minute)) {
+		t.Fatalf("position should be accepted once the reported one is old")
+	}
+
+	m := tracker.Metrics()
+	if m.MLATPositions != 2 || m.MLATRejected != 1 {
+		t.Fatalf("MLAT counts incorrect, wanted 2 and 1 got %v and %v", m.MLATPositions, m.MLATRejected)
+	}
+	if total := tracker.Totals(); total.NewFlights != 1 || total.Positions != 3 {
+		t.Fatalf("totals incorrect, wanted 1 new flight and 3 positions got %+v", total)
+	}
+}
@@ -0,0 +1,107 @@
+package streaming
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// modeCTolerance is how far, in feet, a Mode C altitude may be from a Mode S aircraft's altitude to be attributed to
+// it. Mode C reports in 100 ft steps.
+const modeCTolerance = 100
+
+// ModeACTarget is a Mode A/C code that could not be matched to a Mode S aircraft, typically heard from an aircraft
+// with an older transponder. A reply can be read both as an identity and as an altitude, which of the two it is
+// depends on the interrogation.
+type ModeACTarget struct {
+	// Squawk is the reply read as a Mode A identity, SPI whether the last reply carried the ident pulse.
+	Squawk string
+	SPI    bool
+	// Altitude is the reply read as a Mode C altitude, 0 when it is not a valid altitude.
+	Altitude  int
+	Replies   uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// updateModeAC attributes a Mode A/C reply to the flight with the same squawk, or else the one closest to its Mode C
+// altitude. Other replies are counted as targets of their own. It reports whether the reply was valid and whether it
+// matched a flight.
+func updateModeAC(frame models.Frame, timestamp time.Time, flights map[string]models.Flight, targets map[string]ModeACTarget) (bool, bool) {
+	squawk, spi, err := decode.ModeA(frame.Message)
+	if err != nil {
+		return false, false
+	}
+	alt, err := decode.ModeC(frame.Message)
+	if err != nil {
+		alt = 0
+	}
+
+	var match string
+	best := modeCTolerance + 1
+	for icao, f := range flights {
+		if f.Squawk == squawk {
+			match = icao
+			break
+		}
+		if alt == 0 || f.Altitude == 0 {
+			continue
+		}
+
+		diff := f.Altitude - alt
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < best {
+			match, best = icao, diff
+		}
+	}
+
+	if match != "" {
+		f := flights[match]
+		f.ModeACReplies++
+		flights[match] = f
+		return true, true
+	}
+
+	t := targets[squawk]
+	if t.FirstSeen.IsZero() {
+		t = ModeACTarget{Squawk: squawk, Altitude: alt, FirstSeen: timestamp}
+	}
+	t.SPI = spi
+	t.Replies++
+	if timestamp.After(t.LastSeen) {
+		t.LastSeen = timestamp
+	}
+	targets[squawk] = t
+
+	return true, false
+}
+
+// expireModeAC removes targets last heard more than maxAge before t.
+func expireModeAC(targets map[string]ModeACTarget, t time.Time, maxAge time.Duration) {
+	for squawk, target := range targets {
+		if t.Sub(target.LastSeen) > maxAge {
+			delete(targets, squawk)
+		}
+	}
+}
+
+// ModeAC returns the Mode A/C codes heard recently that did not match a Mode S aircraft, ordered by squawk. Codes
+// heard only once are likely interference.
+func (t *Tracker) ModeAC() []ModeACTarget {
+	t.mu.RLock()
+	targets := make([]ModeACTarget, 0, len(t.modeAC))
+	for _, target := range t.modeAC {
+		targets = append(targets, target)
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].Squawk < targets[j].Squawk
+	})
+
+	return targets
+}
@@ -0,0 +1,56 @@
+package streaming
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestTrackerModeAC(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	squawk := "1200"
+	alt := 10025
+	tracker.ApplySBS(formats.SBSMessage{Type: formats.SBSSurveillanceID, Icao: "ABC123", Squawk: &squawk})
+	tracker.ApplySBS(formats.SBSMessage{Type: formats.SBSSurveillanceAlt, Icao: "DEF456", Altitude: &alt})
+
+	// matched by squawk, by altitude (10000 ft), and twice not at all (Mode C 1000 ft)
+	for _, msg := range []string{"1200", "6520", "0320", "0320"} {
+		if !tracker.Update(models.Frame{Message: msg}) {
+			t.Fatalf("%v: reply should be accepted", msg)
+		}
+	}
+	if tracker.Update(models.Frame{Message: "8000"}) {
+		t.Fatalf("invalid reply should not be accepted")
+	}
+
+	for icao, want := range map[string]int{"ABC123": 1, "DEF456": 1} {
+		f, _ := tracker.Flight(icao)
+		if f.ModeACReplies != want {
+			t.Fatalf("%v: ModeACReplies incorrect, wanted %v got %v", icao, want, f.ModeACReplies)
+		}
+	}
+
+	targets := tracker.ModeAC()
+	if len(targets) != 1 {
+		t.Fatalf("target count incorrect, wanted %v got %v", 1, len(targets))
+	}
+	want := ModeACTarget{Squawk: "0320", Altitude: 1000, Replies: 2, FirstSeen: now, LastSeen: now}
+	if targets[0] != want {
+		t.Fatalf("target incorrect, wanted %+v got %+v", want, targets[0])
+	}
+
+	m := tracker.Metrics()
+	if m.ModeACReplies != 4 || m.ModeACMatched != 2 {
+		t.Fatalf("Mode A/C counts incorrect, wanted 4 and 2 got %v and %v", m.ModeACReplies, m.ModeACMatched)
+	}
+
+	now = now.Add(2 * time.Minute)
+	tracker.Expire()
+	if len(tracker.ModeAC()) != 0 {
+		t.Fatalf("target should have expired")
+	}
+}
@@ -0,0 +1,82 @@
+package streaming
+
+import (
+	"github.com/pragmatic-zac/goModeS/registry"
+)
+
+// Registry looks up aircraft details by ICAO address, see registry.DB.
+type Registry interface {
+	Lookup(icao string) (registry.Aircraft, bool)
+}
+
+// WithRegistry sets the database new flights are looked up in, to fill in their registration, type, operator and
+// military flag.
+func WithRegistry(r Registry) TrackerOption {
+	return func(t *Tracker) {
+		t.registry = r
+	}
+}
+
+// Routes looks up the route flown under a callsign, see registry.Routes.
+type Routes interface {
+	Lookup(callsign string) (registry.Route, bool)
+}
+
+// WithRoutes sets the routes file flights are looked up in when their callsign is known, to fill in their origin and
+// destination.
+func WithRoutes(r Routes) TrackerOption {
+	return func(t *Tracker) {
+		t.routes = r
+	}
+}
+
+// enrich looks up a newly created flight in the registry and resolves the callsign of a flight when it changes.
+func (t *Tracker) enrich(res updateResult) {
+	if res.created {
+		t.lookupAircraft(res.icao)
+	}
+	if res.callsign {
+		t.resolveCallsign(res.icao)
+	}
+}
+
+// lookupAircraft fills in the details of a flight from the registry. Without an entry the country, military blocks
+// and, for some countries, the registration are still known from the address.
+func (t *Tracker) lookupAircraft(icao string) {
+	f := t.flights[icao]
+	f.Country, _ = registry.Country(icao)
+	f.Military = registry.Military(icao)
+	f.Registration, _ = registry.Registration(icao)
+
+	if t.registry != nil {
+		if a, ok := t.registry.Lookup(icao); ok {
+			if a.Registration != "" {
+				f.Registration = a.Registration
+			}
+			f.TypeCode = a.TypeCode
+			f.Manufacturer = a.Manufacturer
+			f.Model = a.Model
+			f.Operator = a.Operator
+			f.Military = f.Military || a.Military
+		}
+	}
+
+	t.flights[icao] = f
+}
+
+// resolveCallsign fills in the airline and route of a flight from its callsign.
+func (t *Tracker) resolveCallsign(icao string) {
+	f := t.flights[icao]
+
+	a, _ := registry.LookupAirline(f.Callsign)
+	f.Airline, f.Telephony = a.Name, a.Telephony
+
+	f.Origin, f.Destination = "", ""
+	if t.routes != nil {
+		if r, ok := t.routes.Lookup(f.Callsign); ok {
+			f.Origin, f.Destination = r.Origin, r.Destination
+		}
+	}
+
+	t.flights[icao] = f
+}
@@ -0,0 +1,70 @@
+package streaming
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+	"github.com/pragmatic-zac/goModeS/registry"
+)
+
+func TestTrackerRegistry(t *testing.T) {
+	db := registry.New()
+	db.Add(registry.Aircraft{Icao: "4840D6", Registration: "PH-BXA", TypeCode: "B738", Manufacturer: "Boeing", Model: "737-8K2", Operator: "KLM"})
+
+	tracker := NewTracker(52.0, 4.0, WithRegistry(db))
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: time.Unix(1457996400, 0)})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: time.Unix(1457996400, 0)})
+
+	f, _ := tracker.Flight("4840D6")
+	if f.Registration != "PH-BXA" || f.TypeCode != "B738" || f.Manufacturer != "Boeing" || f.Model != "737-8K2" || f.Operator != "KLM" {
+		t.Fatalf("registry fields incorrect, got %v %v %v %v %v", f.Registration, f.TypeCode, f.Manufacturer, f.Model, f.Operator)
+	}
+
+	if f, _ := tracker.Flight("40621D"); f.Registration != "" || f.Country != "United Kingdom" {
+		t.Fatalf("flight incorrect, wanted no registration from United Kingdom got %q from %q", f.Registration, f.Country)
+	}
+}
+
+func TestTrackerAllocation(t *testing.T) {
+	tracker := NewTracker(52.0, 4.0)
+
+	// an all-call reply from N12345, and a position of an aircraft in the US military block
+	tracker.Update(models.Frame{Message: allCall(0xA061D9), Received: time.Unix(1457996400, 0)})
+	tracker.ApplySBS(formats.SBSMessage{Type: formats.SBSAirborneVel, Icao: "AE1234", Generated: time.Unix(1457996400, 0)})
+
+	f, _ := tracker.Flight("A061D9")
+	if f.Registration != "N12345" || f.Country != "United States" || f.Military {
+		t.Fatalf("flight incorrect, got %v %v %v", f.Registration, f.Country, f.Military)
+	}
+
+	if f, _ := tracker.Flight("AE1234"); !f.Military || f.Registration != "" {
+		t.Fatalf("flight incorrect, wanted a military aircraft without registration got %v %v", f.Military, f.Registration)
+	}
+}
+
+func TestTrackerRoutes(t *testing.T) {
+	routes, err := registry.ReadRoutes(strings.NewReader("callsign,origin,destination\nKLM1023,EHAM,EGLL\n"))
+	if err != nil {
+		t.Fatalf("ReadRoutes failed: %v", err)
+	}
+
+	tracker := NewTracker(52.0, 4.0, WithRoutes(routes))
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098", Received: time.Unix(1457996400, 0)})
+
+	f, _ := tracker.Flight("4840D6")
+	if f.Airline != "KLM Royal Dutch Airlines" || f.Telephony != "KLM" || f.Origin != "EHAM" || f.Destination != "EGLL" {
+		t.Fatalf("callsign fields incorrect, got %v %v %v %v", f.Airline, f.Telephony, f.Origin, f.Destination)
+	}
+
+	// a new callsign clears the route of the previous one
+	callsign := "BAW12K"
+	tracker.ApplySBS(formats.SBSMessage{Type: formats.SBSIdentification, Icao: "4840D6", Callsign: &callsign, Generated: time.Unix(1457996401, 0)})
+
+	f, _ = tracker.Flight("4840D6")
+	if f.Airline != "British Airways" || f.Telephony != "SPEEDBIRD" || f.Origin != "" || f.Destination != "" {
+		t.Fatalf("callsign fields incorrect, got %v %v %v %v", f.Airline, f.Telephony, f.Origin, f.Destination)
+	}
+}
@@ -0,0 +1,69 @@
+package streaming
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/formats"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// ApplySBS merges a BaseStation message decoded by another receiver into the tracked flights. The message's generated
+// time is used when present.
+func ApplySBS(msg formats.SBSMessage, flightsState map[string]models.Flight) {
+	timestamp := msg.Generated
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	applySBS(msg, timestamp, flightsState)
+	expireCache(flightsState, timestamp, expiry)
+}
+
+func applySBS(msg formats.SBSMessage, timestamp time.Time, flightsState map[string]models.Flight) updateResult {
+	f, tracked := flightsState[msg.Icao]
+	res := updateResult{icao: msg.Icao, accepted: true, created: !tracked}
+
+	f.Icao = msg.Icao
+	if f.FirstSeen.IsZero() {
+		f.FirstSeen = timestamp
+	}
+	if timestamp.After(f.LastSeen) {
+		f.LastSeen = timestamp
+	}
+	f.Messages++
+
+	if msg.Callsign != nil {
+		callsign := strings.TrimSpace(*msg.Callsign)
+		res.callsign = callsign != f.Callsign
+		f.Callsign = callsign
+	}
+	if msg.Altitude != nil {
+		f.Altitude = *msg.Altitude
+	}
+	if msg.Speed != nil {
+		f.Velocity.Speed = *msg.Speed
+		f.Velocity.SpeedType = "GS"
+	}
+	if msg.Track != nil {
+		f.Velocity.Angle = *msg.Track
+	}
+	if msg.VertRate != nil {
+		f.Velocity.VertRate = *msg.VertRate
+	}
+	if msg.OnGround != nil {
+		f.OnGround = *msg.OnGround
+	}
+	if msg.Latitude != nil && msg.Longitude != nil {
+		setPosition(&f, decode.Position{Latitude: *msg.Latitude, Longitude: *msg.Longitude}, timestamp)
+		res.position = true
+	}
+	if msg.Squawk != nil {
+		f.Squawk = *msg.Squawk
+	}
+
+	flightsState[msg.Icao] = f
+
+	return res
+}
@@ -0,0 +1,74 @@
+package streaming
+
+import (
+	"time"
+)
+
+// statsMinutes is how many minutes of statistics the tracker keeps.
+const statsMinutes = 15
+
+// Counters are the number of events the tracker saw.
+type Counters struct {
+	// Frames is every frame offered to the tracker.
+	Frames uint64
+	// Accepted is the frames that updated a flight, or a Mode A/C target.
+	Accepted uint64
+	// Positions is the positions decoded.
+	Positions uint64
+	// NewFlights is the flights that started being tracked.
+	NewFlights uint64
+}
+
+func (c *Counters) add(o Counters) {
+	c.Frames += o.Frames
+	c.Accepted += o.Accepted
+	c.Positions += o.Positions
+	c.NewFlights += o.NewFlights
+}
+
+// Period is the statistics for a span of time.
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Counters
+}
+
+// stats keeps totals and per minute statistics, bucketed by tracker time.
+type stats struct {
+	total   Period
+	minutes [statsMinutes]Period
+}
+
+func (s *stats) record(t time.Time, c Counters) {
+	if s.total.Start.IsZero() || t.Before(s.total.Start) {
+		s.total.Start = t
+	}
+	if t.After(s.total.End) {
+		s.total.End = t
+	}
+	s.total.add(c)
+
+	start := t.Truncate(time.Minute)
+	bucket := &s.minutes[start.Unix()/60%statsMinutes]
+	if !bucket.Start.Equal(start) {
+		if bucket.Start.After(start) {
+			// too old to be kept
+			return
+		}
+		*bucket = Period{Start: start, End: start.Add(time.Minute)}
+	}
+	bucket.add(c)
+}
+
+// since sums the minutes that ended within d before now.
+func (s *stats) since(now time.Time, d time.Duration) Period {
+	p := Period{Start: now.Add(-d), End: now}
+	for _, m := range s.minutes {
+		if m.Start.IsZero() || !m.End.After(p.Start) || m.Start.After(now) {
+			continue
+		}
+		p.add(m.Counters)
+	}
+
+	return p
+}
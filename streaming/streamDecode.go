@@ -6,20 +6,108 @@ import (
 	"time"
 )
 
+// pairWindow is the maximum time between an odd and even airborne position message for them to be decoded as a pair.
+const pairWindow = 10 * time.Second
+
+// expiry is how long a flight is kept after it was last heard.
+const expiry = 60 * time.Second
+
+// maxTrackPoints is the number of recent positions kept per flight.
+const maxTrackPoints = 200
+
 func DecodeAdsB(msg string, flightsState map[string]models.Flight, latRef float64, lonRef float64) {
-	cleanedMsg := decode.CleanMessage(msg)
+	frame := models.Frame{
+		Message:  decode.CleanMessage(msg),
+		Received: time.Now(),
+	}
+
+	DecodeFrame(frame, flightsState, latRef, lonRef)
+}
+
+// DecodeFrame updates the flights with a single frame. The frame's receive time is used for CPR pairing and expiry, so
+// recorded frames give the same result regardless of when they are decoded.
+func DecodeFrame(frame models.Frame, flightsState map[string]models.Flight, latRef float64, lonRef float64) {
+	timestamp := frame.Received
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	if updateFlight(frame, timestamp, flightsState, latRef, lonRef).accepted {
+		expireCache(flightsState, timestamp, expiry)
+	}
+}
+
+// updateResult describes what a single frame changed.
+type updateResult struct {
+	icao     string
+	accepted bool
+	created  bool
+	position bool
+	// positionFailed is set for position messages that did not produce a position
+	positionFailed bool
+	// callsign is set when the callsign of the flight changed
+	callsign bool
+}
+
+// updateFlight applies a frame received at the given time.
+func updateFlight(frame models.Frame, timestamp time.Time, flightsState map[string]models.Flight, latRef float64, lonRef float64) updateResult {
+	var res updateResult
 
+	cleanedMsg := frame.Message
+	if len(cleanedMsg) != 14 && len(cleanedMsg) != 28 {
+		return res
+	}
+
+	df, _ := decode.Df(cleanedMsg)
 	icao, _ := decode.Icao(cleanedMsg)
 	tc, _ := decode.Typecode(cleanedMsg)
-	timestamp := time.Now()
 
-	f := flightsState[icao]
+	if icao == "" {
+		return res
+	}
+
+	f, tracked := flightsState[icao]
+	if !tracked && df != 11 && df != 17 && df != 18 {
+		// the address of surveillance replies is recovered from the parity, so a corrupted reply yields a random
+		// address. Only accept them for aircraft we already know about.
+		return res
+	}
+
+	res.icao = icao
+	res.accepted = true
+	res.created = !tracked
+
 	f.Icao = icao
-	f.LastSeen = timestamp
+	if f.FirstSeen.IsZero() {
+		f.FirstSeen = timestamp
+	}
+	if timestamp.After(f.LastSeen) {
+		f.LastSeen = timestamp
+	}
+	f.Messages++
+	addReceiver(&f, frame.Receiver)
+	if frame.RSSI != 0 {
+		f.RSSI = frame.RSSI
+	}
+
+	if df == 4 || df == 20 {
+		alt, _ := decode.AltitudeCode(cleanedMsg)
+		if alt != 0 {
+			f.Altitude = alt
+		}
+	}
+
+	if df == 5 || df == 21 {
+		squawk, err := decode.Squawk(cleanedMsg)
+		if err == nil {
+			f.Squawk = squawk
+		}
+	}
 
 	if tc >= 1 && tc <= 4 {
 		// identification
 		ident, _ := decode.Callsign(cleanedMsg)
+		res.callsign = ident != f.Callsign
 		f.Callsign = ident
 	}
 
@@ -39,41 +127,113 @@ func DecodeAdsB(msg string, flightsState map[string]models.Flight, latRef float6
 			f.OddMessageTime = timestamp
 		}
 
-		// for now use position with ref, later on add support for position with odd/even message pair
-
 		if tc >= 5 && tc <= 8 {
 			// surface position
-			pos, _ := decode.SurfacePositionWithRef(cleanedMsg, latRef, lonRef)
-			f.Position = pos
+			pos, err := decode.SurfacePositionWithRef(cleanedMsg, latRef, lonRef)
+			f.OnGround = true
+			if err == nil {
+				setPosition(&f, pos, timestamp)
+				res.position = true
+			}
 
 			alt, _ := decode.Altitude(cleanedMsg)
 			if alt != 0 {
 				f.Altitude = alt
 			}
 		} else {
-			// airborne position
-			pos, _ := decode.AirbornePositionWithRef(cleanedMsg, latRef, lonRef)
-			f.Position = pos
-
 			alt, _ := decode.Altitude(cleanedMsg)
 			if alt != 0 {
 				f.Altitude = alt
 			}
+
+			// airborne position, from the odd/even pair when both are recent enough
+			pos, ok := pairedPosition(f)
+			var err error
+			if !ok {
+				pos, err = decode.AirbornePositionWithRef(cleanedMsg, latRef, lonRef)
+			}
+			f.OnGround = false
+			if err == nil {
+				setPosition(&f, pos, timestamp)
+				res.position = true
+			}
 		}
 	}
 
 	// update the flight in the cache
 	flightsState[icao] = f
 
-	expireCache(flightsState, timestamp)
+	return res
+}
+
+// setPosition updates the flight's position and appends it to the track history. Positions older than the current
+// one, which a receiver with more latency than the others delivers late, are ignored.
+func setPosition(f *models.Flight, pos decode.Position, timestamp time.Time) {
+	if timestamp.Before(f.LastPositionTime) {
+		return
+	}
+
+	f.Position = pos
+	f.LastPositionTime = timestamp
+	f.MLAT = false
 
+	f.Track = append(f.Track, models.TrackPoint{
+		Time:      timestamp,
+		Latitude:  pos.Latitude,
+		Longitude: pos.Longitude,
+		Altitude:  f.Altitude,
+		OnGround:  f.OnGround,
+	})
+	if len(f.Track) > maxTrackPoints {
+		// copy so snapshots handed out earlier keep their own history
+		f.Track = append([]models.TrackPoint(nil), f.Track[len(f.Track)-maxTrackPoints:]...)
+	}
 }
 
-func expireCache(flightsState map[string]models.Flight, t time.Time) {
+// pairedPosition decodes the globally unambiguous position from the flight's last odd and even messages.
+func pairedPosition(f models.Flight) (decode.Position, bool) {
+	if f.OddMessage == "" || f.EvenMessage == "" {
+		return decode.Position{}, false
+	}
+
+	diff := f.OddMessageTime.Sub(f.EvenMessageTime)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > pairWindow {
+		return decode.Position{}, false
+	}
+
+	// both messages must be airborne positions
+	tcOdd, _ := decode.Typecode(f.OddMessage)
+	tcEven, _ := decode.Typecode(f.EvenMessage)
+	if tcOdd < 9 || tcOdd > 18 || tcEven < 9 || tcEven > 18 {
+		return decode.Position{}, false
+	}
+
+	pos, err := decode.AirbornePosition(decode.PositionInput{
+		Msg0: f.EvenMessage,
+		Msg1: f.OddMessage,
+		T0:   f.EvenMessageTime,
+		T1:   f.OddMessageTime,
+	})
+	if err != nil || pos == (decode.Position{}) {
+		return decode.Position{}, false
+	}
+
+	return pos, true
+}
+
+// expireCache removes flights last heard more than maxAge before t and returns them.
+func expireCache(flightsState map[string]models.Flight, t time.Time, maxAge time.Duration) []models.Flight {
+	var expired []models.Flight
 	for _, flight := range flightsState {
 		diff := t.Sub(flight.LastSeen)
-		if diff > 60*time.Second {
+		if diff > maxAge {
 			delete(flightsState, flight.Icao)
+			expired = append(expired, flight)
 		}
 	}
+
+	return expired
 }
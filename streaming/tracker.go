@@ -0,0 +1,258 @@
+package streaming
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pragmatic-zac/goModeS/coverage"
+	"github.com/pragmatic-zac/goModeS/decode"
+	"github.com/pragmatic-zac/goModeS/formats"
+	"github.com/pragmatic-zac/goModeS/geo"
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+// Tracker maintains the state of every aircraft heard by a receiver. It is safe for concurrent use.
+//
+// All time based decisions (CPR pairing, expiry) use the receive time carried by each frame. The clock is only
+// consulted for frames without a receive time and when expiring flights between frames, so it can be replaced to
+// replay recorded data.
+type Tracker struct {
+	latRef float64
+	lonRef float64
+	clock  func() time.Time
+	expiry time.Duration
+	// registry, routes and coverage are optional
+	registry Registry
+	routes   Routes
+	coverage *coverage.Coverage
+
+	mu          sync.RWMutex
+	flights     map[string]models.Flight
+	modeAC      map[string]ModeACTarget
+	merger      *merger
+	stats       stats
+	metrics     Metrics
+	subscribers map[*Subscription]struct{}
+}
+
+// TrackerOption configures a Tracker.
+type TrackerOption func(*Tracker)
+
+// WithClock sets the function the tracker uses to tell the current time. Defaults to time.Now.
+func WithClock(clock func() time.Time) TrackerOption {
+	return func(t *Tracker) {
+		t.clock = clock
+	}
+}
+
+// WithExpiry sets how long a flight is kept after it was last heard. Defaults to 60 seconds.
+func WithExpiry(d time.Duration) TrackerOption {
+	return func(t *Tracker) {
+		t.expiry = d
+	}
+}
+
+// NewTracker returns a Tracker that decodes local positions relative to the given receiver location.
+func NewTracker(latRef float64, lonRef float64, opts ...TrackerOption) *Tracker {
+	t := &Tracker{
+		latRef:  latRef,
+		lonRef:  lonRef,
+		clock:   time.Now,
+		expiry:  expiry,
+		flights: make(map[string]models.Flight),
+		modeAC:  make(map[string]ModeACTarget),
+		merger:  newMerger(mergeWindow),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Now returns the tracker's current time.
+func (t *Tracker) Now() time.Time {
+	return t.clock()
+}
+
+// Update applies a received frame and reports whether it was accepted. Frames without a receive time are stamped with
+// the tracker's clock. Extended squitters with a single damaged bit are repaired, other damaged ones are dropped.
+// Mode A/C replies are attributed to a flight with a matching squawk or altitude, or else kept as a ModeACTarget.
+// Copies of a frame heard by several receivers are applied once, see WithMergeWindow.
+func (t *Tracker) Update(frame models.Frame) bool {
+	start := time.Now()
+	if frame.Received.IsZero() {
+		frame.Received = t.clock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer func() {
+		t.metrics.observeLatency(time.Since(start))
+	}()
+
+	if len(frame.Message) == 4 {
+		if !t.merge(&frame) {
+			return false
+		}
+		valid, matched := updateModeAC(frame, frame.Received, t.flights, t.modeAC)
+		t.metrics.countModeAC(valid, matched)
+		t.stats.record(frame.Received, countersFor(updateResult{accepted: valid}))
+		if valid {
+			expireModeAC(t.modeAC, frame.Received, t.expiry)
+		}
+		return valid
+	}
+
+	if len(frame.Message) == 14 || len(frame.Message) == 28 {
+		df, _ := decode.Df(frame.Message)
+		if !t.metrics.checkCRC(&frame, df) {
+			t.metrics.countFrame(df, 0)
+			t.stats.record(frame.Received, Counters{Frames: 1})
+			return false
+		}
+
+		if !t.merge(&frame) {
+			return false
+		}
+
+		tc, _ := decode.Typecode(frame.Message)
+		t.metrics.countFrame(df, int(tc))
+	}
+
+	res := updateFlight(frame, frame.Received, t.flights, t.latRef, t.lonRef)
+	t.enrich(res)
+	t.locate(res)
+	if res.position {
+		p := pointOf(t.flights[res.icao])
+		t.merger.positioned(frame.Message, p)
+		t.cover(frame.Receiver, p, frame.Received)
+	}
+	t.stats.record(frame.Received, countersFor(res))
+	t.metrics.countPosition(res, t.flights[res.icao])
+
+	var expired []models.Flight
+	if res.accepted {
+		expired = expireCache(t.flights, frame.Received, t.expiry)
+	}
+
+	t.publishUpdate(res, frame.Received, expired)
+
+	return res.accepted
+}
+
+func countersFor(res updateResult) Counters {
+	c := Counters{Frames: 1}
+	if res.accepted {
+		c.Accepted = 1
+	}
+	if res.created {
+		c.NewFlights = 1
+	}
+	if res.position {
+		c.Positions = 1
+	}
+
+	return c
+}
+
+// locate updates the distance and bearing from the receiver of a flight whose position changed.
+func (t *Tracker) locate(res updateResult) {
+	if !res.position {
+		return
+	}
+
+	f := t.flights[res.icao]
+	f.Distance = geo.Distance(t.latRef, t.lonRef, f.Position.Latitude, f.Position.Longitude)
+	f.Bearing = geo.Bearing(t.latRef, t.lonRef, f.Position.Latitude, f.Position.Longitude)
+	t.flights[res.icao] = f
+}
+
+// ApplySBS applies a BaseStation message decoded by another receiver. Messages without a generated time are stamped
+// with the tracker's clock.
+func (t *Tracker) ApplySBS(msg formats.SBSMessage) {
+	timestamp := msg.Generated
+	if timestamp.IsZero() {
+		timestamp = t.clock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := applySBS(msg, timestamp, t.flights)
+	t.enrich(res)
+	t.locate(res)
+	t.stats.record(timestamp, countersFor(res))
+
+	expired := expireCache(t.flights, timestamp, t.expiry)
+	t.publishUpdate(res, timestamp, expired)
+}
+
+// Expire removes flights and Mode A/C targets that have not been heard from for longer than the expiry, according to the tracker's clock.
+func (t *Tracker) Expire() {
+	now := t.clock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.publishExpired(now, expireCache(t.flights, now, t.expiry))
+	expireModeAC(t.modeAC, now, t.expiry)
+}
+
+// Receiver returns the receiver location the tracker decodes positions against.
+func (t *Tracker) Receiver() (float64, float64) {
+	return t.latRef, t.lonRef
+}
+
+// Totals returns the statistics since the tracker was created.
+func (t *Tracker) Totals() Period {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stats.total
+}
+
+// Stats returns the statistics for the given time before now, up to 15 minutes, with minute resolution.
+func (t *Tracker) Stats(d time.Duration) Period {
+	now := t.clock()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stats.since(now, d)
+}
+
+// Metrics returns a snapshot of the tracker's monitoring counters.
+func (t *Tracker) Metrics() Metrics {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.metrics.clone()
+}
+
+// Flight returns the current state of a single flight.
+func (t *Tracker) Flight(icao string) (models.Flight, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	f, ok := t.flights[icao]
+	return f, ok
+}
+
+// Flights returns a snapshot of all tracked flights, ordered by ICAO address.
+func (t *Tracker) Flights() []models.Flight {
+	t.mu.RLock()
+	flights := make([]models.Flight, 0, len(t.flights))
+	for _, f := range t.flights {
+		flights = append(flights, f)
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(flights, func(i, j int) bool {
+		return flights[i].Icao < flights[j].Icao
+	})
+
+	return flights
+}
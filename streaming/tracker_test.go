@@ -0,0 +1,87 @@
+package streaming
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	models "github.com/pragmatic-zac/goModeS/models"
+)
+
+func TestTrackerUsesFrameTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	// recorded years before the tracker's clock, must not be expired or unpaired
+	tracker.Update(models.Frame{Message: "8D40621D58C386435CC412692AD6", Received: time.Unix(1457996400, 0)})
+	tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A7", Received: time.Unix(1457996402, 0)})
+
+	f, ok := tracker.Flight("40621D")
+	if !ok {
+		t.Fatalf("flight not tracked")
+	}
+
+	wantedLat := 52.2572
+	wantedLon := 3.91937
+
+	if f.Position.Latitude != wantedLat {
+		t.Fatalf("Latitude incorrect, wanted %v got %v", wantedLat, f.Position.Latitude)
+	}
+	if f.Position.Longitude != wantedLon {
+		t.Fatalf("Longitude incorrect, wanted %v got %v", wantedLon, f.Position.Longitude)
+	}
+	if math.Abs(f.Distance-29124) > 1 || math.Abs(f.Bearing-349.14) > 0.01 {
+		t.Fatalf("Distance and Bearing incorrect, wanted about 29124 and 349.14 got %v and %v", f.Distance, f.Bearing)
+	}
+	if !f.LastSeen.Equal(time.Unix(1457996402, 0)) {
+		t.Fatalf("LastSeen incorrect, wanted frame time got %v", f.LastSeen)
+	}
+
+	tracker.Expire()
+
+	if len(tracker.Flights()) != 0 {
+		t.Fatalf("flight should have expired according to the clock")
+	}
+}
+
+func TestTrackerStampsWithClock(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := NewTracker(52.0, 4.0, WithClock(func() time.Time { return now }))
+
+	tracker.Update(models.Frame{Message: "8D4840D6202CC371C32CE0576098"})
+
+	f, _ := tracker.Flight("4840D6")
+	if !f.LastSeen.Equal(now) {
+		t.Fatalf("LastSeen incorrect, wanted %v got %v", now, f.LastSeen)
+	}
+	if f.Callsign != "KLM1023 " {
+		t.Fatalf("Callsign incorrect, wanted %v got %v", "KLM1023 ", f.Callsign)
+	}
+
+	// surveillance replies are only accepted for known aircraft
+	tracker.Update(models.Frame{Message: "2A00516D492B80"})
+	if len(tracker.Flights()) != 1 {
+		t.Fatalf("surveillance reply from unknown aircraft should be ignored")
+	}
+}
+
+func TestTrackerRepairsExtendedSquitters(t *testing.T) {
+	tracker := NewTracker(52.0, 4.0)
+	received := time.Unix(1457996400, 0)
+
+	// bit 63 of the identification is damaged, and can be repaired
+	if !tracker.Update(models.Frame{Message: "8D4840D6202CC370C32CE0576098", Received: received}) {
+		t.Fatalf("frame with a single damaged bit should be repaired")
+	}
+	if f, _ := tracker.Flight("4840D6"); f.Callsign != "KLM1023 " {
+		t.Fatalf("Callsign incorrect, wanted %v got %v", "KLM1023 ", f.Callsign)
+	}
+
+	// two damaged bits cannot be repaired
+	if tracker.Update(models.Frame{Message: "8D40621D58C382D690C8AC2863A4", Received: received}) {
+		t.Fatalf("frame with two damaged bits should be dropped")
+	}
+	if _, ok := tracker.Flight("40621D"); ok {
+		t.Fatalf("flight of a damaged frame should not be tracked")
+	}
+}